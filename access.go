@@ -0,0 +1,292 @@
+package duit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// Roles of accessibility nodes, see AccessNode.
+const (
+	RoleWindow      = "window"      // The DUI, the root of the tree.
+	RoleGroup       = "group"       // Container of other UIs, e.g. Box, Grid, Split.
+	RoleScroll      = "scroll"      // Scroll, with its visible part as Rect.
+	RoleText        = "text"        // Label.
+	RoleImage       = "image"       // Image.
+	RoleButton      = "button"      // Button.
+	RoleCheckbox    = "checkbox"    // Checkbox.
+	RoleRadioGroup  = "radiogroup"  // Buttongroup.
+	RoleRadio       = "radio"       // Radiobutton, or button of a Buttongroup.
+	RoleTextField   = "textfield"   // Field.
+	RoleTextArea    = "textarea"    // Edit.
+	RoleList        = "list"        // List.
+	RoleListItem    = "listitem"    // Value in a List.
+	RoleTable       = "table"       // Gridlist.
+	RoleRow         = "row"         // Row in a Gridlist.
+	RoleColumnTitle = "columntitle" // Cell in the header of a Gridlist.
+	RoleCell        = "cell"        // Cell in a row of a Gridlist.
+	RoleCombobox    = "combobox"    // Dropdown, Palette.
+	RoleMenu        = "menu"        // Menu.
+	RoleMenuItem    = "menuitem"    // Item in a Menu.
+	RoleSeparator   = "separator"   // Separator in a Menu.
+	RoleDialog      = "dialog"      // Dialog, see DUI.ShowDialog.
+)
+
+// AccessState is the state of a UI, for AccessNode.
+type AccessState struct {
+	Disabled  bool `json:",omitempty"` // Cannot be used.
+	Focused   bool `json:",omitempty"` // Has keyboard focus.
+	Checked   bool `json:",omitempty"` // For checkboxes and radio buttons.
+	Selected  bool `json:",omitempty"` // For list items and rows.
+	Expanded  bool `json:",omitempty"` // For comboboxes with their list of values open, and menu items with their submenu open.
+	Protected bool `json:",omitempty"` // For password fields, Value is left empty.
+}
+
+// AccessNode describes a UI, or a part of a UI like a list item, for assistive technology such as screen readers.
+type AccessNode struct {
+	Role        string          // Kind of UI, one of the Role constants, or a role of a UI outside this package.
+	Name        string          `json:",omitempty"` // Label, e.g. the text of a button.
+	Value       string          `json:",omitempty"` // Current value, e.g. the text of a field.
+	Description string          `json:",omitempty"` // Additional help, the text of a Label set as Kid.Tooltip.
+	ID          string          `json:",omitempty"` // Kid.ID of the UI, if any.
+	Rect        image.Rectangle // Location on the screen. For UIs scrolled out of view, the location they would have on the screen.
+	State       AccessState
+	Children    []*AccessNode `json:",omitempty"`
+}
+
+// Accessible is implemented by UIs that describe themselves for assistive technology, see DUI.AccessTree.
+// UIs that do not implement Accessible are described as a group, with the kids in their exported fields as children.
+type Accessible interface {
+	// Access returns a node describing the UI, with orig the location of self on the screen.
+	// Containers use DUI.AccessKids for the nodes of their kids.
+	// If left empty, Rect, ID, Description and the focused state are set by the caller.
+	Access(dui *DUI, self *Kid, orig image.Point) *AccessNode
+}
+
+// AccessTree returns the accessibility tree for the UI and its overlays.
+// The root is a window node named after the application. Its children are the node for Top, followed by the nodes for the overlays, in drawing order.
+func (d *DUI) AccessTree() *AccessNode {
+	n := &AccessNode{
+		Role:     RoleWindow,
+		Name:     d.name,
+		Rect:     d.Display.ScreenImage.R,
+		Children: []*AccessNode{d.AccessKid(&d.Top, image.ZP)},
+	}
+	for _, o := range d.overlays {
+		n.Children = append(n.Children, d.AccessKid(&o.Kid, o.R.Min))
+	}
+	return n
+}
+
+// AccessKid returns the node for k, with orig the location of k on the screen.
+func (d *DUI) AccessKid(k *Kid, orig image.Point) *AccessNode {
+	var n *AccessNode
+	if a, ok := k.UI.(Accessible); ok {
+		n = a.Access(d, k, orig)
+	} else {
+		n = &AccessNode{Role: RoleGroup}
+		for _, c := range kidsOf(k.UI) {
+			n.Children = append(n.Children, d.AccessKid(c, orig.Add(c.R.Min)))
+		}
+	}
+	if n.Rect.Empty() {
+		n.Rect = rect(k.R.Size()).Add(orig)
+	}
+	if n.ID == "" {
+		n.ID = k.ID
+	}
+	if l, ok := k.Tooltip.(*Label); ok && n.Description == "" {
+		n.Description = l.Text
+	}
+	if k.UI != nil && k.UI == d.focus {
+		n.State.Focused = true
+	}
+	return n
+}
+
+// AccessKids returns the nodes for kids, with orig the location on the screen of the UI holding the kids.
+func (d *DUI) AccessKids(kids []*Kid, orig image.Point) []*AccessNode {
+	l := make([]*AccessNode, len(kids))
+	for i, k := range kids {
+		l[i] = d.AccessKid(k, orig.Add(k.R.Min))
+	}
+	return l
+}
+
+// accessServer publishes the accessibility tree to connected clients.
+type accessServer struct {
+	path    string
+	ln      net.Listener
+	clients map[*accessClient]struct{}
+	last    []byte // Tree last sent to all clients.
+	timer   *Timer // Pending publish after a draw.
+}
+
+type accessClient struct {
+	conn net.Conn
+	msgs chan []byte // Holds at most the latest tree, older trees are dropped for slow clients.
+}
+
+var accessSeq int32
+
+// ServeAccessibility publishes the accessibility tree over a unix domain socket at path, for assistive tools like screen readers.
+// If path is empty, a socket is created in $DUIT_ACCESSIBILITY if set, otherwise in the temporary directory, named duit-<pid>-<n>.sock.
+// Only the user can connect to the socket. The socket is removed when the DUI is closed. NewDUI calls ServeAccessibility with an empty path when $DUIT_ACCESSIBILITY is set.
+//
+// Protocol: after connecting, the client receives the current tree, as AccessNode in JSON on a single line.
+// When the tree changes after a draw, the new tree is sent as another line, at most every 100ms.
+// A client can request the current tree at any time by writing a line, which is otherwise ignored.
+// Slow clients only receive the latest tree.
+func (d *DUI) ServeAccessibility(path string) error {
+	if d.access != nil {
+		return fmt.Errorf("accessibility already served at %s", d.access.path)
+	}
+	if path == "" {
+		dir := os.Getenv("DUIT_ACCESSIBILITY")
+		if dir == "" {
+			dir = os.TempDir()
+		}
+		path = fmt.Sprintf("%s/duit-%d-%d.sock", dir, os.Getpid(), atomic.AddInt32(&accessSeq, 1))
+	}
+	// The socket is created in a new private directory, restricted to the user, and then moved to path.
+	// So other users cannot connect, not even before the permissions are set.
+	dir, err := ioutil.TempDir(filepath.Dir(path), ".duit-access")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "sock")
+	ln, err := net.Listen("unix", tmp)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, 0600); err != nil {
+		ln.Close()
+		return err
+	}
+	os.Remove(path)
+	if err := os.Rename(tmp, path); err != nil {
+		ln.Close()
+		return err
+	}
+	a := &accessServer{path: path, ln: ln, clients: map[*accessClient]struct{}{}}
+	d.access = a
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c := &accessClient{conn, make(chan []byte, 1)}
+			ok := d.call(func() {
+				if d.access != a {
+					conn.Close()
+					return
+				}
+				a.clients[c] = struct{}{}
+				go c.write()
+				go a.read(d, c)
+				c.send(d.accessJSON())
+			})
+			if !ok {
+				conn.Close()
+			}
+		}
+	}()
+	return nil
+}
+
+// write writes trees to the client, until its channel is closed or writing fails.
+func (c *accessClient) write() {
+	defer c.conn.Close()
+	for buf := range c.msgs {
+		if _, err := c.conn.Write(buf); err != nil {
+			return
+		}
+	}
+}
+
+// read sends the current tree for each line the client writes, and removes the client when it disconnects.
+func (a *accessServer) read(d *DUI, c *accessClient) {
+	scanner := bufio.NewScanner(c.conn)
+	for scanner.Scan() {
+		d.call(func() {
+			if _, ok := a.clients[c]; ok {
+				c.send(d.accessJSON())
+			}
+		})
+	}
+	d.call(func() {
+		if _, ok := a.clients[c]; ok {
+			delete(a.clients, c)
+			close(c.msgs)
+		}
+	})
+}
+
+// send queues buf for writing, replacing a tree that was not yet written.
+func (c *accessClient) send(buf []byte) {
+	for {
+		select {
+		case c.msgs <- buf:
+			return
+		default:
+		}
+		select {
+		case <-c.msgs:
+		default:
+		}
+	}
+}
+
+func (d *DUI) accessJSON() []byte {
+	buf, err := json.Marshal(d.AccessTree())
+	if err != nil {
+		log.Printf("duit: encoding accessibility tree: %s\n", err)
+		return nil
+	}
+	return append(buf, '\n')
+}
+
+// accessChanged is called after a draw, and publishes the tree to clients after a short delay.
+func (d *DUI) accessChanged() {
+	a := d.access
+	if a == nil || len(a.clients) == 0 || a.timer != nil {
+		return
+	}
+	a.timer = d.After(nil, 100*time.Millisecond, func() {
+		a.timer = nil
+		buf := d.accessJSON()
+		if bytes.Equal(buf, a.last) {
+			return
+		}
+		a.last = buf
+		for c := range a.clients {
+			c.send(buf)
+		}
+	})
+}
+
+// stopAccess stops serving the accessibility tree, for closing the DUI.
+func (d *DUI) stopAccess() {
+	a := d.access
+	if a == nil {
+		return
+	}
+	d.access = nil
+	a.ln.Close()
+	os.Remove(a.path)
+	for c := range a.clients {
+		close(c.msgs)
+	}
+	a.clients = nil
+}
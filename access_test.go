@@ -0,0 +1,109 @@
+package duit_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/duittest"
+)
+
+// findNode returns the first node with role in the tree of n.
+func findNode(n *duit.AccessNode, role string) *duit.AccessNode {
+	if n.Role == role {
+		return n
+	}
+	for _, c := range n.Children {
+		if x := findNode(c, role); x != nil {
+			return x
+		}
+	}
+	return nil
+}
+
+func TestServeAccessibility(t *testing.T) {
+	dir, err := ioutil.TempDir("", "duit")
+	if err != nil {
+		t.Fatalf("tempdir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	field := &duit.Field{Text: "visible"}
+	password := &duit.Field{Text: "secret", Password: true}
+	kids := duit.NewKids(field, &duit.Box{Kids: duit.NewKids(password)})
+	kids[0].ID = "name"
+	dt := duittest.New(t, &duit.Box{Kids: kids}, &duit.DUIOpts{Dimensions: "200x100"})
+	defer dt.Close()
+
+	path := filepath.Join(dir, "access.sock")
+	if err := dt.DUI.ServeAccessibility(path); err != nil {
+		t.Fatalf("serve accessibility: %s", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat socket: %s", err)
+	}
+	if perm := fi.Mode().Perm(); perm&0077 != 0 {
+		t.Fatalf("socket permissions %o, expected only for the user", perm)
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("dial: %s", err)
+	}
+	defer conn.Close()
+	lines := make(chan []byte)
+	go func() {
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- append([]byte{}, scanner.Bytes()...)
+		}
+		close(lines)
+	}()
+
+	// The tree is sent by the main loop, which the test runs.
+	var line []byte
+	deadline := time.After(5 * time.Second)
+	for line == nil {
+		select {
+		case e := <-dt.DUI.Inputs:
+			dt.DUI.Input(e)
+		case l, ok := <-lines:
+			if !ok {
+				t.Fatalf("connection closed without tree")
+			}
+			line = l
+		case <-deadline:
+			t.Fatalf("no tree received")
+		}
+	}
+
+	if bytes.Contains(line, []byte("secret")) {
+		t.Fatalf("tree contains password")
+	}
+	var root duit.AccessNode
+	if err := json.Unmarshal(line, &root); err != nil {
+		t.Fatalf("parsing tree: %s", err)
+	}
+	if root.Role != duit.RoleWindow || len(root.Children) != 1 {
+		t.Fatalf("root node %s with %d children, expected window with 1 child", root.Role, len(root.Children))
+	}
+	top := root.Children[0]
+	if len(top.Children) != 2 {
+		t.Fatalf("top node with %d children, expected 2", len(top.Children))
+	}
+	if n := top.Children[0]; n.Role != duit.RoleTextField || n.Value != "visible" || n.ID != "name" || n.State.Protected {
+		t.Fatalf("field node %#v", n)
+	}
+	n := findNode(top.Children[1], duit.RoleTextField)
+	if n == nil || n.Value != "" || !n.State.Protected {
+		t.Fatalf("password field node %#v, expected protected without value", n)
+	}
+}
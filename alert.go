@@ -1,20 +1,58 @@
 package duit
 
 import (
+	"context"
 	"fmt"
 	"image"
 )
 
 // Alert creates a new window that show text and a button labeled OK that closes the window.
+// Alert blocks until the window is closed. Programs with an App should use App.Alert, which keeps the other windows responsive. To ask a question within a window, see DUI.Confirm.
 func Alert(text string) (err error) {
-	stop := make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	dui, err := NewDUI("alert", &DUIOpts{Dimensions: "300x200"})
 	if err != nil {
 		return fmt.Errorf("new alert window: %s", err)
 	}
 
-	dui.Top.UI = NewMiddle(SpaceXY(20, 10),
+	dui.Top.UI = alertUI(dui, text, cancel)
+	err = dui.Run(ctx, &RunOpts{
+		Error: func(err error) error {
+			return err
+		},
+	})
+	if err == context.Canceled {
+		return nil
+	}
+	return err
+}
+
+// Alert opens a new window as child of parent, that shows text and a button labeled OK that closes the window.
+// Opts are passed to NewDUI, e.g. with the Backend of the other windows, and can be nil. Without Dimensions, the window is 300x200.
+// Alert returns after opening the window, the window is served by Run.
+func (a *App) Alert(parent *DUI, text string, opts *DUIOpts) error {
+	o := DUIOpts{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Dimensions == "" {
+		o.Dimensions = "300x200"
+	}
+	dui, err := a.NewWindow("alert", &o, parent)
+	if err != nil {
+		return fmt.Errorf("new alert window: %s", err)
+	}
+	dui.Top.UI = alertUI(dui, text, func() {
+		a.Close(dui)
+	})
+	return nil
+}
+
+// alertUI returns the UI for an alert window with text, calling ok when its button is clicked.
+func alertUI(dui *DUI, text string, ok func()) UI {
+	return NewMiddle(SpaceXY(20, 10),
 		&Box{
 			Margin: image.Pt(0, 10),
 			Kids: NewKids(
@@ -29,7 +67,7 @@ func Alert(text string) (err error) {
 						Colorset: &dui.Primary,
 						Text:     "OK",
 						Click: func() (e Event) {
-							stop <- struct{}{}
+							ok()
 							return
 						},
 					},
@@ -37,23 +75,4 @@ func Alert(text string) (err error) {
 			),
 		},
 	)
-	dui.Render()
-
-	for {
-		select {
-		case e := <-dui.Inputs:
-			dui.Input(e)
-
-		case xerr, ok := <-dui.Error:
-			if !ok {
-				return
-			}
-			dui.Close()
-			return xerr
-
-		case <-stop:
-			dui.Close()
-			return
-		}
-	}
 }
@@ -0,0 +1,215 @@
+package duit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	imagedraw "image/draw"
+	"image/png"
+	"time"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// APNG frame control, from an fcTL chunk.
+const (
+	apngDisposeNone       = 0
+	apngDisposeBackground = 1
+	apngDisposePrevious   = 2
+
+	apngBlendSource = 0
+	apngBlendOver   = 1
+)
+
+// apngFrame is a frame of an APNG, with its zlib-compressed image data from IDAT or fdAT chunks.
+type apngFrame struct {
+	r       image.Rectangle
+	delay   time.Duration
+	dispose byte
+	blend   byte
+	data    []byte
+}
+
+// decodeAPNG decodes the frames of an animated PNG in buf, composed with the frame's dispose and blend operations, so each frame can be drawn as is.
+// Package image/png only decodes the default image, so the frames are each decoded as a PNG assembled from the chunks of the APNG.
+// If buf is a PNG without animation, decodeAPNG returns nil frames and no error.
+// LoopCount has the meaning of Animation.LoopCount.
+func decodeAPNG(buf []byte) (frames []*image.RGBA, delays []time.Duration, loopCount int, err error) {
+	if !bytes.HasPrefix(buf, pngSignature) {
+		return nil, nil, 0, fmt.Errorf("not a png")
+	}
+	var ihdr []byte
+	var header [][]byte // Chunks to repeat for each frame, e.g. PLTE and tRNS.
+	var animated bool
+	var plays uint32
+	var l []*apngFrame
+	var frame *apngFrame
+	seenData := false // Whether IDAT was seen, after which header chunks are no longer gathered.
+	b := buf[len(pngSignature):]
+	for len(b) > 0 {
+		if len(b) < 12 {
+			return nil, nil, 0, fmt.Errorf("truncated chunk")
+		}
+		n := binary.BigEndian.Uint32(b[0:4])
+		if uint64(n) > uint64(len(b)-12) {
+			return nil, nil, 0, fmt.Errorf("truncated chunk")
+		}
+		typ := string(b[4:8])
+		data := b[8 : 8+n]
+		chunk := b[:12+n]
+		b = b[12+n:]
+
+		switch typ {
+		case "IHDR":
+			ihdr = data
+		case "acTL":
+			if len(data) != 8 {
+				return nil, nil, 0, fmt.Errorf("bad acTL chunk")
+			}
+			animated = true
+			plays = binary.BigEndian.Uint32(data[4:8])
+		case "fcTL":
+			if len(data) != 26 || len(ihdr) != 13 {
+				return nil, nil, 0, fmt.Errorf("bad fcTL chunk")
+			}
+			frame, err = parseFrameControl(data, ihdr)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			l = append(l, frame)
+		case "IDAT":
+			seenData = true
+			// The default image is the first frame only if an fcTL chunk came before it.
+			if frame != nil && len(l) == 1 {
+				frame.data = append(frame.data, data...)
+			}
+		case "fdAT":
+			if len(data) < 4 || frame == nil {
+				return nil, nil, 0, fmt.Errorf("bad fdAT chunk")
+			}
+			frame.data = append(frame.data, data[4:]...)
+		case "IEND":
+			b = nil
+		default:
+			if !seenData {
+				header = append(header, chunk)
+			}
+		}
+	}
+	if !animated || len(l) == 0 {
+		return nil, nil, 0, nil
+	}
+
+	w := binary.BigEndian.Uint32(ihdr[0:4])
+	h := binary.BigEndian.Uint32(ihdr[4:8])
+	bounds := image.Rect(0, 0, int(w), int(h))
+	canvas := image.NewRGBA(bounds)
+	for i, f := range l {
+		img, err := png.Decode(bytes.NewReader(framePNG(ihdr, header, f)))
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("decoding frame %d: %s", i, err)
+		}
+		dispose := f.dispose
+		var prev *image.RGBA
+		if dispose == apngDisposePrevious {
+			if i == 0 {
+				dispose = apngDisposeBackground
+			} else {
+				prev = image.NewRGBA(bounds)
+				copy(prev.Pix, canvas.Pix)
+			}
+		}
+		op := imagedraw.Src
+		if f.blend == apngBlendOver {
+			op = imagedraw.Over
+		}
+		imagedraw.Draw(canvas, f.r, img, img.Bounds().Min, op)
+		composed := image.NewRGBA(bounds)
+		copy(composed.Pix, canvas.Pix)
+		frames = append(frames, composed)
+		delays = append(delays, f.delay)
+
+		switch dispose {
+		case apngDisposeBackground:
+			imagedraw.Draw(canvas, f.r, image.Transparent, image.ZP, imagedraw.Src)
+		case apngDisposePrevious:
+			canvas = prev
+		}
+	}
+
+	// APNG counts plays, with 0 meaning forever. Animation counts extra loops, like GIF.
+	switch plays {
+	case 0:
+		loopCount = 0
+	case 1:
+		loopCount = -1
+	default:
+		loopCount = int(plays - 1)
+	}
+	return frames, delays, loopCount, nil
+}
+
+// parseFrameControl parses the data of an fcTL chunk, checking the frame fits in the image of ihdr.
+func parseFrameControl(data, ihdr []byte) (*apngFrame, error) {
+	width := binary.BigEndian.Uint32(ihdr[0:4])
+	height := binary.BigEndian.Uint32(ihdr[4:8])
+	w := binary.BigEndian.Uint32(data[4:8])
+	h := binary.BigEndian.Uint32(data[8:12])
+	x := binary.BigEndian.Uint32(data[12:16])
+	y := binary.BigEndian.Uint32(data[16:20])
+	if w == 0 || h == 0 || uint64(x)+uint64(w) > uint64(width) || uint64(y)+uint64(h) > uint64(height) {
+		return nil, fmt.Errorf("fcTL frame outside image")
+	}
+	num := binary.BigEndian.Uint16(data[20:22])
+	den := binary.BigEndian.Uint16(data[22:24])
+	if den == 0 {
+		den = 100
+	}
+	// Like browsers, and like GIF in ReadAnimation, treat very short delays as the common default.
+	delay := time.Duration(num) * time.Second / time.Duration(den)
+	if delay <= 10*time.Millisecond {
+		delay = 100 * time.Millisecond
+	}
+	f := &apngFrame{
+		r:       image.Rect(int(x), int(y), int(x+w), int(y+h)),
+		delay:   delay,
+		dispose: data[24],
+		blend:   data[25],
+	}
+	if f.dispose > apngDisposePrevious || f.blend > apngBlendOver {
+		return nil, fmt.Errorf("bad fcTL dispose or blend operation")
+	}
+	return f, nil
+}
+
+// framePNG returns a PNG for frame f, with the header chunks of the APNG and the size of the frame.
+func framePNG(ihdr []byte, header [][]byte, f *apngFrame) []byte {
+	var b bytes.Buffer
+	b.Write(pngSignature)
+	hdr := append([]byte{}, ihdr...)
+	binary.BigEndian.PutUint32(hdr[0:4], uint32(f.r.Dx()))
+	binary.BigEndian.PutUint32(hdr[4:8], uint32(f.r.Dy()))
+	writeChunk(&b, "IHDR", hdr)
+	for _, c := range header {
+		b.Write(c)
+	}
+	writeChunk(&b, "IDAT", f.data)
+	writeChunk(&b, "IEND", nil)
+	return b.Bytes()
+}
+
+// writeChunk writes a PNG chunk with its length and checksum.
+func writeChunk(b *bytes.Buffer, typ string, data []byte) {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(data)))
+	b.Write(n[:])
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(data)
+	b.WriteString(typ)
+	b.Write(data)
+	binary.BigEndian.PutUint32(n[:], crc.Sum32())
+	b.Write(n[:])
+}
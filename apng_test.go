@@ -0,0 +1,151 @@
+package duit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+)
+
+// testFrame is a frame for makeAPNG.
+type testFrame struct {
+	img            *image.NRGBA
+	delayNum       uint16
+	delayDen       uint16
+	dispose, blend byte
+}
+
+// makeAPNG assembles an APNG of size from the frames, each encoded with package image/png.
+// Frames must encode to the same color type, e.g. by all having transparent pixels.
+func makeAPNG(t *testing.T, size image.Point, plays uint32, frames []testFrame) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	var first []byte
+	b.Write(pngSignature)
+	seq := uint32(0)
+	for i, f := range frames {
+		var fb bytes.Buffer
+		if err := png.Encode(&fb, f.img); err != nil {
+			t.Fatalf("encode frame: %s", err)
+		}
+		var ihdr, idat []byte
+		buf := fb.Bytes()[len(pngSignature):]
+		for len(buf) > 0 {
+			n := binary.BigEndian.Uint32(buf[0:4])
+			switch string(buf[4:8]) {
+			case "IHDR":
+				ihdr = append([]byte{}, buf[8:8+n]...)
+			case "IDAT":
+				idat = append(idat, buf[8:8+n]...)
+			}
+			buf = buf[12+n:]
+		}
+		if i > 0 && !bytes.Equal(ihdr[8:], first[8:]) {
+			t.Fatalf("frame %d encoded with different header", i)
+		}
+		if i == 0 {
+			first = ihdr
+			binary.BigEndian.PutUint32(ihdr[0:4], uint32(size.X))
+			binary.BigEndian.PutUint32(ihdr[4:8], uint32(size.Y))
+			writeChunk(&b, "IHDR", ihdr)
+			actl := make([]byte, 8)
+			binary.BigEndian.PutUint32(actl[0:4], uint32(len(frames)))
+			binary.BigEndian.PutUint32(actl[4:8], plays)
+			writeChunk(&b, "acTL", actl)
+		}
+		r := f.img.Bounds()
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:4], seq)
+		binary.BigEndian.PutUint32(fctl[4:8], uint32(r.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:12], uint32(r.Dy()))
+		binary.BigEndian.PutUint32(fctl[12:16], uint32(r.Min.X))
+		binary.BigEndian.PutUint32(fctl[16:20], uint32(r.Min.Y))
+		binary.BigEndian.PutUint16(fctl[20:22], f.delayNum)
+		binary.BigEndian.PutUint16(fctl[22:24], f.delayDen)
+		fctl[24] = f.dispose
+		fctl[25] = f.blend
+		writeChunk(&b, "fcTL", fctl)
+		seq++
+		if i == 0 {
+			writeChunk(&b, "IDAT", idat)
+		} else {
+			fdat := make([]byte, 4, 4+len(idat))
+			binary.BigEndian.PutUint32(fdat, seq)
+			writeChunk(&b, "fdAT", append(fdat, idat...))
+			seq++
+		}
+	}
+	writeChunk(&b, "IEND", nil)
+	return b.Bytes()
+}
+
+func fill(r image.Rectangle, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestDecodeAPNG(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	green := color.RGBA{0, 0xff, 0, 0xff}
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+
+	// All frames have a transparent pixel, to be encoded with alpha.
+	first := fill(image.Rect(0, 0, 4, 4), red)
+	first.Set(3, 0, color.Transparent)
+	over := fill(image.Rect(1, 1, 3, 3), green)
+	over.Set(1, 1, color.Transparent)
+	last := fill(image.Rect(0, 0, 2, 1), blue)
+	last.Set(1, 0, color.Transparent)
+	buf := makeAPNG(t, image.Pt(4, 4), 3, []testFrame{
+		{img: first, delayNum: 1, delayDen: 10, dispose: apngDisposeNone, blend: apngBlendSource},
+		{img: over, delayNum: 20, delayDen: 0, dispose: apngDisposePrevious, blend: apngBlendOver},
+		{img: last, dispose: apngDisposeBackground, blend: apngBlendSource},
+	})
+	frames, delays, loopCount, err := decodeAPNG(buf)
+	if err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+	if len(frames) != 3 {
+		t.Fatalf("got %d frames, expected 3", len(frames))
+	}
+	if exp := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 100 * time.Millisecond}; len(delays) != 3 || delays[0] != exp[0] || delays[1] != exp[1] || delays[2] != exp[2] {
+		t.Fatalf("delays %v, expected %v", delays, exp)
+	}
+	if loopCount != 2 {
+		t.Fatalf("loop count %d, expected 2", loopCount)
+	}
+
+	check := func(frame int, p image.Point, c color.RGBA) {
+		t.Helper()
+		if got := frames[frame].RGBAAt(p.X, p.Y); got != c {
+			t.Fatalf("frame %d at %v is %v, expected %v", frame, p, got, c)
+		}
+	}
+	check(0, image.Pt(3, 3), red)
+	// Transparent pixel blended over the previous frame.
+	check(1, image.Pt(1, 1), red)
+	check(1, image.Pt(2, 2), green)
+	check(1, image.Pt(3, 3), red)
+	// Previous frame was disposed back to the first frame.
+	check(2, image.Pt(0, 0), blue)
+	check(2, image.Pt(2, 2), red)
+}
+
+func TestDecodePNG(t *testing.T) {
+	var b bytes.Buffer
+	if err := png.Encode(&b, fill(image.Rect(0, 0, 2, 2), color.Black)); err != nil {
+		t.Fatalf("encode: %s", err)
+	}
+	frames, _, _, err := decodeAPNG(b.Bytes())
+	if err != nil || frames != nil {
+		t.Fatalf("png without animation gave %d frames, err %v, expected none", len(frames), err)
+	}
+}
@@ -0,0 +1,188 @@
+package duit
+
+import (
+	"context"
+	"reflect"
+)
+
+// App runs multiple windows, each a DUI, from a single main loop, see Run.
+// Callbacks of all windows are called from that loop, so UIs of one window can safely be changed from callbacks of another window.
+//
+// Windows can have a parent window. Closing a window closes its child windows too.
+// The windows of an App must not be run with DUI.Run.
+type App struct {
+	windows []*DUI
+	parents map[*DUI]*DUI
+}
+
+// NewApp returns a new app, without windows.
+func NewApp() *App {
+	return &App{parents: map[*DUI]*DUI{}}
+}
+
+// NewWindow creates a new window with NewDUI and adds it to the app, see Add.
+func (a *App) NewWindow(name string, opts *DUIOpts, parent *DUI) (*DUI, error) {
+	dui, err := NewDUI(name, opts)
+	if err != nil {
+		return nil, err
+	}
+	a.Add(dui, parent)
+	return dui, nil
+}
+
+// Add adds dui as window to the app, as child window of parent if not nil.
+// The window is rendered and receives inputs from Run. Windows can be added from the main loop while Run is running, e.g. from a button click.
+func (a *App) Add(dui *DUI, parent *DUI) {
+	a.windows = append(a.windows, dui)
+	if parent != nil {
+		a.parents[dui] = parent
+	}
+}
+
+// Windows returns the open windows, in order of adding.
+func (a *App) Windows() []*DUI {
+	return a.windows
+}
+
+// Parent returns the parent window of dui, or nil if it has none.
+func (a *App) Parent(dui *DUI) *DUI {
+	return a.parents[dui]
+}
+
+// Children returns the open child windows of dui.
+func (a *App) Children(dui *DUI) (l []*DUI) {
+	for _, w := range a.windows {
+		if a.parents[w] == dui {
+			l = append(l, w)
+		}
+	}
+	return
+}
+
+// Close closes window dui and its child windows, and removes them from the app.
+// If the last window is closed, Run returns.
+func (a *App) Close(dui *DUI) {
+	a.remove(dui)
+	if !dui.closed {
+		dui.Close()
+	}
+}
+
+// remove removes dui and closes its child windows, after dui was closed.
+func (a *App) remove(dui *DUI) {
+	for _, c := range a.Children(dui) {
+		a.Close(c)
+	}
+	for i, w := range a.windows {
+		if w == dui {
+			copy(a.windows[i:], a.windows[i+1:])
+			a.windows = a.windows[:len(a.windows)-1]
+			break
+		}
+	}
+	delete(a.parents, dui)
+}
+
+func sameWindows(a, b []*DUI) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// closeAll closes all windows.
+func (a *App) closeAll() {
+	for len(a.windows) > 0 {
+		a.Close(a.windows[0])
+	}
+}
+
+// Run runs the main loop for all windows, like DUI.Run does for a single window, and the channels of opts.
+// Run returns nil when the last window is closed. When ctx is canceled or a hook returns an error, all windows are closed and ctx.Err() or the error of the hook is returned.
+// The Error hook of opts is called for errors of all windows. Opts can be nil.
+func (a *App) Run(ctx context.Context, opts *RunOpts) error {
+	if opts == nil {
+		opts = &RunOpts{}
+	}
+	chans, err := opts.chanCases()
+	if err != nil {
+		return err
+	}
+	stop := func(err error) error {
+		a.closeAll()
+		return err
+	}
+
+	// The select cases start with ctx, then the channels of opts, then inputs and errors of each window.
+	caseWindows := 1 + len(chans)
+	cases := append([]reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}}, chans...)
+	var windows []*DUI // Windows in the cases.
+	for {
+		// Windows may have been closed with DUI.Close instead of App.Close, e.g. by a callback.
+		for _, w := range append([]*DUI{}, a.windows...) {
+			if w.closed {
+				a.remove(w)
+			}
+		}
+		if len(a.windows) == 0 {
+			return nil
+		}
+		if !sameWindows(windows, a.windows) {
+			for _, w := range a.windows {
+				w.Render()
+			}
+			windows = append([]*DUI{}, a.windows...)
+			cases = cases[:caseWindows]
+			for _, w := range windows {
+				cases = append(cases,
+					reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(w.Inputs)},
+					reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(w.Error)},
+				)
+			}
+		}
+
+		i, v, ok := reflect.Select(cases)
+		switch {
+		case i == 0:
+			return stop(ctx.Err())
+
+		case i < caseWindows:
+			if err := opts.receive(i-1, &cases[i], v, ok); err != nil {
+				return stop(err)
+			}
+			for _, w := range a.windows {
+				if !w.closed {
+					w.Render()
+				}
+			}
+
+		case (i-caseWindows)%2 == 0:
+			w := windows[(i-caseWindows)/2]
+			if !w.closed {
+				w.Input(v.Interface().(Input))
+			}
+			// Callbacks may have changed UIs of other windows.
+			for _, w := range a.windows {
+				if !w.closed {
+					w.Render()
+				}
+			}
+
+		default:
+			w := windows[(i-caseWindows)/2]
+			if !ok {
+				// Window was closed.
+				a.remove(w)
+				continue
+			}
+			if err := opts.error(v.Interface().(error)); err != nil {
+				return stop(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package duit_test
+
+import (
+	"context"
+	"image"
+	"testing"
+	"time"
+
+	"9fans.net/go/draw"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/devdraw"
+	"github.com/mjl-/duit/duittest"
+)
+
+// waitScreen waits until the screen of h differs from prev, returning the new screen. If prev is nil, it waits for the screen to be created.
+func waitScreen(t *testing.T, h *devdraw.Headless, prev *image.RGBA) *image.RGBA {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		img := h.Screenshot()
+		if img != nil && prev == nil {
+			return img
+		}
+		if img != nil {
+			if _, n := duittest.Diff(prev, img); n > 0 {
+				return img
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("screen did not change")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func click(h *devdraw.Headless, p image.Point) {
+	h.SendMouse(draw.Mouse{Point: p, Buttons: 1})
+	h.SendMouse(draw.Mouse{Point: p})
+}
+
+func TestApp(t *testing.T) {
+	a := duit.NewApp()
+	h1, h2, h3 := devdraw.NewHeadless(), devdraw.NewHeadless(), devdraw.NewHeadless()
+	w1, err := a.NewWindow("one", &duit.DUIOpts{Dimensions: "200x100", Backend: h1}, nil)
+	if err != nil {
+		t.Fatalf("new window: %s", err)
+	}
+	w2, err := a.NewWindow("two", &duit.DUIOpts{Dimensions: "200x100", Backend: h2}, w1)
+	if err != nil {
+		t.Fatalf("new window: %s", err)
+	}
+	if a.Parent(w2) != w1 || len(a.Children(w1)) != 1 {
+		t.Fatalf("window two not a child of window one")
+	}
+
+	// The first click in window one changes the label in window two, the second opens an alert.
+	label := &duit.Label{Text: "before"}
+	w2.Top.UI = &duit.Box{Kids: duit.NewKids(label)}
+	clicks := 0
+	button := &duit.Button{Text: "change", Click: func() (e duit.Event) {
+		clicks++
+		if clicks == 1 {
+			label.Text = "after"
+			w2.MarkLayout(label)
+		} else if err := a.Alert(w1, "alert", &duit.DUIOpts{Backend: h3}); err != nil {
+			t.Errorf("alert: %s", err)
+		}
+		return
+	}}
+	box := &duit.Box{Kids: duit.NewKids(button)}
+	w1.Top.UI = box
+	w1.Render()
+	w2.Render()
+	two := h2.Screenshot()
+	buttonCenter := box.Kids[0].R.Min.Add(box.Kids[0].R.Size().Div(2))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- a.Run(ctx, nil)
+	}()
+
+	click(h1, buttonCenter)
+	waitScreen(t, h2, two)
+	click(h1, buttonCenter)
+	alert := waitScreen(t, h3, nil)
+	if size := alert.Bounds().Size(); size != image.Pt(300, 200) {
+		t.Fatalf("alert window size %v, expected 300x200", size)
+	}
+
+	cancel()
+	select {
+	case err := <-errc:
+		if err != context.Canceled {
+			t.Fatalf("run returned %v, expected context canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("run did not return after cancel")
+	}
+	if len(a.Windows()) != 0 {
+		t.Fatalf("%d windows open after run, expected none", len(a.Windows()))
+	}
+}
@@ -0,0 +1,25 @@
+package duit
+
+import (
+	"9fans.net/go/draw"
+)
+
+// Backend connects a DUI to a display with mouse and keyboard.
+// The default backend starts devdraw, which shows a window.
+// Package github.com/mjl-/duit/devdraw has a headless backend that draws into memory, useful for testing.
+type Backend interface {
+	// Init connects to a new display, for a window with label and dimensions (eg "800x600"), and fontName as default font (empty for the builtin font).
+	// Errors from the display are sent on errch. After the display is gone, io.EOF is sent.
+	Init(errch chan<- error, fontName, label, dimensions string) (*draw.Display, *draw.Mousectl, *draw.Keyboardctl, error)
+}
+
+// devdrawBackend is the default backend, connecting to devdraw.
+type devdrawBackend struct{}
+
+func (devdrawBackend) Init(errch chan<- error, fontName, label, dimensions string) (*draw.Display, *draw.Mousectl, *draw.Keyboardctl, error) {
+	display, err := draw.Init(errch, fontName, label, dimensions)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return display, display.InitMouse(), display.InitKeyboard(), nil
+}
@@ -0,0 +1,312 @@
+package duit
+
+import (
+	"reflect"
+	"sync"
+)
+
+// observable is the implementation of the typed observable values, like StringValue.
+// The value can be read and set from any goroutine. Bindings and observers are only used from the main loop.
+type observable struct {
+	dui       *DUI
+	mu        sync.Mutex
+	value     interface{}
+	pending   bool        // Whether update was sent to DUI.Call and has not yet run.
+	notified  interface{} // Value bindings and observers were last updated with.
+	bindings  []*binding
+	observers []func(v interface{})
+}
+
+// binding connects a UI to an observable.
+type binding struct {
+	ui    UI
+	o     *observable
+	read  func() interface{}       // Returns the value from the UI, after the user changed it.
+	apply func(v interface{}) bool // Sets the value on the UI and marks the UI for layout or draw. Returns whether the UI changed.
+}
+
+func (o *observable) get() interface{} {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.value
+}
+
+// set stores v, and updates bindings and observers from the main loop through DUI.Call.
+// Multiple sets before the update runs result in a single update with the latest value.
+func (o *observable) set(v interface{}) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.value = v
+	if o.pending {
+		return
+	}
+	o.pending = true
+	// Not sending from this goroutine: set may be called from the main loop, which must keep reading inputs for DUI.Call to be delivered.
+	go func() {
+		o.dui.call(o.update)
+	}()
+}
+
+// update applies the current value to all bindings and calls the observers. Called from the main loop.
+func (o *observable) update() {
+	o.mu.Lock()
+	v := o.value
+	o.pending = false
+	o.mu.Unlock()
+	o.notify(v, nil)
+}
+
+// changedBy is called from the main loop after the user changed the value in ui.
+func (o *observable) changedBy(ui UI, v interface{}) {
+	o.mu.Lock()
+	o.value = v
+	o.mu.Unlock()
+	o.notify(v, ui)
+}
+
+// notify applies v to all bindings except the one for ui, and calls the observers if v changed.
+func (o *observable) notify(v interface{}, ui UI) {
+	changed := ui != nil || !equalValues(v, o.notified)
+	for _, b := range o.bindings {
+		if b.ui != ui && b.apply(v) {
+			changed = true
+		}
+	}
+	o.notified = v
+	if !changed {
+		return
+	}
+	for _, fn := range o.observers {
+		fn(v)
+	}
+}
+
+func equalValues(a, b interface{}) bool {
+	if a == nil || b == nil || reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// bind connects ui to o, replacing an earlier binding of ui, and applies the current value to ui.
+func (o *observable) bind(ui UI, read func() interface{}, apply func(v interface{}) bool) {
+	d := o.dui
+	d.Unbind(ui)
+	b := &binding{ui, o, read, apply}
+	if d.bindings == nil {
+		d.bindings = map[UI]*binding{}
+	}
+	d.bindings[ui] = b
+	o.bindings = append(o.bindings, b)
+	apply(o.get())
+}
+
+// valueChanged is called by UIs after the user changed their value, e.g. the text of a Field, before calling their Changed function.
+func (d *DUI) valueChanged(ui UI) {
+	if b, ok := d.bindings[ui]; ok {
+		b.o.changedBy(ui, b.read())
+	}
+}
+
+// markBound marks ui for layout or draw, if it is in the UI. Values can be bound to UIs that are not currently shown, such as the UI of an inactive tab, or before the Top UI is set.
+func (d *DUI) markBound(ui UI, forLayout bool) {
+	if d.Top.UI != nil {
+		d.mark(ui, forLayout)
+	}
+}
+
+// Unbind removes the binding of ui to an observable value, such as made with StringValue.BindField.
+// The DUI and the value keep a bound UI in memory until Unbind, also after the UI was removed from the UI tree.
+// Unlike timers, bindings are not dropped for UIs that are not in the UI tree, because bound UIs can be hidden and shown again, like the UI of an inactive tab.
+// So unbind UIs that are removed for good, e.g. on a reload with LoadUI.
+// Unbind must be called from the main loop.
+func (d *DUI) Unbind(ui UI) {
+	b, ok := d.bindings[ui]
+	if !ok {
+		return
+	}
+	delete(d.bindings, ui)
+	l := b.o.bindings[:0]
+	for _, e := range b.o.bindings {
+		if e != b {
+			l = append(l, e)
+		}
+	}
+	b.o.bindings = l
+}
+
+// StringValue is an observable string, for binding to UIs like Field. Create one with NewStringValue.
+//
+// Get and Set can be called from any goroutine. Set updates the bound UIs and calls the observers in the main loop, through DUI.Call.
+// When the user changes the value in a bound UI, the other bound UIs are updated and the observers called immediately, before the Changed function of the UI is called.
+// Binding and observing must be done from the main loop. Bound UIs are kept in memory until they are unbound, see DUI.Unbind.
+type StringValue struct {
+	o observable
+}
+
+// NewStringValue returns a new observable string with initial value v.
+func NewStringValue(dui *DUI, v string) *StringValue {
+	return &StringValue{observable{dui: dui, value: v, notified: v}}
+}
+
+// Get returns the current value, including a value passed to Set whose update has not yet run.
+func (v *StringValue) Get() string {
+	return v.o.get().(string)
+}
+
+// Set changes the value. Bound UIs are updated and marked for draw or layout, and observers are called, from the main loop.
+func (v *StringValue) Set(s string) {
+	v.o.set(s)
+}
+
+// Observe registers fn to be called from the main loop after the value changed.
+func (v *StringValue) Observe(fn func(s string)) {
+	v.o.observers = append(v.o.observers, func(x interface{}) {
+		fn(x.(string))
+	})
+}
+
+// BindField binds the text of ui to the value, two-way. The value is set in ui immediately.
+func (v *StringValue) BindField(ui *Field) {
+	v.o.bind(ui, func() interface{} {
+		return ui.Text
+	}, func(x interface{}) bool {
+		s := x.(string)
+		if ui.Text == s {
+			return false
+		}
+		ui.Text = s
+		ui.Cursor1 = 0
+		ui.SelectionStart1 = 0
+		v.o.dui.markBound(ui, false)
+		return true
+	})
+}
+
+// BindLabel binds the text of ui to the value, one-way: the label shows the value. The value is set in ui immediately.
+func (v *StringValue) BindLabel(ui *Label) {
+	v.o.bind(ui, func() interface{} {
+		return ui.Text
+	}, func(x interface{}) bool {
+		s := x.(string)
+		if ui.Text == s {
+			return false
+		}
+		ui.Text = s
+		v.o.dui.markBound(ui, true)
+		return true
+	})
+}
+
+// BoolValue is an observable bool, for binding to UIs like Checkbox. Create one with NewBoolValue.
+// See StringValue for details about observable values.
+type BoolValue struct {
+	o observable
+}
+
+// NewBoolValue returns a new observable bool with initial value v.
+func NewBoolValue(dui *DUI, v bool) *BoolValue {
+	return &BoolValue{observable{dui: dui, value: v, notified: v}}
+}
+
+// Get returns the current value, including a value passed to Set whose update has not yet run.
+func (v *BoolValue) Get() bool {
+	return v.o.get().(bool)
+}
+
+// Set changes the value. Bound UIs are updated and marked for draw, and observers are called, from the main loop.
+func (v *BoolValue) Set(b bool) {
+	v.o.set(b)
+}
+
+// Observe registers fn to be called from the main loop after the value changed.
+func (v *BoolValue) Observe(fn func(b bool)) {
+	v.o.observers = append(v.o.observers, func(x interface{}) {
+		fn(x.(bool))
+	})
+}
+
+// BindCheckbox binds whether ui is checked to the value, two-way. The value is set in ui immediately.
+func (v *BoolValue) BindCheckbox(ui *Checkbox) {
+	v.o.bind(ui, func() interface{} {
+		return ui.Checked
+	}, func(x interface{}) bool {
+		b := x.(bool)
+		if ui.Checked == b {
+			return false
+		}
+		ui.Checked = b
+		v.o.dui.markBound(ui, false)
+		return true
+	})
+}
+
+// BindRadiobutton binds whether ui is selected to the value, two-way. The value is set in ui immediately.
+// Setting the value to true selects ui, unselecting the other radiobuttons in its group, which updates the values bound to those radiobuttons.
+func (v *BoolValue) BindRadiobutton(ui *Radiobutton) {
+	dui := v.o.dui
+	v.o.bind(ui, func() interface{} {
+		return ui.Selected
+	}, func(x interface{}) bool {
+		b := x.(bool)
+		if ui.Selected == b {
+			return false
+		}
+		if !b {
+			ui.Selected = false
+			dui.markBound(ui, false)
+			return true
+		}
+		ui.Selected = true
+		dui.markBound(ui, false)
+		for _, o := range ui.Group {
+			if o != ui && o.Selected {
+				o.Selected = false
+				dui.markBound(o, false)
+				dui.valueChanged(o)
+			}
+		}
+		return true
+	})
+}
+
+// ListValues is an observable list of values, for binding to a List. Create one with NewListValues.
+// See StringValue for details about observable values.
+// The value changes when the user changes the selection in a bound List, the selection is stored in the Selected field of the values.
+type ListValues struct {
+	o observable
+}
+
+// NewListValues returns a new observable list of values with initial value l.
+func NewListValues(dui *DUI, l []*ListValue) *ListValues {
+	return &ListValues{observable{dui: dui, value: l}}
+}
+
+// Get returns the current values, including values passed to Set whose update has not yet run.
+func (v *ListValues) Get() []*ListValue {
+	return v.o.get().([]*ListValue)
+}
+
+// Set changes the values. Bound lists are updated and marked for layout, and observers are called, from the main loop.
+// After changing the fields of the values in place, call Set with the same values to update the bound lists.
+func (v *ListValues) Set(l []*ListValue) {
+	v.o.set(l)
+}
+
+// Observe registers fn to be called from the main loop after the values or their selection changed.
+func (v *ListValues) Observe(fn func(l []*ListValue)) {
+	v.o.observers = append(v.o.observers, func(x interface{}) {
+		fn(x.([]*ListValue))
+	})
+}
+
+// BindList binds the values of ui to the value, two-way. The values are set in ui immediately.
+func (v *ListValues) BindList(ui *List) {
+	v.o.bind(ui, func() interface{} {
+		return ui.Values
+	}, func(x interface{}) bool {
+		ui.Values = x.([]*ListValue)
+		v.o.dui.markBound(ui, true)
+		return true
+	})
+}
@@ -0,0 +1,139 @@
+package duit_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/duittest"
+)
+
+// pumpUntil runs the main loop of dt until cond returns true.
+func pumpUntil(t *testing.T, dt *duittest.Tester, cond func() bool) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for !cond() {
+		select {
+		case e := <-dt.DUI.Inputs:
+			dt.DUI.Input(e)
+		case <-deadline:
+			t.Fatalf("timeout waiting for main loop")
+		}
+	}
+}
+
+func TestBindConcurrentSet(t *testing.T) {
+	field := &duit.Field{}
+	label := &duit.Label{}
+	dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(field, label)}, &duit.DUIOpts{Dimensions: "200x100"})
+	defer dt.Close()
+
+	v := duit.NewStringValue(dt.DUI, "start")
+	v.BindField(field)
+	v.BindLabel(label)
+	if field.Text != "start" || label.Text != "start" {
+		t.Fatalf("bound field %q and label %q, expected %q", field.Text, label.Text, "start")
+	}
+	var observed []string
+	v.Observe(func(s string) {
+		observed = append(observed, s)
+	})
+
+	// Sets from many goroutines, while the main loop runs. The UIs and observers are only updated from the main loop.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				v.Set(fmt.Sprintf("%d-%d", i, j))
+				v.Get()
+			}
+		}(i)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for running := true; running; {
+		select {
+		case e := <-dt.DUI.Inputs:
+			dt.DUI.Input(e)
+		case <-done:
+			running = false
+		}
+	}
+	final := v.Get()
+	pumpUntil(t, dt, func() bool {
+		return field.Text == final
+	})
+	if label.Text != final || len(observed) == 0 || observed[len(observed)-1] != final {
+		t.Fatalf("label %q, observed %v, expected %q", label.Text, observed, final)
+	}
+}
+
+func TestBindTwoWay(t *testing.T) {
+	first := &duit.Field{}
+	second := &duit.Field{}
+	check := &duit.Checkbox{}
+	otherCheck := &duit.Checkbox{}
+	dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(first, second, check, otherCheck)}, &duit.DUIOpts{Dimensions: "300x100"})
+	defer dt.Close()
+
+	text := duit.NewStringValue(dt.DUI, "")
+	text.BindField(first)
+	text.BindField(second)
+	var observed []string
+	text.Observe(func(s string) {
+		observed = append(observed, s)
+	})
+	checked := duit.NewBoolValue(dt.DUI, false)
+	checked.BindCheckbox(check)
+	checked.BindCheckbox(otherCheck)
+
+	// Typing in a field updates the value, the other field and the observers, immediately.
+	dt.Click(dt.Center(first))
+	dt.Type("ab")
+	if text.Get() != "ab" || second.Text != "ab" || len(observed) != 2 || observed[1] != "ab" {
+		t.Fatalf("value %q, second field %q, observed %v, expected %q everywhere", text.Get(), second.Text, observed, "ab")
+	}
+	dt.Click(dt.Center(check))
+	if !checked.Get() || !otherCheck.Checked {
+		t.Fatalf("value %v, other checkbox %v after click, expected checked", checked.Get(), otherCheck.Checked)
+	}
+
+	// Setting the value updates the UIs from the main loop.
+	checked.Set(false)
+	pumpUntil(t, dt, func() bool {
+		return !check.Checked && !otherCheck.Checked
+	})
+}
+
+func TestUnbind(t *testing.T) {
+	first := &duit.Field{}
+	second := &duit.Field{}
+	dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(first, second)}, &duit.DUIOpts{Dimensions: "300x100"})
+	defer dt.Close()
+
+	v := duit.NewStringValue(dt.DUI, "")
+	v.BindField(first)
+	v.BindField(second)
+	dt.DUI.Unbind(first)
+
+	// The unbound field no longer changes the value, or follows it.
+	dt.Click(dt.Center(first))
+	dt.Type("a")
+	if v.Get() != "" || second.Text != "" {
+		t.Fatalf("value %q, second field %q after typing in unbound field, expected empty", v.Get(), second.Text)
+	}
+	v.Set("b")
+	pumpUntil(t, dt, func() bool {
+		return second.Text == "b"
+	})
+	if first.Text != "a" {
+		t.Fatalf("unbound field %q, expected %q", first.Text, "a")
+	}
+}
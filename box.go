@@ -161,6 +161,10 @@ func (ui *Box) FirstFocus(dui *DUI, self *Kid) *image.Point {
 	return KidsFirstFocus(dui, self, ui.orderedKids())
 }
 
+func (ui *Box) LastFocus(dui *DUI, self *Kid) *image.Point {
+	return KidsLastFocus(dui, self, ui.orderedKids())
+}
+
 func (ui *Box) Focus(dui *DUI, self *Kid, o UI) *image.Point {
 	return KidsFocus(dui, self, ui.Kids, o)
 }
@@ -169,6 +173,10 @@ func (ui *Box) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 	return KidsMark(self, ui.Kids, o, forLayout)
 }
 
+func (ui *Box) Children() []*Kid {
+	return ui.Kids
+}
+
 func (ui *Box) Print(self *Kid, indent int) {
 	PrintUI("Box", self, indent)
 	KidsPrint(ui.Kids, indent+1)
@@ -123,6 +123,10 @@ func (ui *Button) FirstFocus(dui *DUI, self *Kid) *image.Point {
 	return &p
 }
 
+func (ui *Button) LastFocus(dui *DUI, self *Kid) *image.Point {
+	return ui.FirstFocus(dui, self)
+}
+
 func (ui *Button) Focus(dui *DUI, self *Kid, o UI) *image.Point {
 	if o != ui {
 		return nil
@@ -137,3 +141,8 @@ func (ui *Button) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 func (ui *Button) Print(self *Kid, indent int) {
 	PrintUI("Button", self, indent)
 }
+
+// Access returns a button node, see Accessible.
+func (ui *Button) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	return &AccessNode{Role: RoleButton, Name: ui.Text, State: AccessState{Disabled: ui.Disabled}}
+}
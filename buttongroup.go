@@ -163,6 +163,15 @@ func (ui *Buttongroup) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image
 			r.Consumed = true
 			self.Draw = Dirty
 		}
+	case dui.BackTab:
+		index, start, _ := ui.findIndex(dui, m)
+		if index <= 0 {
+			break
+		}
+		p := orig.Add(image.Pt(start-BorderSize*2-ui.padding(dui).X, m.Y))
+		r.Warp = &p
+		r.Consumed = true
+		self.Draw = Dirty
 	}
 	return
 }
@@ -173,6 +182,19 @@ func (ui *Buttongroup) FirstFocus(dui *DUI, self *Kid) *image.Point {
 	return &p
 }
 
+func (ui *Buttongroup) LastFocus(dui *DUI, self *Kid) *image.Point {
+	p := ui.padding(dui)
+	pad2 := p.Mul(2)
+	font := ui.font(dui)
+	for i, t := range ui.Texts {
+		if i == len(ui.Texts)-1 {
+			break
+		}
+		p.X += font.StringSize(t).X + pad2.X + BorderSize
+	}
+	return &p
+}
+
 func (ui *Buttongroup) Focus(dui *DUI, self *Kid, o UI) *image.Point {
 	if o != ui {
 		return nil
@@ -187,3 +209,30 @@ func (ui *Buttongroup) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 func (ui *Buttongroup) Print(self *Kid, indent int) {
 	PrintUI("Buttongroup", self, indent)
 }
+
+// Access returns a radiogroup node with a radio node for each button, see Accessible.
+func (ui *Buttongroup) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	n := &AccessNode{Role: RoleRadioGroup, State: AccessState{Disabled: ui.Disabled}}
+	sel := ui.selected()
+	offset := 0
+	pad2 := ui.padding(dui).Mul(2)
+	font := ui.font(dui)
+	for i, t := range ui.Texts {
+		end := offset + font.StringSize(t).X + pad2.X + BorderSize
+		n.Children = append(n.Children, &AccessNode{
+			Role:  RoleRadio,
+			Name:  t,
+			Rect:  image.Rect(offset, 0, end, ui.size.Y).Add(orig),
+			State: AccessState{Checked: i == sel, Disabled: ui.Disabled},
+		})
+		offset = end
+	}
+	return n
+}
+
+// KeepState copies the selected button of old, for LoadUI.
+func (ui *Buttongroup) KeepState(old UI) {
+	if o, ok := old.(*Buttongroup); ok && o.Selected < len(ui.Texts) {
+		ui.Selected = o.Selected
+	}
+}
@@ -20,10 +20,7 @@ type Checkbox struct {
 var _ UI = &Checkbox{}
 
 func (ui *Checkbox) font(dui *DUI) *draw.Font {
-	if ui.Font != nil {
-		return ui.Font
-	}
-	return dui.Display.DefaultFont
+	return dui.Font(ui.Font)
 }
 
 func (ui *Checkbox) size(dui *DUI) image.Point {
@@ -92,6 +89,7 @@ func (ui *Checkbox) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, o
 		if m.Buttons&1 == 0 {
 			r.Consumed = true
 			ui.Checked = !ui.Checked
+			dui.valueChanged(ui)
 			if ui.Changed != nil {
 				e := ui.Changed()
 				propagateEvent(self, &r, e)
@@ -110,6 +108,7 @@ func (ui *Checkbox) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Po
 		r.Consumed = true
 		self.Draw = Dirty
 		ui.Checked = !ui.Checked
+		dui.valueChanged(ui)
 		if ui.Changed != nil {
 			e := ui.Changed()
 			propagateEvent(self, &r, e)
@@ -123,6 +122,10 @@ func (ui *Checkbox) FirstFocus(dui *DUI, self *Kid) *image.Point {
 	return &p
 }
 
+func (ui *Checkbox) LastFocus(dui *DUI, self *Kid) *image.Point {
+	return ui.FirstFocus(dui, self)
+}
+
 func (ui *Checkbox) Focus(dui *DUI, self *Kid, o UI) *image.Point {
 	if o != ui {
 		return nil
@@ -137,3 +140,15 @@ func (ui *Checkbox) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 func (ui *Checkbox) Print(self *Kid, indent int) {
 	PrintUI("Checkbox", self, indent)
 }
+
+// Access returns a checkbox node, see Accessible.
+func (ui *Checkbox) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	return &AccessNode{Role: RoleCheckbox, State: AccessState{Checked: ui.Checked, Disabled: ui.Disabled}}
+}
+
+// KeepState copies whether old is checked, for LoadUI.
+func (ui *Checkbox) KeepState(old UI) {
+	if o, ok := old.(*Checkbox); ok {
+		ui.Checked = o.Checked
+	}
+}
@@ -0,0 +1,181 @@
+// Command duitdevdraw is a devdraw that draws into memory, for running duit programs without a window system, e.g. in tests.
+//
+// Programs using 9fans.net/go/draw, including duit, start devdraw as found in $DEVDRAW.
+// Run a program unmodified with:
+//
+//	DEVDRAW=duitdevdraw DUITDEVDRAW_SCREENSHOT=screen.png DUITDEVDRAW_INPUT=input.txt ./program
+//
+// If $DUITDEVDRAW_SCREENSHOT is set, the screen is written to it as PNG after each time the program flushes its drawing.
+//
+// If $DUITDEVDRAW_INPUT is set, input is read from the file it names, one event per line:
+//
+//	mouse x y buttons	# mouse event, buttons is a bit mask, 1 for button 1, etc.
+//	key c			# a single character as key press, or a number for a special key, e.g. 0xf00e for draw.KeyUp.
+//	type text		# key press for each character of text.
+//	resize widthxheight	# resize the screen, e.g. 400x300.
+//	snarf text		# set the snarf buffer.
+//	wait			# wait for the next flush of drawing.
+//	screenshot path	# write the screen to path as PNG.
+//	sleep milliseconds	# pause.
+//
+// Commands and arguments are separated by a single space. The arguments of key, type and snarf are used as is, including leading and trailing spaces, so "key " followed by a space presses the space bar.
+// Empty lines and lines starting with # are ignored. Input starts after the program has initialized its display.
+// When input has been read completely, the program keeps running until it closes the display.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"9fans.net/go/draw"
+
+	"github.com/mjl-/duit/devdraw"
+)
+
+type stdio struct {
+	io.Reader
+	io.Writer
+}
+
+func main() {
+	log.SetPrefix("duitdevdraw: ")
+	log.SetFlags(0)
+
+	s := devdraw.NewServer()
+	initialized := s.Flushed()
+
+	if path := os.Getenv("DUITDEVDRAW_SCREENSHOT"); path != "" {
+		go func() {
+			for {
+				<-s.Flushed()
+				if err := writeScreenshot(s, path); err != nil {
+					log.Printf("writing screenshot: %s", err)
+				}
+			}
+		}()
+	}
+
+	if path := os.Getenv("DUITDEVDRAW_INPUT"); path != "" {
+		go func() {
+			// The draw library flushes during initialization, after the screen exists.
+			<-initialized
+			if err := script(s, path); err != nil {
+				log.Printf("input: %s", err)
+			}
+		}()
+	}
+
+	err := s.Serve(stdio{os.Stdin, os.Stdout})
+	if err != nil && err != io.EOF {
+		log.Fatalf("serve: %s", err)
+	}
+}
+
+func writeScreenshot(s *devdraw.Server, path string) error {
+	img := s.Screen()
+	if img == nil {
+		return fmt.Errorf("no screen")
+	}
+	tmp := filepath.Join(filepath.Dir(path), ".tmp."+filepath.Base(path))
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(f, img)
+	if xerr := f.Close(); err == nil {
+		err = xerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+func script(s *devdraw.Server, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if t := strings.TrimSpace(line); t == "" || strings.HasPrefix(t, "#") {
+			continue
+		}
+		if err := command(s, line); err != nil {
+			return fmt.Errorf("%s:%d: %s", path, lineno, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func command(s *devdraw.Server, line string) error {
+	t := strings.SplitN(strings.TrimLeft(line, " \t"), " ", 2)
+	cmd, text := strings.TrimSpace(t[0]), ""
+	if len(t) == 2 {
+		text = t[1]
+	}
+	// Text is used as is for key, type and snarf, numbers and paths can have surrounding space.
+	arg := strings.TrimSpace(text)
+	switch cmd {
+	case "mouse":
+		var m draw.Mouse
+		if _, err := fmt.Sscanf(arg, "%d %d %d", &m.X, &m.Y, &m.Buttons); err != nil {
+			return fmt.Errorf("bad mouse %q: %s", arg, err)
+		}
+		m.Msec = uint32(time.Now().UnixNano() / int64(time.Millisecond))
+		s.Mouse(m)
+	case "key":
+		r := []rune(text)
+		if len(r) == 1 {
+			s.Key(r[0])
+			break
+		}
+		v, err := strconv.ParseInt(arg, 0, 32)
+		if err != nil {
+			return fmt.Errorf("bad key %q", arg)
+		}
+		s.Key(rune(v))
+	case "type":
+		for _, c := range text {
+			s.Key(c)
+		}
+	case "resize":
+		var size image.Point
+		if _, err := fmt.Sscanf(arg, "%dx%d", &size.X, &size.Y); err != nil {
+			return fmt.Errorf("bad size %q: %s", arg, err)
+		}
+		return s.Resize(size)
+	case "snarf":
+		s.SetSnarf([]byte(text))
+	case "wait":
+		<-s.Flushed()
+	case "screenshot":
+		return writeScreenshot(s, arg)
+	case "sleep":
+		ms, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("bad milliseconds %q", arg)
+		}
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+	return nil
+}
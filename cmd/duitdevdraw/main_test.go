@@ -0,0 +1,60 @@
+package main
+
+import (
+	"image"
+	"os"
+	"testing"
+
+	"9fans.net/go/draw"
+
+	"github.com/mjl-/duit/devdraw"
+)
+
+func TestMain(m *testing.M) {
+	devdraw.Main()
+	os.Exit(m.Run())
+}
+
+func TestCommand(t *testing.T) {
+	h := devdraw.NewHeadless()
+	display, mousectl, keyctl, err := h.Init(make(chan error, 1), "", "test", "40x30")
+	if err != nil {
+		t.Fatalf("init: %s", err)
+	}
+	defer display.Close()
+	<-mousectl.C
+
+	keys := func(line string, exp string) {
+		t.Helper()
+		if err := command(h.Server, line); err != nil {
+			t.Fatalf("command %q: %s", line, err)
+		}
+		for _, c := range exp {
+			if k := <-keyctl.C; k != c {
+				t.Fatalf("command %q: key %q, expected %q", line, k, c)
+			}
+		}
+	}
+	keys("key  ", " ")
+	keys("key x", "x")
+	keys("key 0xf00e", string(draw.KeyUp))
+	keys("type  two words ", " two words ")
+
+	if err := command(h.Server, "mouse 1 2 1 "); err != nil {
+		t.Fatalf("mouse: %s", err)
+	}
+	if m := <-mousectl.C; m.Point != image.Pt(1, 2) || m.Buttons != 1 {
+		t.Fatalf("mouse %v, expected 1,2 with button 1", m)
+	}
+
+	if err := command(h.Server, "snarf  text "); err != nil {
+		t.Fatalf("snarf: %s", err)
+	}
+	if buf := h.Server.Snarf(); string(buf) != " text " {
+		t.Fatalf("snarf %q, expected %q", buf, " text ")
+	}
+
+	if err := command(h.Server, "bogus"); err == nil {
+		t.Fatalf("unknown command accepted")
+	}
+}
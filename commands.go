@@ -0,0 +1,529 @@
+package duit
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"9fans.net/go/draw"
+)
+
+// Command is a named action run with key bindings, registered with DUI.AddCommand.
+//
+// Commands are looked up before keys are delivered to the UI with keyboard focus, or the UI under the mouse if no UI has focus.
+// Commands with a Scope matching the type of that UI take precedence over global commands.
+// Global commands, including the command palette, are not run while a dialog is shown, see DUI.ShowDialog.
+// A command without Run is handled by the UIs of its scope themselves, e.g. cmd-a in an Edit. Those commands document the keys of UIs, for the shortcuts help and conflict detection.
+// When their keys are configured differently, the UI receives the first key of Keys for a configured key, and no longer receives its default keys.
+type Command struct {
+	Name     string                // Unique name, e.g. "save". Used for configuring keys in DUI.KeyBindings. The commands of this package start with "duit.".
+	Title    string                // Shown in the shortcuts help, e.g. "Save file".
+	Keys     []rune                // Default key bindings, e.g. draw.KeyCmd + 's'. Overridden by DUI.KeyBindings.
+	Scope    string                // Type of the UI for which the command is active, as used with RegisterUI, e.g. "*duit.Edit". Global if empty.
+	Fallback bool                  // If set, Run is only called if the UI did not consume the key, e.g. for a default action.
+	Enabled  func() bool           // If not nil, the command is only active while Enabled returns true. An inactive command does not take its keys from the UI.
+	Run      func(dui *DUI, ui UI) // Called with the UI that would receive the key, nil if none. Use DUI.MarkLayout or DUI.MarkDraw to update UIs.
+}
+
+// KeyConflict is a key bound to multiple commands in the same scope, see DUI.KeyConflicts.
+type KeyConflict struct {
+	Key      rune
+	Scope    string
+	Commands []string // Names of the commands, in order of registration. The first active command wins.
+}
+
+// AddCommand registers c. Its key bindings are effective immediately.
+// An error is returned if a command with the same name is already registered.
+// With DUI.Debug set, keys that conflict with other commands are logged.
+func (d *DUI) AddCommand(c *Command) error {
+	if c.Name == "" {
+		return fmt.Errorf("command without name")
+	}
+	if d.command(c.Name) != nil {
+		return fmt.Errorf("duplicate command %q", c.Name)
+	}
+	d.commands = append(d.commands, c)
+	if d.Debug {
+		for _, kc := range d.KeyConflicts() {
+			if kc.Commands[len(kc.Commands)-1] == c.Name {
+				log.Printf("duit: key %s in scope %q bound to multiple commands: %s\n", KeyName(kc.Key), kc.Scope, strings.Join(kc.Commands, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// RemoveCommand unregisters the command with name, if any.
+func (d *DUI) RemoveCommand(name string) {
+	for i, c := range d.commands {
+		if c.Name == name {
+			copy(d.commands[i:], d.commands[i+1:])
+			d.commands = d.commands[:len(d.commands)-1]
+			return
+		}
+	}
+}
+
+// Commands returns the registered commands, in order of registration.
+func (d *DUI) Commands() []*Command {
+	return d.commands
+}
+
+func (d *DUI) command(name string) *Command {
+	for _, c := range d.commands {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// CommandKeys returns the keys bound to command c, from DUI.KeyBindings or the default keys of c.
+func (d *DUI) CommandKeys(c *Command) []rune {
+	if keys, ok := d.KeyBindings[c.Name]; ok {
+		return keys
+	}
+	return c.Keys
+}
+
+// KeyConflicts returns keys bound to multiple commands in the same scope.
+func (d *DUI) KeyConflicts() (l []KeyConflict) {
+	type scopeKey struct {
+		scope string
+		key   rune
+	}
+	names := map[scopeKey][]string{}
+	var order []scopeKey
+	for _, c := range d.commands {
+		for _, k := range d.CommandKeys(c) {
+			sk := scopeKey{c.Scope, k}
+			if len(names[sk]) == 0 {
+				order = append(order, sk)
+			}
+			names[sk] = append(names[sk], c.Name)
+		}
+	}
+	for _, sk := range order {
+		if len(names[sk]) > 1 {
+			l = append(l, KeyConflict{sk.key, sk.scope, names[sk]})
+		}
+	}
+	return
+}
+
+// active returns whether c is active for keys going to a UI of type scope, the type name as formatted by fmt with %T.
+// Callers format the type name once, not for each command.
+func (c *Command) active(scope string) bool {
+	return (c.Scope == "" || c.Scope == scope) && (c.Enabled == nil || c.Enabled())
+}
+
+// keyCommand returns the active command for key k with a UI of type scope receiving keys, scoped commands first.
+// If fallback is set, only commands with Fallback set are returned, otherwise only commands without.
+// While a dialog is shown, only scoped commands are returned.
+func (d *DUI) keyCommand(k rune, scope string, fallback bool) *Command {
+	for _, scoped := range []bool{true, false} {
+		if !scoped && d.dialogShown() {
+			break
+		}
+		for _, c := range d.commands {
+			if (c.Scope != "") != scoped || c.Fallback != fallback || !c.active(scope) {
+				continue
+			}
+			for _, ck := range d.CommandKeys(c) {
+				if ck == k {
+					return c
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// keyUI returns the UI that receives keys: the UI with focus, or the UI under the mouse.
+func (d *DUI) keyUI() UI {
+	if d.focus != nil {
+		return d.focus
+	}
+	return d.lastMouseUI
+}
+
+// commandKey runs the command bound to k, before delivering the key to the UI.
+// It returns the key to deliver to the UI, and whether to deliver it at all.
+func (d *DUI) commandKey(k rune) (rune, bool) {
+	ui := d.keyUI()
+	scope := fmt.Sprintf("%T", ui)
+	c := d.keyCommand(k, scope, false)
+	if c != nil && c.Run != nil {
+		c.Run(d, ui)
+		return 0, false
+	}
+	if c != nil {
+		// Handled by the UI, which knows its default keys only.
+		for _, ck := range c.Keys {
+			if ck == k {
+				return k, true
+			}
+		}
+		if len(c.Keys) == 0 {
+			return k, true
+		}
+		return c.Keys[0], true
+	}
+	// Don't deliver default keys of UI commands whose keys are configured differently.
+	for _, c := range d.commands {
+		if c.Run != nil || c.Scope == "" || !c.active(scope) {
+			continue
+		}
+		for _, ck := range c.Keys {
+			if ck == k {
+				return 0, false
+			}
+		}
+	}
+	return k, true
+}
+
+// ShowShortcuts shows an overlay with the key bindings of the active commands, for the UI currently receiving keys.
+// If the overlay is already shown, it is removed instead. Escape or a click outside the overlay also removes it.
+// It is bound to F10 by the command "duit.shortcuts".
+func (d *DUI) ShowShortcuts() {
+	if d.shortcuts != nil {
+		d.RemoveOverlay(d.shortcuts)
+		d.shortcuts = nil
+		return
+	}
+
+	scope := fmt.Sprintf("%T", d.keyUI())
+	type shortcut struct {
+		key   string
+		title string
+		scope string
+	}
+	var l []shortcut
+	for _, c := range d.commands {
+		if !c.active(scope) {
+			continue
+		}
+		keys := d.CommandKeys(c)
+		if len(keys) == 0 {
+			continue
+		}
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			names[i] = KeyName(k)
+		}
+		title := c.Title
+		if title == "" {
+			title = c.Name
+		}
+		l = append(l, shortcut{strings.Join(names, ", "), title, c.Scope})
+	}
+	sort.SliceStable(l, func(i, j int) bool {
+		return l[i].scope != "" && l[j].scope == ""
+	})
+
+	bg := d.Placeholder.Background
+	var kids []*Kid
+	for _, s := range l {
+		kids = append(kids, NewKids(&Label{Text: s.key}, &Label{Text: s.title})...)
+	}
+	grid := &Grid{
+		Kids:       kids,
+		Columns:    2,
+		Padding:    []Space{SpaceXY(0, 1), {Top: 1, Right: 0, Bottom: 1, Left: 12}},
+		Background: bg,
+	}
+	title := &Label{Text: "Keyboard shortcuts", Font: d.FontStyle(FontStyle{Weight: FontBold})}
+	box := &Box{Kids: NewKids(title, grid), Padding: SpaceXY(4, 4), Background: bg}
+
+	o := &Overlay{
+		Kid: Kid{UI: &popup{Kid: Kid{UI: box}}},
+		Dismissed: func() {
+			d.shortcuts = nil
+		},
+	}
+	screen := d.Display.ScreenImage.R
+	o.UI.Layout(d, &o.Kid, screen.Size(), true)
+	o.At = screen.Min.Add(screen.Size().Sub(o.R.Size()).Div(2))
+	d.shortcuts = o
+	d.AddOverlay(o)
+}
+
+// ShowCommandPalette shows a Palette near the top of the window for searching and running commands that are active for the UI currently receiving keys, with their key bindings.
+// The chosen command is run from the main loop after the palette is removed, with keyboard focus restored. If the palette is already shown, it is removed instead.
+// It is bound to cmd-p by the command "duit.palette".
+func (d *DUI) ShowCommandPalette() {
+	if d.palette != nil {
+		d.RemoveOverlay(d.palette)
+		d.palette = nil
+		return
+	}
+
+	ui := d.keyUI()
+	scope := fmt.Sprintf("%T", ui)
+	var values []*ListValue
+	for _, c := range d.commands {
+		if c.Run == nil || c.Name == "duit.palette" || !c.active(scope) {
+			continue
+		}
+		text := c.Title
+		if text == "" {
+			text = c.Name
+		}
+		if keys := d.CommandKeys(c); len(keys) > 0 {
+			names := make([]string, len(keys))
+			for i, k := range keys {
+				names[i] = KeyName(k)
+			}
+			text += " (" + strings.Join(names, ", ") + ")"
+		}
+		values = append(values, &ListValue{Text: text, Value: c})
+	}
+
+	var o *Overlay
+	palette := &Palette{
+		Values:      values,
+		Placeholder: "Command",
+		Width:       400,
+		Chosen: func(v *ListValue) (e Event) {
+			d.RemoveOverlay(o)
+			d.palette = nil
+			c := v.Value.(*Command)
+			// Not running the command while delivering the key to the palette, and not sending from the main loop, which must keep reading inputs for DUI.Call to be delivered.
+			go func() {
+				d.call(func() {
+					if c.active(scope) {
+						c.Run(d, ui)
+					}
+				})
+			}()
+			e.Consumed = true
+			return
+		},
+	}
+	o = &Overlay{
+		Kid: Kid{UI: &popup{Kid: Kid{UI: palette}}},
+		Dismissed: func() {
+			d.palette = nil
+		},
+	}
+	screen := d.Display.ScreenImage.R
+	o.UI.Layout(d, &o.Kid, screen.Size(), true)
+	o.At = screen.Min.Add(image.Pt((screen.Dx()-o.R.Dx())/2, d.Scale(40)))
+	d.palette = o
+	d.AddOverlay(o)
+	d.setFocus(palette.field, nil, true)
+}
+
+var keyNames = map[rune]string{
+	'\t':              "tab",
+	'\n':              "enter",
+	' ':               "space",
+	draw.KeyBackspace: "backspace",
+	draw.KeyDelete:    "delete",
+	draw.KeyEscape:    "escape",
+	draw.KeyHome:      "home",
+	draw.KeyEnd:       "end",
+	draw.KeyUp:        "up",
+	draw.KeyDown:      "down",
+	draw.KeyLeft:      "left",
+	draw.KeyRight:     "right",
+	draw.KeyPageUp:    "pageup",
+	draw.KeyPageDown:  "pagedown",
+	draw.KeyInsert:    "insert",
+}
+
+// KeyName returns a name for key k, for showing to users and for configuring keys, see ParseKey.
+// Examples: "cmd-s", "ctl-a", "F1", "pageup", "x".
+func KeyName(k rune) string {
+	if s, ok := keyNames[k]; ok {
+		return s
+	}
+	switch {
+	case k >= draw.KeyCmd && k < draw.KeyCmd+128:
+		return "cmd-" + KeyName(k-draw.KeyCmd)
+	case k > draw.KeyFn && k <= draw.KeyFn+12:
+		return fmt.Sprintf("F%d", k-draw.KeyFn)
+	case k >= 1 && k <= 26:
+		return "ctl-" + string('a'+k-1)
+	case k < ' ' || k >= draw.KeyFn && k < draw.KeyFn+0x200:
+		return fmt.Sprintf("0x%x", k)
+	}
+	return string(k)
+}
+
+// ParseKey parses a key name as returned by KeyName.
+func ParseKey(s string) (rune, error) {
+	for k, name := range keyNames {
+		if s == name {
+			return k, nil
+		}
+	}
+	switch {
+	case strings.HasPrefix(s, "cmd-"):
+		k, err := ParseKey(s[len("cmd-"):])
+		if err != nil || k >= 128 {
+			return 0, fmt.Errorf("bad key %q", s)
+		}
+		return draw.KeyCmd + k, nil
+	case strings.HasPrefix(s, "ctl-") && len(s) == len("ctl-")+1 && s[4] >= 'a' && s[4] <= 'z':
+		return rune(s[4]-'a') + 1, nil
+	case len(s) > 1 && (s[0] == 'F' || s[0] == 'f'):
+		n, err := strconv.Atoi(s[1:])
+		if err != nil || n < 1 || n > 12 {
+			return 0, fmt.Errorf("bad key %q", s)
+		}
+		return draw.KeyFn + rune(n), nil
+	case strings.HasPrefix(s, "0x"):
+		n, err := strconv.ParseInt(s[2:], 16, 32)
+		if err != nil {
+			return 0, fmt.Errorf("bad key %q", s)
+		}
+		return rune(n), nil
+	}
+	r := []rune(s)
+	if len(r) != 1 {
+		return 0, fmt.Errorf("bad key %q", s)
+	}
+	return r[0], nil
+}
+
+// ReadKeyBindings reads key bindings in JSON from r, for DUI.KeyBindings.
+// The JSON is an object with command names as keys, and lists of key names as values, e.g. {"duit.close": ["cmd-q"], "duit.zoomin": ["cmd-+", "F11"]}.
+// An empty list removes all keys of a command.
+func ReadKeyBindings(r io.Reader) (map[string][]rune, error) {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var names map[string][]string
+	if err := json.Unmarshal(buf, &names); err != nil {
+		return nil, fmt.Errorf("reading key bindings: %s", err)
+	}
+	bindings := map[string][]rune{}
+	for name, l := range names {
+		keys := []rune{}
+		for _, s := range l {
+			k, err := ParseKey(s)
+			if err != nil {
+				return nil, fmt.Errorf("reading key bindings: command %q: %s", name, err)
+			}
+			keys = append(keys, k)
+		}
+		bindings[name] = keys
+	}
+	return bindings, nil
+}
+
+// ReadKeyBindingsPath is a convenience function that opens path and calls ReadKeyBindings.
+func ReadKeyBindingsPath(path string) (map[string][]rune, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %s", path, err)
+	}
+	defer f.Close()
+	return ReadKeyBindings(f)
+}
+
+// addDefaultCommands registers the commands of DUI, and the commands documenting the keys of the UIs of this package.
+func (d *DUI) addDefaultCommands() {
+	debug := func(name, title string, fn int, run func()) *Command {
+		return &Command{Name: name, Title: title, Keys: []rune{draw.KeyFn + rune(fn)}, Run: func(dui *DUI, ui UI) { run() }}
+	}
+	l := []*Command{
+		debug("duit.loginputs", "Toggle logging of inputs", 1, func() {
+			d.logInputs = !d.logInputs
+			log.Println("duit: logInputs now", d.logInputs)
+		}),
+		debug("duit.logtiming", "Toggle logging of layout and draw timing", 2, func() {
+			d.logTiming = !d.logTiming
+			log.Println("duit: logTiming now", d.logTiming)
+		}),
+		debug("duit.printui", "Print the UI tree", 3, func() {
+			d.Top.UI.Print(&d.Top, 0)
+		}),
+		debug("duit.drawdebug", "Toggle draw debugging of the display", 4, func() {
+			d.drawDebug = !d.drawDebug
+			d.Display.SetDebug(d.drawDebug)
+			log.Println("duit: drawDebug now", d.drawDebug)
+		}),
+		debug("duit.debugkids", "Toggle drawing kids in debug colors", 5, func() {
+			d.DebugKids = !d.DebugKids
+			log.Println("duit: debugKids now", d.DebugKids)
+		}),
+		debug("duit.render", "Render the entire UI", 6, func() {
+			log.Println("duit: rendering entire ui")
+			d.Top.Layout = Dirty
+			d.Top.Draw = Dirty
+			d.Render()
+		}),
+		debug("duit.debugdraw", "Cycle draw debug logging", 7, func() {
+			d.DebugDraw = (d.DebugDraw + 1) % 3
+			log.Println("duit: DebugDraw now", d.DebugDraw)
+		}),
+		debug("duit.debuglayout", "Cycle layout debug logging", 8, func() {
+			d.DebugLayout = (d.DebugLayout + 1) % 3
+			log.Println("duit: DebugLayout now", d.DebugLayout)
+		}),
+		debug("duit.printjson", "Print the UI tree as JSON", 9, func() {
+			err := json.NewEncoder(os.Stderr).Encode(&d.Top)
+			if err != nil {
+				log.Printf("encoding d.Top: %s\n", err)
+			}
+		}),
+		debug("duit.shortcuts", "Show keyboard shortcuts", 10, d.ShowShortcuts),
+		debug("duit.record", "Start or stop recording inputs to a file", 11, d.toggleRecording),
+		{Name: "duit.palette", Title: "Search commands", Keys: []rune{draw.KeyCmd + 'p'}, Run: func(dui *DUI, ui UI) {
+			d.ShowCommandPalette()
+		}},
+		{Name: "duit.zoomin", Title: "Larger fonts", Keys: []rune{draw.KeyCmd + '+', draw.KeyCmd + '='}, Fallback: true, Run: func(dui *DUI, ui UI) {
+			d.SetFontZoom(d.Fonts.Zoom + 1)
+		}},
+		{Name: "duit.zoomout", Title: "Smaller fonts", Keys: []rune{draw.KeyCmd + '-'}, Fallback: true, Run: func(dui *DUI, ui UI) {
+			d.SetFontZoom(d.Fonts.Zoom - 1)
+		}},
+		{Name: "duit.zoomreset", Title: "Normal font size", Keys: []rune{draw.KeyCmd + '0'}, Fallback: true, Run: func(dui *DUI, ui UI) {
+			d.SetFontZoom(0)
+		}},
+		{Name: "duit.close", Title: "Close window", Keys: []rune{draw.KeyCmd + 'w'}, Fallback: true, Run: func(dui *DUI, ui UI) {
+			close(d.Error)
+			d.Close()
+		}},
+	}
+
+	ui := func(scope, name, title string, keys ...rune) *Command {
+		return &Command{Name: name, Title: title, Keys: keys, Scope: scope}
+	}
+	const edit, field, gridlist = "*duit.Edit", "*duit.Field", "*duit.Gridlist"
+	l = append(l,
+		ui(edit, "duit.edit.selectall", "Select all text", draw.KeyCmd+'a'),
+		ui(edit, "duit.edit.selectnone", "Clear selection", draw.KeyCmd+'n'),
+		ui(edit, "duit.edit.copy", "Copy selection", draw.KeyCmd+'c'),
+		ui(edit, "duit.edit.cut", "Cut selection", draw.KeyCmd+'x'),
+		ui(edit, "duit.edit.paste", "Paste", draw.KeyCmd+'v'),
+		ui(edit, "duit.edit.undo", "Undo last change", draw.KeyCmd+'z'),
+		ui(edit, "duit.edit.redo", "Redo last undone change", draw.KeyCmd+'Z'),
+		ui(edit, "duit.edit.unindent", "Unindent selection or line", draw.KeyCmd+'['),
+		ui(edit, "duit.edit.indent", "Indent selection or line", draw.KeyCmd+']'),
+		ui(edit, "duit.edit.warp", "Warp mouse to the cursor", draw.KeyCmd+'m'),
+		ui(edit, "duit.edit.selectlast", "Select last modification", draw.KeyCmd+'y'),
+		ui(edit, "duit.edit.searchnext", "Repeat last search forward", draw.KeyCmd+'/'),
+		ui(edit, "duit.edit.searchprev", "Repeat last search backward", draw.KeyCmd+'?'),
+		ui(field, "duit.field.selectall", "Select all text", draw.KeyCmd+'a'),
+		ui(field, "duit.field.copy", "Copy selection", draw.KeyCmd+'c'),
+		ui(field, "duit.field.cut", "Cut selection", draw.KeyCmd+'x'),
+		ui(field, "duit.field.paste", "Paste", draw.KeyCmd+'v'),
+		ui(field, "duit.field.warp", "Warp mouse to the cursor", draw.KeyCmd+'m'),
+		ui(gridlist, "duit.gridlist.selectall", "Select all rows", draw.KeyCmd+'a'),
+		ui(gridlist, "duit.gridlist.selectnone", "Clear selection", draw.KeyCmd+'n'),
+		ui(gridlist, "duit.gridlist.copy", "Copy selected rows", draw.KeyCmd+'c'),
+	)
+	d.commands = append(d.commands, l...)
+}
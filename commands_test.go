@@ -0,0 +1,94 @@
+package duit_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"9fans.net/go/draw"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/duittest"
+)
+
+func TestKeyNames(t *testing.T) {
+	for _, k := range []rune{draw.KeyCmd + 's', draw.KeyFn + 1, draw.KeyPageUp, 'x'} {
+		name := duit.KeyName(k)
+		pk, err := duit.ParseKey(name)
+		if err != nil || pk != k {
+			t.Fatalf("key %x: name %q parsed as %x, err %v", k, name, pk, err)
+		}
+	}
+	if _, err := duit.ParseKey("bogus-x"); err == nil {
+		t.Fatalf("bogus key name accepted")
+	}
+
+	bindings, err := duit.ReadKeyBindings(strings.NewReader(`{"save": ["cmd-s", "F2"], "duit.close": []}`))
+	if err != nil {
+		t.Fatalf("read key bindings: %s", err)
+	}
+	if l := bindings["save"]; len(l) != 2 || l[0] != draw.KeyCmd+'s' || l[1] != draw.KeyFn+2 {
+		t.Fatalf("bindings for save %v", l)
+	}
+	if l, ok := bindings["duit.close"]; !ok || len(l) != 0 {
+		t.Fatalf("bindings for duit.close %v, expected empty list", l)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	field := &duit.Field{}
+	label := &duit.Label{Text: "label"}
+	dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(field, label)}, &duit.DUIOpts{Dimensions: "300x100"})
+	defer dt.Close()
+
+	var global, scoped int
+	var scopedUI duit.UI
+	err := dt.DUI.AddCommand(&duit.Command{Name: "global", Keys: []rune{draw.KeyCmd + 'g'}, Run: func(dui *duit.DUI, ui duit.UI) {
+		global++
+	}})
+	if err != nil {
+		t.Fatalf("add command: %s", err)
+	}
+	err = dt.DUI.AddCommand(&duit.Command{Name: "scoped", Scope: "*duit.Field", Keys: []rune{'x'}, Run: func(dui *duit.DUI, ui duit.UI) {
+		scoped++
+		scopedUI = ui
+	}})
+	if err != nil {
+		t.Fatalf("add command: %s", err)
+	}
+	if err := dt.DUI.AddCommand(&duit.Command{Name: "global"}); err == nil {
+		t.Fatalf("duplicate command accepted")
+	}
+
+	// The scoped command takes its key from the field, but not from other UIs.
+	dt.Click(dt.Center(field))
+	dt.Type("axb")
+	if field.Text != "ab" || scoped != 1 || scopedUI != field {
+		t.Fatalf("field text %q, %d scoped runs for %v, expected %q and 1 run for field", field.Text, scoped, scopedUI, "ab")
+	}
+	dt.Key(draw.KeyCmd + 'g')
+	if global != 1 {
+		t.Fatalf("got %d global runs, expected 1", global)
+	}
+
+	// Configured keys replace the default keys.
+	dt.DUI.KeyBindings = map[string][]rune{"scoped": {'y'}}
+	dt.Type("xy")
+	if field.Text != "abx" || scoped != 2 {
+		t.Fatalf("field text %q with %d scoped runs, expected %q and 2", field.Text, scoped, "abx")
+	}
+
+	// Running a command from the palette, after the palette is gone.
+	dt.DUI.ShowCommandPalette()
+	dt.DUI.Render()
+	dt.Type("global\n")
+	deadline := time.After(5 * time.Second)
+	for global != 2 {
+		select {
+		case e := <-dt.DUI.Inputs:
+			dt.DUI.Input(e)
+		case <-deadline:
+			t.Fatalf("command from palette not run")
+		}
+	}
+}
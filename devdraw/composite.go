@@ -0,0 +1,145 @@
+package devdraw
+
+import (
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+// drawClip clips r to what can be drawn on dst, from src and through mask.
+// The returned points in src and mask are adjusted along with r.
+func drawClip(dst *memImage, r image.Rectangle, src *memImage, sp image.Point, mask *memImage, mp image.Point) (image.Rectangle, image.Point, image.Point, bool) {
+	clip := func(nr image.Rectangle) {
+		nr = r.Intersect(nr)
+		d := nr.Min.Sub(r.Min)
+		sp = sp.Add(d)
+		mp = mp.Add(d)
+		r = nr
+	}
+
+	clip(dst.r.Intersect(dst.clipr))
+	if src.repl {
+		clip(src.clipr.Add(r.Min.Sub(sp)))
+	} else {
+		clip(src.r.Intersect(src.clipr).Add(r.Min.Sub(sp)))
+	}
+	if mask.repl {
+		clip(mask.clipr.Add(r.Min.Sub(mp)))
+	} else {
+		clip(mask.r.Intersect(mask.clipr).Add(r.Min.Sub(mp)))
+	}
+	return r, sp, mp, !r.Empty()
+}
+
+// replPoint returns p for image i, wrapped to within i.r if i is replicated.
+func (i *memImage) replPoint(p image.Point) image.Point {
+	if !i.repl {
+		return p
+	}
+	dx, dy := i.r.Dx(), i.r.Dy()
+	p.X = i.r.Min.X + (p.X-i.r.Min.X)%dx
+	if p.X < i.r.Min.X {
+		p.X += dx
+	}
+	p.Y = i.r.Min.Y + (p.Y-i.r.Min.Y)%dy
+	if p.Y < i.r.Min.Y {
+		p.Y += dy
+	}
+	return p
+}
+
+func (i *memImage) at(p image.Point) []uint8 {
+	p = i.replPoint(p)
+	if !p.In(i.img.Rect) {
+		return []uint8{0, 0, 0, 0}
+	}
+	o := i.img.PixOffset(p.X, p.Y)
+	return i.img.Pix[o : o+4 : o+4]
+}
+
+// isOpaqueColor returns whether i is a replicated single pixel, and whether its mask value is fully opaque.
+func (i *memImage) isOpaqueColor() (single, opaque bool) {
+	if !i.repl || i.r.Dx() != 1 || i.r.Dy() != 1 {
+		return false, false
+	}
+	return true, i.maskAlpha(i.at(i.r.Min)) == 0xff
+}
+
+func div255(v int) int {
+	return (v + 127) / 255
+}
+
+// memDraw composites src through mask onto dst in rectangle r with op.
+// Point sp in src and mp in mask are aligned with r.Min.
+// The result of op is applied through the mask: dst = mask*op(src, dst) + (1-mask)*dst.
+func memDraw(dst *memImage, r image.Rectangle, src *memImage, sp image.Point, mask *memImage, mp image.Point, op draw.Op) {
+	r, sp, mp, ok := drawClip(dst, r, src, sp, mask, mp)
+	if !ok {
+		return
+	}
+
+	srcSingle, _ := src.isOpaqueColor()
+	maskSingle, maskOpaque := mask.isOpaqueColor()
+	if srcSingle && maskOpaque && (op == draw.S || op == draw.SoverD && src.at(src.r.Min)[3] == 0xff) {
+		c := src.at(src.r.Min)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			o := dst.img.PixOffset(r.Min.X, y)
+			for x := r.Min.X; x < r.Max.X; x++ {
+				copy(dst.img.Pix[o:o+4], c)
+				o += 4
+			}
+		}
+		dst.normalize(r)
+		return
+	}
+
+	// Work on a copy of dst, src and mask may be dst itself.
+	result := make([]uint8, 4*r.Dx()*r.Dy())
+	n := 0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			d := dst.at(image.Pt(x, y))
+			var m int
+			if maskSingle {
+				m = int(mask.maskAlpha(mask.at(mask.r.Min)))
+			} else {
+				m = int(mask.maskAlpha(mask.at(image.Pt(mp.X+x-r.Min.X, mp.Y+y-r.Min.Y))))
+			}
+			if m == 0 {
+				copy(result[n:n+4], d)
+				n += 4
+				continue
+			}
+			s := src.at(image.Pt(sp.X+x-r.Min.X, sp.Y+y-r.Min.Y))
+			sa, da := int(s[3]), int(d[3])
+			var fs, fd int
+			if op&draw.SinD != 0 {
+				fs += da
+			}
+			if op&draw.SoutD != 0 {
+				fs += 255 - da
+			}
+			if op&draw.DinS != 0 {
+				fd += sa
+			}
+			if op&draw.DoutS != 0 {
+				fd += 255 - sa
+			}
+			for k := 0; k < 4; k++ {
+				v := div255(int(s[k])*fs + int(d[k])*fd)
+				if v > 255 {
+					v = 255
+				}
+				result[n+k] = uint8(div255(v*m + int(d[k])*(255-m)))
+			}
+			n += 4
+		}
+	}
+	n = 0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		o := dst.img.PixOffset(r.Min.X, y)
+		copy(dst.img.Pix[o:o+4*r.Dx()], result[n:n+4*r.Dx()])
+		n += 4 * r.Dx()
+	}
+	dst.normalize(r)
+}
@@ -0,0 +1,536 @@
+package devdraw
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+func glong(b []byte) int {
+	return int(int32(binary.LittleEndian.Uint32(b)))
+}
+
+func gshort(b []byte) int {
+	return int(binary.LittleEndian.Uint16(b))
+}
+
+func gpoint(b []byte) image.Point {
+	return image.Pt(glong(b), glong(b[4:]))
+}
+
+func grect(b []byte) image.Rectangle {
+	return image.Rectangle{gpoint(b), gpoint(b[8:])}
+}
+
+// gcoord reads a coordinate as sent in poly messages, either relative to old or absolute.
+func gcoord(b []byte, old int) (int, []byte, error) {
+	if len(b) < 1 {
+		return 0, nil, fmt.Errorf("short poly coordinates")
+	}
+	c := b[0]
+	if c&0x80 == 0 {
+		v := int(c & 0x7f)
+		if c&0x40 != 0 {
+			v |= ^0x7f
+		}
+		return old + v, b[1:], nil
+	}
+	if len(b) < 3 {
+		return 0, nil, fmt.Errorf("short poly coordinates")
+	}
+	v := int(c&0x7f) | int(b[1])<<7 | int(int8(b[2]))<<15
+	return v, b[3:], nil
+}
+
+func (s *Server) image(b []byte) (*memImage, error) {
+	id := uint32(glong(b))
+	i, ok := s.images[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown image id %d", id)
+	}
+	return i, nil
+}
+
+// draw executes the draw commands in b, as sent in a Twrdraw message.
+func (s *Server) draw(b []byte) error {
+	for len(b) > 0 {
+		n, err := s.drawCommand(b)
+		if err != nil {
+			return fmt.Errorf("draw command %q: %v", b[0], err)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// drawCommand executes the first command in b, returning its length.
+func (s *Server) drawCommand(b []byte) (int, error) {
+	need := func(n int) error {
+		if len(b) < n {
+			return fmt.Errorf("short message, %d < %d", len(b), n)
+		}
+		return nil
+	}
+
+	// Op applies to the next drawing command only.
+	op := s.op
+	s.op = draw.SoverD
+
+	switch b[0] {
+	default:
+		return 0, fmt.Errorf("unknown command")
+
+	case 'b':
+		// allocate image: id screenid refresh chan repl R clipr color
+		if err := need(51); err != nil {
+			return 0, err
+		}
+		id := uint32(glong(b[1:]))
+		if _, ok := s.images[id]; ok {
+			return 0, fmt.Errorf("image id %d in use", id)
+		}
+		screenid := uint32(glong(b[5:]))
+		pix := draw.Pix(glong(b[10:]))
+		r := grect(b[15:])
+		val := draw.Color(uint32(glong(b[47:])))
+		if screenid != 0 {
+			scr, ok := s.screens[screenid]
+			if !ok {
+				return 0, fmt.Errorf("unknown screen id %d", screenid)
+			}
+			s.images[id] = scr.image.window(r, val)
+			return 51, nil
+		}
+		i, err := newMemImage(pix, r, b[14] != 0, grect(b[31:]), val)
+		if err != nil {
+			return 0, err
+		}
+		s.images[id] = i
+		return 51, nil
+
+	case 'A':
+		// allocate screen: id imageid fillid public
+		if err := need(14); err != nil {
+			return 0, err
+		}
+		id := uint32(glong(b[1:]))
+		i, err := s.image(b[5:])
+		if err != nil {
+			return 0, err
+		}
+		fill, err := s.image(b[9:])
+		if err != nil {
+			return 0, err
+		}
+		s.screens[id] = &memScreen{i, fill}
+		return 14, nil
+
+	case 'c':
+		// set repl and clip: dstid repl clipr
+		if err := need(22); err != nil {
+			return 0, err
+		}
+		i, err := s.image(b[1:])
+		if err != nil {
+			return 0, err
+		}
+		i.repl = b[5] != 0
+		i.clipr = grect(b[6:])
+		return 22, nil
+
+	case 'd':
+		// draw: dstid srcid maskid R sp mp
+		if err := need(45); err != nil {
+			return 0, err
+		}
+		dst, err := s.image(b[1:])
+		if err != nil {
+			return 0, err
+		}
+		src, err := s.image(b[5:])
+		if err != nil {
+			return 0, err
+		}
+		mask, err := s.image(b[9:])
+		if err != nil {
+			return 0, err
+		}
+		memDraw(dst, grect(b[13:]), src, gpoint(b[29:]), mask, gpoint(b[37:]), op)
+		return 45, nil
+
+	case 'D':
+		// debug: on/off
+		if err := need(2); err != nil {
+			return 0, err
+		}
+		return 2, nil
+
+	case 'e', 'E':
+		// ellipse (or filled): dstid srcid center a b thick sp alpha phi
+		if err := need(45); err != nil {
+			return 0, err
+		}
+		dst, err := s.image(b[1:])
+		if err != nil {
+			return 0, err
+		}
+		src, err := s.image(b[5:])
+		if err != nil {
+			return 0, err
+		}
+		ualpha := uint32(glong(b[37:]))
+		arc := false
+		alpha := 0
+		if ualpha&(1<<31) != 0 {
+			// High bit indicates arc angles are present. The next bit is sign.
+			arc = true
+			if ualpha&(1<<30) == 0 {
+				ualpha &^= 1 << 31
+			}
+			alpha = int(int32(ualpha))
+		}
+		memEllipse(dst, gpoint(b[9:]), glong(b[17:]), glong(b[21:]), glong(b[25:]), src, gpoint(b[29:]), arc, alpha, glong(b[41:]), b[0] == 'E', op)
+		return 45, nil
+
+	case 'f':
+		// free image: id
+		if err := need(5); err != nil {
+			return 0, err
+		}
+		id := uint32(glong(b[1:]))
+		if _, ok := s.images[id]; !ok {
+			return 0, fmt.Errorf("unknown image id %d", id)
+		}
+		delete(s.images, id)
+		return 5, nil
+
+	case 'F':
+		// free screen: id
+		if err := need(5); err != nil {
+			return 0, err
+		}
+		delete(s.screens, uint32(glong(b[1:])))
+		return 5, nil
+
+	case 'i':
+		// init font: fontid nchars ascent
+		if err := need(10); err != nil {
+			return 0, err
+		}
+		i, err := s.image(b[1:])
+		if err != nil {
+			return 0, err
+		}
+		n := glong(b[5:])
+		if n < 0 || n > 1<<16 {
+			return 0, fmt.Errorf("bad number of font characters %d", n)
+		}
+		i.font = &memFont{ascent: int(b[9]), chars: make([]fontChar, n)}
+		return 10, nil
+
+	case 'l':
+		// load font character: fontid srcid index R P left width
+		if err := need(37); err != nil {
+			return 0, err
+		}
+		fi, err := s.image(b[1:])
+		if err != nil {
+			return 0, err
+		}
+		if fi.font == nil {
+			return 0, fmt.Errorf("image is not a font")
+		}
+		src, err := s.image(b[5:])
+		if err != nil {
+			return 0, err
+		}
+		index := gshort(b[9:])
+		if index >= len(fi.font.chars) {
+			return 0, fmt.Errorf("font index %d out of range", index)
+		}
+		r := grect(b[11:])
+		if !r.In(fi.r) {
+			return 0, fmt.Errorf("font character rectangle %v outside font image %v", r, fi.r)
+		}
+		memDraw(fi, r, src, gpoint(b[27:]), s.opaque, image.ZP, draw.S)
+		fi.font.chars[index] = fontChar{r, int(int8(b[35])), int(b[36])}
+		return 37, nil
+
+	case 'L':
+		// line: dstid p0 p1 end0 end1 radius srcid sp
+		if err := need(45); err != nil {
+			return 0, err
+		}
+		dst, err := s.image(b[1:])
+		if err != nil {
+			return 0, err
+		}
+		src, err := s.image(b[33:])
+		if err != nil {
+			return 0, err
+		}
+		memLine(dst, gpoint(b[5:]), gpoint(b[13:]), glong(b[21:]), glong(b[25:]), glong(b[29:]), src, gpoint(b[37:]), op)
+		return 45, nil
+
+	case 'N':
+		// name image: id in namelen name
+		if err := need(7); err != nil {
+			return 0, err
+		}
+		n := int(b[6])
+		if err := need(7 + n); err != nil {
+			return 0, err
+		}
+		i, err := s.image(b[1:])
+		if err != nil {
+			return 0, err
+		}
+		name := string(b[7 : 7+n])
+		if b[5] != 0 {
+			s.names[name] = i
+		} else {
+			delete(s.names, name)
+		}
+		return 7 + n, nil
+
+	case 'n':
+		// attach to named image: id namelen name
+		if err := need(6); err != nil {
+			return 0, err
+		}
+		n := int(b[5])
+		if err := need(6 + n); err != nil {
+			return 0, err
+		}
+		name := string(b[6 : 6+n])
+		i, ok := s.names[name]
+		if !ok {
+			return 0, fmt.Errorf("no image named %q", name)
+		}
+		s.images[uint32(glong(b[1:]))] = i
+		return 6 + n, nil
+
+	case 'o':
+		// set window origin: id rmin screenrmin
+		// Windows on the in-memory screen never move, nothing to do.
+		if err := need(21); err != nil {
+			return 0, err
+		}
+		return 21, nil
+
+	case 'O':
+		// set compositing operator for next draw operation: op
+		if err := need(2); err != nil {
+			return 0, err
+		}
+		s.op = draw.Op(b[1])
+		return 2, nil
+
+	case 'p', 'P':
+		// polygon (or filled): dstid n end0 end1 radius srcid sp coords
+		if err := need(31); err != nil {
+			return 0, err
+		}
+		dst, err := s.image(b[1:])
+		if err != nil {
+			return 0, err
+		}
+		src, err := s.image(b[19:])
+		if err != nil {
+			return 0, err
+		}
+		n := gshort(b[5:]) + 1
+		pts := make([]image.Point, n)
+		rest := b[31:]
+		o := image.ZP
+		for i := range pts {
+			o.X, rest, err = gcoord(rest, o.X)
+			if err != nil {
+				return 0, err
+			}
+			o.Y, rest, err = gcoord(rest, o.Y)
+			if err != nil {
+				return 0, err
+			}
+			pts[i] = o
+		}
+		end0, end1, radius := glong(b[7:]), glong(b[11:]), glong(b[15:])
+		if b[0] == 'P' {
+			memFillPoly(dst, pts, end0, src, gpoint(b[23:]), op)
+		} else {
+			memPoly(dst, pts, end0, end1, radius, src, gpoint(b[23:]), op)
+		}
+		return len(b) - len(rest), nil
+
+	case 'r':
+		// read image data: id R
+		if err := need(21); err != nil {
+			return 0, err
+		}
+		i, err := s.image(b[1:])
+		if err != nil {
+			return 0, err
+		}
+		data, err := i.unload(grect(b[5:]))
+		if err != nil {
+			return 0, err
+		}
+		s.readData = data
+		return 21, nil
+
+	case 's', 'x':
+		// string (with background): dstid srcid fontid P clipr sp n [bgid bgp] indices
+		if err := need(47); err != nil {
+			return 0, err
+		}
+		n := gshort(b[45:])
+		l := 47 + 2*n
+		o := 47
+		if b[0] == 'x' {
+			l += 12
+			o += 12
+		}
+		if err := need(l); err != nil {
+			return 0, err
+		}
+		dst, err := s.image(b[1:])
+		if err != nil {
+			return 0, err
+		}
+		src, err := s.image(b[5:])
+		if err != nil {
+			return 0, err
+		}
+		fi, err := s.image(b[9:])
+		if err != nil {
+			return 0, err
+		}
+		if fi.font == nil {
+			return 0, fmt.Errorf("image is not a font")
+		}
+		indices := make([]int, n)
+		for i := range indices {
+			indices[i] = gshort(b[o+2*i:])
+			if indices[i] >= len(fi.font.chars) {
+				return 0, fmt.Errorf("font index %d out of range", indices[i])
+			}
+		}
+		p := gpoint(b[13:])
+		clipr := dst.clipr
+		dst.clipr = grect(b[21:])
+		defer func() {
+			dst.clipr = clipr
+		}()
+		if b[0] == 'x' {
+			bg, err := s.image(b[47:])
+			if err != nil {
+				return 0, err
+			}
+			width := 0
+			for _, ci := range indices {
+				width += fi.font.chars[ci].width
+			}
+			r := image.Rect(p.X, p.Y-fi.font.ascent, p.X+width, p.Y-fi.font.ascent+fi.r.Dy())
+			memDraw(dst, r, bg, gpoint(b[51:]), s.opaque, image.ZP, op)
+		}
+		sp := gpoint(b[37:])
+		for _, ci := range indices {
+			c := fi.font.chars[ci]
+			r := image.Rectangle{image.Pt(p.X+c.left, p.Y-(fi.font.ascent-c.r.Min.Y)), image.ZP}
+			r.Max = r.Min.Add(c.r.Size())
+			memDraw(dst, r, src, image.Pt(sp.X+c.left, sp.Y+c.r.Min.Y), fi, c.r.Min, op)
+			p.X += c.width
+			sp.X += c.width
+		}
+		return l, nil
+
+	case 'S':
+		// attach to public screen: id chan
+		return 0, fmt.Errorf("public screens not supported")
+
+	case 't':
+		// top or bottom windows: top n ids
+		if err := need(4); err != nil {
+			return 0, err
+		}
+		l := 4 + 4*gshort(b[2:])
+		if err := need(l); err != nil {
+			return 0, err
+		}
+		return l, nil
+
+	case 'v':
+		// flush
+		s.flushed()
+		return 1, nil
+
+	case 'y', 'Y':
+		// load image data (compressed): id R data
+		if err := need(21); err != nil {
+			return 0, err
+		}
+		i, err := s.image(b[1:])
+		if err != nil {
+			return 0, err
+		}
+		r := grect(b[5:])
+		data := b[21:]
+		if b[0] == 'Y' {
+			var n int
+			data, n, err = uncompress(r, i.depth(), data)
+			if err != nil {
+				return 0, err
+			}
+			if _, err := i.load(r, data); err != nil {
+				return 0, err
+			}
+			return 21 + n, nil
+		}
+		n, err := i.load(r, data)
+		if err != nil {
+			return 0, err
+		}
+		return 21 + n, nil
+
+	case 'J':
+		// install screen image as image 0
+		s.images[0] = s.screen
+		return 1, nil
+
+	case 'I':
+		// info about image 0
+		i, ok := s.images[0]
+		if !ok {
+			return 0, fmt.Errorf("no screen image")
+		}
+		repl := 0
+		if i.repl {
+			repl = 1
+		}
+		s.readData = []byte(fmt.Sprintf("%11d %11d %11s %11d %11d %11d %11d %11d %11d %11d %11d %11d ", 0, 0, i.pix.String(), repl, i.r.Min.X, i.r.Min.Y, i.r.Max.X, i.r.Max.Y, i.clipr.Min.X, i.clipr.Min.Y, i.clipr.Max.X, i.clipr.Max.Y))
+		return 1, nil
+
+	case 'q':
+		// query: n queries
+		if err := need(2); err != nil {
+			return 0, err
+		}
+		n := int(b[1])
+		if err := need(2 + n); err != nil {
+			return 0, err
+		}
+		var data []byte
+		for _, c := range b[2 : 2+n] {
+			switch c {
+			case 'd':
+				data = append(data, fmt.Sprintf("%11d ", s.DPI)...)
+			default:
+				return 0, fmt.Errorf("unknown query %q", c)
+			}
+		}
+		s.readData = data
+		return 2 + n, nil
+	}
+}
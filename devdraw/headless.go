@@ -0,0 +1,57 @@
+package devdraw
+
+import (
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+// Headless is a display backend that draws into memory instead of a window on the screen.
+// It implements duit.Backend, set it in duit.DUIOpts.Backend.
+// Mouse and keyboard input are synthesized with SendMouse and SendKey, the contents of the screen are read with Screenshot.
+// Programs and test binaries using Headless must call Main.
+type Headless struct {
+	Server *Server // Serves the display, from the calling process.
+}
+
+// NewHeadless returns a new headless backend. A new screen is created when the backend is initialized by a DUI.
+func NewHeadless() *Headless {
+	return &Headless{NewServer()}
+}
+
+// Init connects a new display to the in-memory screen, with dimensions as initial size, eg "800x600".
+// The returned controls deliver input sent through SendMouse and SendKey. The mouse control starts out with a mouse at 0,0, like a real display.
+// Errors, including io.EOF after the display has been closed, are sent on errch.
+func (h *Headless) Init(errch chan<- error, fontName, label, dimensions string) (*draw.Display, *draw.Mousectl, *draw.Keyboardctl, error) {
+	display, err := h.Server.Init(errch, fontName, label, dimensions)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return display, display.InitMouse(), display.InitKeyboard(), nil
+}
+
+// SendMouse delivers a mouse event, as if the mouse moved or a button changed.
+func (h *Headless) SendMouse(m draw.Mouse) {
+	h.Server.Mouse(m)
+}
+
+// SendKey delivers a key press.
+func (h *Headless) SendKey(k rune) {
+	h.Server.Key(k)
+}
+
+// Resize changes the size of the screen, and signals the resize to the mouse control, as happens when the user resizes a window.
+func (h *Headless) Resize(size image.Point) error {
+	return h.Server.Resize(size)
+}
+
+// SetSize changes the size of the screen without signaling a resize, see Server.SetSize.
+// It makes the headless backend usable for duit.DUI.Replay.
+func (h *Headless) SetSize(size image.Point) error {
+	return h.Server.SetSize(size)
+}
+
+// Screenshot returns a copy of the contents of the screen.
+func (h *Headless) Screenshot() *image.RGBA {
+	return h.Server.Screen()
+}
@@ -0,0 +1,148 @@
+package devdraw_test
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"testing"
+	"time"
+
+	"9fans.net/go/draw"
+
+	"github.com/mjl-/duit/devdraw"
+)
+
+func TestMain(m *testing.M) {
+	devdraw.Main()
+	os.Exit(m.Run())
+}
+
+func initHeadless(t *testing.T, dimensions string) (*devdraw.Headless, *draw.Display, *draw.Mousectl, *draw.Keyboardctl) {
+	t.Helper()
+	h := devdraw.NewHeadless()
+	errch := make(chan error, 1)
+	display, mousectl, keyctl, err := h.Init(errch, "", "test", dimensions)
+	if err != nil {
+		t.Fatalf("init: %s", err)
+	}
+	// The initial mouse event.
+	<-mousectl.C
+	return h, display, mousectl, keyctl
+}
+
+func TestDraw(t *testing.T) {
+	h, display, _, _ := initHeadless(t, "40x30")
+	defer display.Close()
+
+	if size := h.Screenshot().Bounds().Size(); size != image.Pt(40, 30) {
+		t.Fatalf("screen size %v, expected 40x30", size)
+	}
+	display.ScreenImage.Draw(image.Rect(10, 10, 20, 20), display.Black, nil, image.ZP)
+	flushed := h.Server.Flushed()
+	display.Flush()
+	<-flushed
+
+	img := h.Screenshot()
+	black := color.RGBA{0, 0, 0, 0xff}
+	if c := img.RGBAAt(15, 15); c != black {
+		t.Fatalf("pixel inside rectangle is %v, expected black", c)
+	}
+	if c := img.RGBAAt(5, 5); c == black {
+		t.Fatalf("pixel outside rectangle is black")
+	}
+}
+
+func TestInput(t *testing.T) {
+	h, display, mousectl, keyctl := initHeadless(t, "40x30")
+	defer display.Close()
+
+	m := draw.Mouse{Point: image.Pt(3, 4), Buttons: 1, Msec: 1}
+	h.SendMouse(m)
+	if got := <-mousectl.C; got != m {
+		t.Fatalf("mouse %v, expected %v", got, m)
+	}
+
+	for _, k := range []rune{'a', ' ', draw.KeyUp} {
+		h.SendKey(k)
+		if got := <-keyctl.C; got != k {
+			t.Fatalf("key %x, expected %x", got, k)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	h, display, mousectl, _ := initHeadless(t, "40x30")
+	defer display.Close()
+
+	h.SendMouse(draw.Mouse{Point: image.Pt(5, 6)})
+	<-mousectl.C
+
+	if err := h.Resize(image.Pt(0, 10)); err == nil {
+		t.Fatalf("resize to empty size succeeded")
+	}
+	if err := h.Resize(image.Pt(60, 50)); err != nil {
+		t.Fatalf("resize: %s", err)
+	}
+	select {
+	case <-mousectl.Resize:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no resize signaled")
+	}
+	// Like a real display, a resize is followed by a mouse event at the current position.
+	if m := <-mousectl.C; m.Point != image.Pt(5, 6) {
+		t.Fatalf("mouse after resize at %v, expected 5,6", m.Point)
+	}
+	if err := display.Attach(draw.Refmesg); err != nil {
+		t.Fatalf("attach: %s", err)
+	}
+	if size := display.ScreenImage.R.Size(); size != image.Pt(60, 50) {
+		t.Fatalf("screen image size %v after resize, expected 60x50", size)
+	}
+	if size := h.Screenshot().Bounds().Size(); size != image.Pt(60, 50) {
+		t.Fatalf("screenshot size %v after resize, expected 60x50", size)
+	}
+}
+
+func TestSetSize(t *testing.T) {
+	h, display, mousectl, _ := initHeadless(t, "40x30")
+	defer display.Close()
+
+	if err := h.SetSize(image.Pt(60, 50)); err != nil {
+		t.Fatalf("set size: %s", err)
+	}
+	select {
+	case <-mousectl.Resize:
+		t.Fatalf("resize signaled for set size")
+	case m := <-mousectl.C:
+		t.Fatalf("mouse event %v for set size", m)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := display.Attach(draw.Refmesg); err != nil {
+		t.Fatalf("attach: %s", err)
+	}
+	if size := display.ScreenImage.R.Size(); size != image.Pt(60, 50) {
+		t.Fatalf("screen image size %v after set size, expected 60x50", size)
+	}
+}
+
+func TestSnarf(t *testing.T) {
+	h, display, _, _ := initHeadless(t, "40x30")
+	defer display.Close()
+
+	if err := display.WriteSnarf([]byte("from client")); err != nil {
+		t.Fatalf("write snarf: %s", err)
+	}
+	if buf := h.Server.Snarf(); string(buf) != "from client" {
+		t.Fatalf("snarf %q, expected %q", buf, "from client")
+	}
+
+	h.Server.SetSnarf([]byte("from server"))
+	buf := make([]byte, 100)
+	n, _, err := display.ReadSnarf(buf)
+	if err != nil {
+		t.Fatalf("read snarf: %s", err)
+	}
+	if string(buf[:n]) != "from server" {
+		t.Fatalf("read snarf %q, expected %q", buf[:n], "from server")
+	}
+}
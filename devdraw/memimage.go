@@ -0,0 +1,385 @@
+package devdraw
+
+import (
+	"fmt"
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+// memImage is an image held by the server. Pixels are stored premultiplied in an image.RGBA, regardless of pix.
+// After each modification, pixels are normalized to what can be represented in pix.
+type memImage struct {
+	pix   draw.Pix
+	r     image.Rectangle
+	clipr image.Rectangle
+	repl  bool
+	img   *image.RGBA // shared between a screen image and the windows on it
+	font  *memFont    // set if the image is used as font cache
+
+	channels []channel
+	alpha    bool // whether pix has an alpha channel
+	lossless bool // whether pix stores 8 bit r, g, b and alpha, needing no normalization
+}
+
+// channel is a single channel of a pixel in a pix descriptor, e.g. the "r8" in "r8g8b8".
+type channel struct {
+	kind  int // draw.CRed, draw.CGrey, etc.
+	nbits uint
+	shift uint // of the channel within the pixel, the last channel in a pix string is at shift 0
+}
+
+type memFont struct {
+	ascent int
+	chars  []fontChar
+}
+
+// fontChar describes a character loaded into a font cache image.
+type fontChar struct {
+	r     image.Rectangle // in cache image
+	left  int
+	width int
+}
+
+func parseChannels(pix draw.Pix) (l []channel, depth int) {
+	shift := uint(0)
+	for p := pix; p != 0; p >>= 8 {
+		c := channel{kind: int(p>>4) & 15, nbits: uint(p & 15), shift: shift}
+		l = append(l, c)
+		shift += c.nbits
+	}
+	return l, int(shift)
+}
+
+func newMemImage(pix draw.Pix, r image.Rectangle, repl bool, clipr image.Rectangle, val draw.Color) (*memImage, error) {
+	channels, depth := parseChannels(pix)
+	if depth == 0 || depth > 32 || (depth < 8 && 8%depth != 0) || (depth > 8 && depth%8 != 0) {
+		return nil, fmt.Errorf("bad channel descriptor %v", pix)
+	}
+	if r.Dx() <= 0 || r.Dy() <= 0 {
+		return nil, fmt.Errorf("bad rectangle %v", r)
+	}
+	i := &memImage{
+		pix:      pix,
+		r:        r,
+		clipr:    clipr,
+		repl:     repl,
+		img:      image.NewRGBA(r),
+		channels: channels,
+	}
+	var have [draw.NChan]int
+	for _, c := range channels {
+		if c.kind < draw.NChan {
+			have[c.kind] = int(c.nbits)
+		}
+	}
+	i.alpha = have[draw.CAlpha] > 0
+	i.lossless = have[draw.CRed] == 8 && have[draw.CGreen] == 8 && have[draw.CBlue] == 8 && have[draw.CAlpha] == 8
+	if val != draw.Nofill {
+		i.fill(r, val)
+	}
+	return i, nil
+}
+
+// window returns a new image for a window on i, sharing i's pixels.
+func (i *memImage) window(r image.Rectangle, val draw.Color) *memImage {
+	w := *i
+	w.r = r.Intersect(i.img.Rect)
+	w.clipr = w.r
+	w.repl = false
+	w.font = nil
+	if val != draw.Nofill {
+		w.fill(w.r, val)
+	}
+	return &w
+}
+
+func (i *memImage) depth() int {
+	return i.pix.Depth()
+}
+
+func (i *memImage) fill(r image.Rectangle, val draw.Color) {
+	r = r.Intersect(i.img.Rect)
+	c := [4]uint8{uint8(val >> 24), uint8(val >> 16), uint8(val >> 8), uint8(val)}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		o := i.img.PixOffset(r.Min.X, y)
+		for x := r.Min.X; x < r.Max.X; x++ {
+			copy(i.img.Pix[o:o+4], c[:])
+			o += 4
+		}
+	}
+	i.normalize(r)
+}
+
+// normalize makes the pixels in r representable in i.pix, by dropping alpha, converting to grey and reducing precision.
+func (i *memImage) normalize(r image.Rectangle) {
+	if i.lossless {
+		return
+	}
+	r = r.Intersect(i.img.Rect)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		o := i.img.PixOffset(r.Min.X, y)
+		for x := r.Min.X; x < r.Max.X; x++ {
+			p := i.img.Pix[o : o+4 : o+4]
+			i.unpack(i.pack(p), p)
+			o += 4
+		}
+	}
+}
+
+// pack returns the raw pixel value for the premultiplied color p.
+func (i *memImage) pack(p []uint8) uint32 {
+	var v uint32
+	for _, c := range i.channels {
+		var x uint8
+		switch c.kind {
+		case draw.CRed:
+			x = p[0]
+		case draw.CGreen:
+			x = p[1]
+		case draw.CBlue:
+			x = p[2]
+		case draw.CGrey:
+			x = grey(p)
+		case draw.CAlpha:
+			x = p[3]
+		case draw.CMap:
+			v |= uint32(rgb2cmap(int(p[0]), int(p[1]), int(p[2]))) << c.shift
+			continue
+		default:
+			continue
+		}
+		v |= uint32(x>>(8-c.nbits)) << c.shift
+	}
+	return v
+}
+
+// unpack stores the premultiplied color for raw pixel value v in p.
+func (i *memImage) unpack(v uint32, p []uint8) {
+	p[0], p[1], p[2], p[3] = 0, 0, 0, 0xff
+	color := false
+	for _, c := range i.channels {
+		x := (v >> c.shift) & (1<<c.nbits - 1)
+		switch c.kind {
+		case draw.CRed:
+			p[0] = expand(x, c.nbits)
+			color = true
+		case draw.CGreen:
+			p[1] = expand(x, c.nbits)
+			color = true
+		case draw.CBlue:
+			p[2] = expand(x, c.nbits)
+			color = true
+		case draw.CGrey:
+			g := expand(x, c.nbits)
+			p[0], p[1], p[2] = g, g, g
+			color = true
+		case draw.CAlpha:
+			p[3] = expand(x, c.nbits)
+		case draw.CMap:
+			r, g, b := cmap2rgb(int(x))
+			p[0], p[1], p[2] = uint8(r), uint8(g), uint8(b)
+			color = true
+		}
+	}
+	if !color {
+		// alpha-only image, premultiplied white
+		p[0], p[1], p[2] = p[3], p[3], p[3]
+	}
+}
+
+// expand scales an n-bit value to 8 bits by replicating its bits.
+func expand(x uint32, n uint) uint8 {
+	if n == 0 {
+		return 0
+	}
+	v := x << (8 - n)
+	for s := n; s < 8; s *= 2 {
+		v |= v >> s
+	}
+	return uint8(v)
+}
+
+func grey(p []uint8) uint8 {
+	return uint8((299*int(p[0]) + 587*int(p[1]) + 114*int(p[2]) + 500) / 1000)
+}
+
+// maskAlpha returns the value of pixel p when i is used as a mask.
+// Images without alpha channel, like the grey font cache, act as mask through their grey value.
+func (i *memImage) maskAlpha(p []uint8) uint8 {
+	if i.alpha {
+		return p[3]
+	}
+	return grey(p)
+}
+
+// bitOffset returns the offset in bits of pixel x within a scan line of raw data that starts at r.Min.X.
+func bitOffset(r image.Rectangle, x, depth int) int {
+	return x*depth - floorDiv(r.Min.X*depth, 8)*8
+}
+
+func floorDiv(a, b int) int {
+	if a >= 0 {
+		return a / b
+	}
+	return -((-a + b - 1) / b)
+}
+
+// load stores raw pixel data in r, as sent in the 'y' message.
+func (i *memImage) load(r image.Rectangle, data []byte) (int, error) {
+	if !r.In(i.r) {
+		return 0, fmt.Errorf("bad rectangle %v for image %v", r, i.r)
+	}
+	depth := i.depth()
+	bpl := draw.BytesPerLine(r, depth)
+	n := bpl * r.Dy()
+	if len(data) < n {
+		return 0, fmt.Errorf("short image data, %d < %d", len(data), n)
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		line := data[(y-r.Min.Y)*bpl:]
+		o := i.img.PixOffset(r.Min.X, y)
+		for x := r.Min.X; x < r.Max.X; x++ {
+			i.unpack(getPixel(line, bitOffset(r, x, depth), depth), i.img.Pix[o:o+4:o+4])
+			o += 4
+		}
+	}
+	return n, nil
+}
+
+// unload returns raw pixel data for r, as requested with the 'r' message.
+func (i *memImage) unload(r image.Rectangle) ([]byte, error) {
+	if !r.In(i.r) {
+		return nil, fmt.Errorf("bad rectangle %v for image %v", r, i.r)
+	}
+	depth := i.depth()
+	bpl := draw.BytesPerLine(r, depth)
+	data := make([]byte, bpl*r.Dy())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		line := data[(y-r.Min.Y)*bpl:]
+		o := i.img.PixOffset(r.Min.X, y)
+		for x := r.Min.X; x < r.Max.X; x++ {
+			putPixel(line, bitOffset(r, x, depth), depth, i.pack(i.img.Pix[o:o+4:o+4]))
+			o += 4
+		}
+	}
+	return data, nil
+}
+
+// getPixel reads a raw pixel. Pixels smaller than a byte are packed with the leftmost pixel in the high bits. Larger pixels are little endian.
+func getPixel(line []byte, bit, depth int) uint32 {
+	if depth < 8 {
+		b := line[bit/8]
+		shift := uint(8 - depth - bit%8)
+		return uint32(b>>shift) & (1<<uint(depth) - 1)
+	}
+	var v uint32
+	o := bit / 8
+	for k := 0; k < depth/8; k++ {
+		v |= uint32(line[o+k]) << uint(8*k)
+	}
+	return v
+}
+
+func putPixel(line []byte, bit, depth int, v uint32) {
+	if depth < 8 {
+		shift := uint(8 - depth - bit%8)
+		mask := byte(1<<uint(depth)-1) << shift
+		line[bit/8] = line[bit/8]&^mask | byte(v)<<shift&mask
+		return
+	}
+	o := bit / 8
+	for k := 0; k < depth/8; k++ {
+		line[o+k] = byte(v >> uint(8*k))
+	}
+}
+
+// uncompress expands the compressed image data for r, as sent in the 'Y' message.
+// It returns the uncompressed data and the number of bytes of compressed data consumed.
+func uncompress(r image.Rectangle, depth int, data []byte) ([]byte, int, error) {
+	const (
+		nmem   = 1024 // window size
+		nmatch = 3    // shortest match possible
+	)
+	bpl := draw.BytesPerLine(r, depth)
+	out := make([]byte, bpl*r.Dy())
+	var mem [nmem]byte
+	memp := 0
+	o := 0
+	u := 0
+	for o < len(out) {
+		if u >= len(data) {
+			return nil, 0, fmt.Errorf("short compressed data")
+		}
+		c := int(data[u])
+		u++
+		if c >= 128 {
+			for cnt := c - 128 + 1; cnt > 0; cnt-- {
+				if u >= len(data) || o >= len(out) {
+					return nil, 0, fmt.Errorf("bad compressed data")
+				}
+				out[o] = data[u]
+				mem[memp] = data[u]
+				o++
+				u++
+				memp = (memp + 1) % nmem
+			}
+			continue
+		}
+		if u >= len(data) {
+			return nil, 0, fmt.Errorf("short compressed data")
+		}
+		offs := int(data[u]) + (c&3)<<8 + 1
+		u++
+		omemp := (memp - offs + nmem) % nmem
+		for cnt := c>>2 + nmatch; cnt > 0; cnt-- {
+			if o >= len(out) {
+				return nil, 0, fmt.Errorf("bad compressed data")
+			}
+			out[o] = mem[omemp]
+			mem[memp] = mem[omemp]
+			o++
+			memp = (memp + 1) % nmem
+			omemp = (omemp + 1) % nmem
+		}
+	}
+	return out, u, nil
+}
+
+func cmap2rgb(c int) (r, g, b int) {
+	r = c >> 6
+	v := (c >> 4) & 3
+	j := (c - v + r) & 15
+	g = j >> 2
+	b = j & 3
+	den := r
+	if g > den {
+		den = g
+	}
+	if b > den {
+		den = b
+	}
+	if den == 0 {
+		v *= 17
+		return v, v, v
+	}
+	num := 17 * (4*den + v)
+	r = r * num / den
+	g = g * num / den
+	b = b * num / den
+	return
+}
+
+func rgb2cmap(cr, cg, cb int) int {
+	best := 0
+	bestsq := 0x7FFFFFFF
+	for i := 0; i < 256; i++ {
+		r, g, b := cmap2rgb(i)
+		sq := (r-cr)*(r-cr) + (g-cg)*(g-cg) + (b-cb)*(b-cb)
+		if sq < bestsq {
+			bestsq = sq
+			best = i
+		}
+	}
+	return best
+}
@@ -0,0 +1,141 @@
+package devdraw
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"9fans.net/go/draw"
+)
+
+// Package draw always starts devdraw as a new process, taken from $DEVDRAW, talking over stdin/stdout.
+// To serve it from this process, we point $DEVDRAW to our own executable, with relayEnv set to a unix socket we listen on.
+// In the child process, Main notices relayEnv and copies data between stdin/stdout and the socket.
+const relayEnv = "DUIT_DEVDRAW_RELAY"
+
+// mainCalled is set by Main. Without it, Init cannot start a relay.
+var mainCalled bool
+
+// Main must be called at the start of programs that use Server.Init or Headless, before anything else, e.g. first thing in main.
+// Test binaries call it from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		devdraw.Main()
+//		os.Exit(m.Run())
+//	}
+//
+// Server.Init starts the executable of the program again, as devdraw for package draw.
+// In that process, Main relays the devdraw protocol to the server in the original process, and exits.
+// In all other cases, Main returns immediately.
+func Main() {
+	mainCalled = true
+	path := os.Getenv(relayEnv)
+	if path == "" || len(os.Args) == 0 || os.Args[len(os.Args)-1] != "(devdraw)" {
+		return
+	}
+	os.Exit(relay(path))
+}
+
+// relay copies data between stdin/stdout and the unix socket at path until either side is closed.
+func relay(path string) int {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "devdraw relay: %s\n", err)
+		return 1
+	}
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(conn, os.Stdin)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(os.Stdout, conn)
+		done <- struct{}{}
+	}()
+	<-done
+	return 0
+}
+
+// initLock protects the environment while package draw starts the relay.
+var initLock sync.Mutex
+
+// Init is like draw.Init, but with s serving the display from this process instead of an external devdraw.
+// When the connection is gone, e.g. because the display was closed, the error (typically io.EOF) is sent on errch.
+// If fontName is empty, the builtin font is used, regardless of $font.
+// Init starts the executable of the program as relay, which must call Main, see Main.
+func (s *Server) Init(errch chan<- error, fontName, label, winsize string) (*draw.Display, error) {
+	if !mainCalled {
+		return nil, fmt.Errorf("devdraw.Main not called, needed for starting the devdraw relay")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("finding executable for devdraw relay: %s", err)
+	}
+	dir, err := ioutil.TempDir("", "devdraw")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	l, err := net.Listen("unix", filepath.Join(dir, "devdraw"))
+	if err != nil {
+		return nil, err
+	}
+	defer l.Close()
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			// Listener closed, draw.Init failed.
+			return
+		}
+		err = s.Serve(conn)
+		conn.Close()
+		select {
+		case errch <- err:
+		default:
+		}
+	}()
+
+	initLock.Lock()
+	defer initLock.Unlock()
+	env := map[string]string{
+		"DEVDRAW": exe,
+		relayEnv:  l.Addr().String(),
+	}
+	if fontName == "" {
+		env["font"] = ""
+	}
+	restore := setenv(env)
+	defer restore()
+	return draw.Init(errch, fontName, label, winsize)
+}
+
+// setenv sets environment variables, unsetting empty values, and returns a function that restores the original environment.
+func setenv(env map[string]string) (restore func()) {
+	type saved struct {
+		value string
+		ok    bool
+	}
+	orig := map[string]saved{}
+	set := func(k, v string, ok bool) {
+		if ok && v != "" {
+			os.Setenv(k, v)
+		} else {
+			os.Unsetenv(k)
+		}
+	}
+	for k, v := range env {
+		ov, ok := os.LookupEnv(k)
+		orig[k] = saved{ov, ok}
+		set(k, v, true)
+	}
+	return func() {
+		for k, o := range orig {
+			set(k, o.value, o.ok)
+		}
+	}
+}
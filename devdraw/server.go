@@ -0,0 +1,338 @@
+package devdraw
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"sync"
+
+	"9fans.net/go/draw"
+	"9fans.net/go/draw/drawfcall"
+)
+
+// DefaultSize is the size of the screen when the client does not ask for a size.
+var DefaultSize = image.Pt(800, 600)
+
+// Server is a devdraw server that draws into an in-memory screen.
+// It speaks the protocol of plan9port's devdraw, as used by package 9fans.net/go/draw.
+//
+// Mouse and keyboard input is injected with Mouse and Key. Resize changes the screen size and tells the client, like a user resizing a window.
+// The client reads and writes the snarf buffer, accessible through Snarf and SetSnarf.
+type Server struct {
+	DPI int // Reported to clients, e.g. 100 (the default) for lowDPI, or 200 for hiDPI. Set before serving.
+
+	images   map[uint32]*memImage
+	screens  map[uint32]*memScreen
+	names    map[string]*memImage
+	screen   *memImage // Current screen image, installed as image 0 on request.
+	opaque   *memImage // For drawing without a mask.
+	op       draw.Op   // For next draw operation.
+	readData []byte    // Response for next Trddraw.
+	label    string
+	snarf    []byte
+
+	mouse        draw.Mouse    // Last mouse state, sent or warped to.
+	mouseQueue   []mouseEvent  // Waiting for Trdmouse.
+	mouseWaiting []uint8       // Tags of Trdmouse requests waiting for an event.
+	keyQueue     []rune        // Waiting for Trdkbd.
+	keyWaiting   []uint8       // Tags of Trdkbd requests waiting for a key.
+	w            io.Writer     // Connection currently served, for responding to waiting requests.
+	flush        chan struct{} // Closed and replaced by a new channel on each flush.
+
+	mu    sync.Mutex // For all fields above.
+	wlock sync.Mutex // For writing responses.
+}
+
+type memScreen struct {
+	image *memImage
+	fill  *memImage
+}
+
+type mouseEvent struct {
+	mouse   draw.Mouse
+	resized bool
+}
+
+// NewServer returns a new server, without screen. A screen is created when a client initializes the connection.
+func NewServer() *Server {
+	opaque, err := newMemImage(draw.GREY1, image.Rect(0, 0, 1, 1), true, image.Rect(-0x3FFFFFFF, -0x3FFFFFFF, 0x3FFFFFFF, 0x3FFFFFFF), draw.White)
+	if err != nil {
+		panic(err)
+	}
+	return &Server{
+		DPI:     100,
+		images:  map[uint32]*memImage{},
+		screens: map[uint32]*memScreen{},
+		names:   map[string]*memImage{},
+		opaque:  opaque,
+		op:      draw.SoverD,
+		flush:   make(chan struct{}),
+	}
+}
+
+// Serve reads requests from rw and writes responses to it, until reading fails, typically with io.EOF when the client closed the connection.
+// A server serves a single connection.
+func (s *Server) Serve(rw io.ReadWriter) error {
+	s.mu.Lock()
+	s.w = rw
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.w = nil
+		s.mu.Unlock()
+	}()
+
+	for {
+		buf, err := drawfcall.ReadMsg(rw)
+		if err != nil {
+			return err
+		}
+		var m drawfcall.Msg
+		if err := m.Unmarshal(buf); err != nil {
+			return err
+		}
+		r := s.handle(&m)
+		if r == nil {
+			// Response is sent when input arrives.
+			continue
+		}
+		r.Tag = m.Tag
+		if err := s.write(rw, r); err != nil {
+			return err
+		}
+		if m.Type == drawfcall.Tresize {
+			// Only now that the client has its response, send the resize event.
+			s.respondWaiting()
+		}
+	}
+}
+
+func (s *Server) write(w io.Writer, m *drawfcall.Msg) error {
+	s.wlock.Lock()
+	defer s.wlock.Unlock()
+	_, err := w.Write(m.Marshal())
+	return err
+}
+
+func rerror(err error) *drawfcall.Msg {
+	return &drawfcall.Msg{Type: drawfcall.Rerror, Error: err.Error()}
+}
+
+// handle processes request m and returns the response.
+// For reads of mouse and keyboard that cannot be answered yet, handle returns nil. They are answered when input arrives.
+func (s *Server) handle(m *drawfcall.Msg) *drawfcall.Msg {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := &drawfcall.Msg{Type: m.Type + 1}
+	switch m.Type {
+	case drawfcall.Tinit:
+		if s.screen != nil {
+			return rerror(fmt.Errorf("already initialized"))
+		}
+		size := DefaultSize
+		if m.Winsize != "" {
+			var x, y int
+			if _, err := fmt.Sscanf(m.Winsize, "%dx%d", &x, &y); err != nil || x <= 0 || y <= 0 {
+				return rerror(fmt.Errorf("bad window size %q", m.Winsize))
+			}
+			size = image.Pt(x, y)
+		}
+		s.label = m.Label
+		s.resize(size)
+		// Like a newly mapped window, start with a mouse event with the current position.
+		s.mouseQueue = append(s.mouseQueue, mouseEvent{s.mouse, false})
+	case drawfcall.Trddraw:
+		n := m.Count
+		if n > len(s.readData) {
+			n = len(s.readData)
+		}
+		r.Data = s.readData[:n]
+		s.readData = s.readData[n:]
+	case drawfcall.Twrdraw:
+		if s.screen == nil {
+			return rerror(fmt.Errorf("not initialized"))
+		}
+		if err := s.draw(m.Data); err != nil {
+			return rerror(err)
+		}
+		r.Count = len(m.Data)
+	case drawfcall.Trdmouse:
+		if len(s.mouseQueue) == 0 {
+			s.mouseWaiting = append(s.mouseWaiting, m.Tag)
+			return nil
+		}
+		s.mouseResponse(r)
+	case drawfcall.Trdkbd:
+		if len(s.keyQueue) == 0 {
+			s.keyWaiting = append(s.keyWaiting, m.Tag)
+			return nil
+		}
+		s.keyResponse(r)
+	case drawfcall.Tmoveto:
+		s.mouse.Point = m.Mouse.Point
+	case drawfcall.Trdsnarf:
+		r.Snarf = s.snarf
+	case drawfcall.Twrsnarf:
+		s.snarf = append([]byte{}, m.Snarf...)
+	case drawfcall.Tresize:
+		if m.Rect.Dx() <= 0 || m.Rect.Dy() <= 0 {
+			return rerror(fmt.Errorf("bad size %v", m.Rect))
+		}
+		s.resize(m.Rect.Size())
+		s.mouseQueue = append(s.mouseQueue, mouseEvent{s.mouse, true})
+	case drawfcall.Tlabel:
+		s.label = m.Label
+	case drawfcall.Tcursor, drawfcall.Tbouncemouse, drawfcall.Ttop:
+		// Nothing to do for an in-memory screen.
+	default:
+		return rerror(fmt.Errorf("unsupported message type %d", m.Type))
+	}
+	return r
+}
+
+// mouseResponse fills r with the first queued mouse event. Must be called with s.mu held.
+func (s *Server) mouseResponse(r *drawfcall.Msg) {
+	e := s.mouseQueue[0]
+	s.mouseQueue = s.mouseQueue[1:]
+	r.Type = drawfcall.Rrdmouse
+	r.Mouse = drawfcall.Mouse{Point: e.mouse.Point, Buttons: e.mouse.Buttons, Msec: int(e.mouse.Msec)}
+	r.Resized = e.resized
+}
+
+// keyResponse fills r with the first queued key. Must be called with s.mu held.
+func (s *Server) keyResponse(r *drawfcall.Msg) {
+	r.Type = drawfcall.Rrdkbd
+	r.Rune = s.keyQueue[0]
+	s.keyQueue = s.keyQueue[1:]
+}
+
+// respondWaiting answers waiting reads of mouse and keyboard with queued input.
+func (s *Server) respondWaiting() {
+	s.mu.Lock()
+	w := s.w
+	if w == nil {
+		s.mu.Unlock()
+		return
+	}
+	var l []*drawfcall.Msg
+	for len(s.mouseWaiting) > 0 && len(s.mouseQueue) > 0 {
+		r := &drawfcall.Msg{Tag: s.mouseWaiting[0]}
+		s.mouseWaiting = s.mouseWaiting[1:]
+		s.mouseResponse(r)
+		l = append(l, r)
+	}
+	for len(s.keyWaiting) > 0 && len(s.keyQueue) > 0 {
+		r := &drawfcall.Msg{Tag: s.keyWaiting[0]}
+		s.keyWaiting = s.keyWaiting[1:]
+		s.keyResponse(r)
+		l = append(l, r)
+	}
+	s.mu.Unlock()
+
+	for _, r := range l {
+		// Errors are noticed by Serve when reading.
+		s.write(w, r)
+	}
+}
+
+// Mouse injects a mouse event, as if the mouse moved or a button changed.
+// The event is delivered when the client reads the mouse.
+func (s *Server) Mouse(m draw.Mouse) {
+	s.mu.Lock()
+	s.mouse = m
+	s.mouseQueue = append(s.mouseQueue, mouseEvent{m, false})
+	s.mu.Unlock()
+	s.respondWaiting()
+}
+
+// Key injects a key press. The key is delivered when the client reads the keyboard.
+func (s *Server) Key(k rune) {
+	s.mu.Lock()
+	s.keyQueue = append(s.keyQueue, k)
+	s.mu.Unlock()
+	s.respondWaiting()
+}
+
+// resize replaces the screen image with a new image of size.
+// Clients see the new screen after they reattach.
+func (s *Server) resize(size image.Point) {
+	i, err := newMemImage(draw.XRGB32, image.Rectangle{image.ZP, size}, false, image.Rectangle{image.ZP, size}, draw.White)
+	if err != nil {
+		panic(err)
+	}
+	s.screen = i
+}
+
+// Resize changes the size of the screen, and sends a mouse event flagged as resize to the client.
+// The client sees the new screen after it reattaches.
+func (s *Server) Resize(size image.Point) error {
+	if size.X <= 0 || size.Y <= 0 {
+		return fmt.Errorf("bad size %v", size)
+	}
+	s.mu.Lock()
+	s.resize(size)
+	s.mouseQueue = append(s.mouseQueue, mouseEvent{s.mouse, true})
+	s.mu.Unlock()
+	s.respondWaiting()
+	return nil
+}
+
+// SetSize changes the size of the screen like Resize, but without telling the client.
+// It is for clients that reattach by themselves, like duit.DUI.Replay replaying a recording.
+func (s *Server) SetSize(size image.Point) error {
+	if size.X <= 0 || size.Y <= 0 {
+		return fmt.Errorf("bad size %v", size)
+	}
+	s.mu.Lock()
+	s.resize(size)
+	s.mu.Unlock()
+	return nil
+}
+
+// flushed signals waiters for a flush. Must be called with s.mu held.
+func (s *Server) flushed() {
+	close(s.flush)
+	s.flush = make(chan struct{})
+}
+
+// Flushed returns a channel that is closed when the client next flushes its drawing to the screen.
+func (s *Server) Flushed() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flush
+}
+
+// Screen returns a copy of the current screen contents.
+// Nil is returned if no client has initialized the screen yet.
+func (s *Server) Screen() *image.RGBA {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.screen == nil {
+		return nil
+	}
+	img := image.NewRGBA(s.screen.r)
+	copy(img.Pix, s.screen.img.Pix)
+	return img
+}
+
+// Label returns the window label as set by the client.
+func (s *Server) Label() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.label
+}
+
+// Snarf returns the contents of the snarf buffer, as written by the client.
+func (s *Server) Snarf() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]byte{}, s.snarf...)
+}
+
+// SetSnarf sets the contents of the snarf buffer, for reading by the client.
+func (s *Server) SetSnarf(buf []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snarf = append([]byte{}, buf...)
+}
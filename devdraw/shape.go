@@ -0,0 +1,308 @@
+package devdraw
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"9fans.net/go/draw"
+)
+
+// Line end styles, see line(3) in plan 9.
+const (
+	endSquare = 0
+	endDisc   = 1
+	endArrow  = 2
+	endMask   = 0x1f
+)
+
+// Shapes are drawn by first determining which pixels they cover, in a coverage mask.
+// The source is then drawn onto the destination through that mask.
+// Pixel coordinates are used as the centers of pixels, so a point p covers pixel p.
+
+// coverage is a mask of pixels covered by a shape.
+type coverage struct {
+	*memImage
+}
+
+// newCoverage returns a coverage for r, clipped to what is drawable on dst. If nothing remains, ok is false.
+func newCoverage(dst *memImage, r image.Rectangle) (cov coverage, ok bool) {
+	r = r.Intersect(dst.r.Intersect(dst.clipr))
+	if r.Empty() {
+		return cov, false
+	}
+	i, err := newMemImage(draw.GREY8, r, false, r, draw.Nofill)
+	if err != nil {
+		return cov, false
+	}
+	return coverage{i}, true
+}
+
+func (c coverage) set(x, y int) {
+	if !image.Pt(x, y).In(c.r) {
+		return
+	}
+	o := c.img.PixOffset(x, y)
+	copy(c.img.Pix[o:o+4], []uint8{0xff, 0xff, 0xff, 0xff})
+}
+
+// draw composites src onto dst through the coverage. Point sp in src is aligned with p in dst.
+func (c coverage) draw(dst, src *memImage, p, sp image.Point, op draw.Op) {
+	memDraw(dst, c.r, src, sp.Add(c.r.Min.Sub(p)), c.memImage, c.r.Min, op)
+}
+
+type fpoint struct {
+	x, y float64
+}
+
+// fillPolygon marks the pixels with their centers inside the polygon, using wind as mask on the winding number.
+// A wind of ^0 fills with the non-zero rule, a wind of 1 with the even-odd rule.
+func (c coverage) fillPolygon(pts []fpoint, wind int) {
+	if len(pts) < 3 {
+		return
+	}
+	type crossing struct {
+		x   float64
+		dir int
+	}
+	for y := c.r.Min.Y; y < c.r.Max.Y; y++ {
+		fy := float64(y)
+		var xs []crossing
+		for i, a := range pts {
+			b := pts[(i+1)%len(pts)]
+			dir := 1
+			if a.y > b.y {
+				a, b = b, a
+				dir = -1
+			}
+			if fy < a.y || fy >= b.y {
+				continue
+			}
+			x := a.x + (fy-a.y)*(b.x-a.x)/(b.y-a.y)
+			xs = append(xs, crossing{x, dir})
+		}
+		sort.Slice(xs, func(i, j int) bool {
+			return xs[i].x < xs[j].x
+		})
+		w := 0
+		for i := 0; i+1 < len(xs); i++ {
+			w += xs[i].dir
+			if w&wind == 0 {
+				continue
+			}
+			x0 := int(math.Ceil(xs[i].x))
+			x1 := int(math.Ceil(xs[i+1].x))
+			for x := maximum(x0, c.r.Min.X); x < x1 && x < c.r.Max.X; x++ {
+				c.set(x, y)
+			}
+		}
+	}
+}
+
+// fillEllipse marks the pixels inside the ellipse at center with radii a and b.
+// If inner radii are > 0, pixels inside the inner ellipse are not marked.
+// If arc is set, only pixels within the angles alpha through alpha+phi (in degrees, counter-clockwise from the x-axis) are marked.
+func (c coverage) fillEllipse(center image.Point, a, b, innerA, innerB float64, arc bool, alpha, phi int) {
+	if phi < 0 {
+		alpha += phi
+		phi = -phi
+	}
+	if phi >= 360 {
+		arc = false
+	}
+	alpha = ((alpha % 360) + 360) % 360
+	in := func(x, y, a, b float64) bool {
+		return (x*x)/(a*a)+(y*y)/(b*b) <= 1
+	}
+	for y := c.r.Min.Y; y < c.r.Max.Y; y++ {
+		for x := c.r.Min.X; x < c.r.Max.X; x++ {
+			dx := float64(x - center.X)
+			dy := float64(y - center.Y)
+			if a <= 0 || b <= 0 || !in(dx, dy, a, b) {
+				continue
+			}
+			if innerA > 0 && innerB > 0 && in(dx, dy, innerA, innerB) && !onEdge(dx, dy, innerA, innerB) {
+				continue
+			}
+			if arc {
+				deg := math.Atan2(-dy, dx) * 180 / math.Pi
+				d := int(math.Floor(deg+0.5)) - alpha
+				d = ((d % 360) + 360) % 360
+				if d > phi {
+					continue
+				}
+			}
+			c.set(x, y)
+		}
+	}
+}
+
+// onEdge returns whether point x,y lies on the ellipse, within rounding.
+func onEdge(x, y, a, b float64) bool {
+	v := (x*x)/(a*a) + (y*y)/(b*b)
+	return math.Abs(v-1) < 1e-9
+}
+
+// thinLine marks the pixels on the line from p0 to p1, including both end points.
+func (c coverage) thinLine(p0, p1 image.Point) {
+	dx := abs(p1.X - p0.X)
+	dy := -abs(p1.Y - p0.Y)
+	sx, sy := 1, 1
+	if p0.X > p1.X {
+		sx = -1
+	}
+	if p0.Y > p1.Y {
+		sy = -1
+	}
+	e := dx + dy
+	p := p0
+	for {
+		c.set(p.X, p.Y)
+		if p == p1 {
+			break
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			p.X += sx
+		}
+		if e2 <= dx {
+			e += dx
+			p.Y += sy
+		}
+	}
+}
+
+// line marks the pixels of the line from p0 to p1 with thickness 1+2*radius.
+func (c coverage) line(p0, p1 image.Point, end0, end1, radius int) {
+	if radius <= 0 {
+		c.thinLine(p0, p1)
+		return
+	}
+	h := float64(radius) + 0.5
+	dx := float64(p1.X - p0.X)
+	dy := float64(p1.Y - p0.Y)
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		dx, dy, l = 1, 0, 1
+	}
+	// unit vectors along and perpendicular to the line
+	ux, uy := dx/l, dy/l
+	nx, ny := -uy*h, ux*h
+	ex, ey := ux*0.5, uy*0.5
+	a := fpoint{float64(p0.X) - ex, float64(p0.Y) - ey}
+	b := fpoint{float64(p1.X) + ex, float64(p1.Y) + ey}
+	c.fillPolygon([]fpoint{
+		{a.x + nx, a.y + ny},
+		{b.x + nx, b.y + ny},
+		{b.x - nx, b.y - ny},
+		{a.x - nx, a.y - ny},
+	}, ^0)
+	if end0&endMask == endDisc {
+		c.fillEllipse(p0, h, h, 0, 0, false, 0, 0)
+	}
+	if end1&endMask == endDisc {
+		c.fillEllipse(p1, h, h, 0, 0, false, 0, 0)
+	}
+}
+
+func lineBounds(p0, p1 image.Point, radius int) image.Rectangle {
+	r := image.Rectangle{p0, p1}.Canon()
+	r.Max = r.Max.Add(image.Pt(1, 1))
+	return r.Inset(-(radius + 1))
+}
+
+// memLine draws a line from p0 to p1 with src, with sp in src aligned to p0.
+func memLine(dst *memImage, p0, p1 image.Point, end0, end1, radius int, src *memImage, sp image.Point, op draw.Op) {
+	cov, ok := newCoverage(dst, lineBounds(p0, p1, radius))
+	if !ok {
+		return
+	}
+	cov.line(p0, p1, end0, end1, radius)
+	cov.draw(dst, src, p0, sp, op)
+}
+
+// memEllipse draws an ellipse or arc, filled or with thickness 1+2*thick. Point sp in src is aligned with center c.
+func memEllipse(dst *memImage, c image.Point, a, b, thick int, src *memImage, sp image.Point, arc bool, alpha, phi int, fill bool, op draw.Op) {
+	if a < 0 || b < 0 {
+		return
+	}
+	t := thick
+	if fill || t < 0 {
+		t = 0
+	}
+	cov, ok := newCoverage(dst, image.Rect(c.X-a-t-1, c.Y-b-t-1, c.X+a+t+2, c.Y+b+t+2))
+	if !ok {
+		return
+	}
+	oa := float64(a+t) + 0.5
+	ob := float64(b+t) + 0.5
+	if fill {
+		cov.fillEllipse(c, oa, ob, 0, 0, arc, alpha, phi)
+	} else {
+		cov.fillEllipse(c, oa, ob, float64(a-t)-0.5, float64(b-t)-0.5, arc, alpha, phi)
+	}
+	cov.draw(dst, src, c, sp, op)
+}
+
+// memPoly draws lines connecting the points, with end0 for the first and end1 for the last point. Point sp in src is aligned with pts[0].
+func memPoly(dst *memImage, pts []image.Point, end0, end1, radius int, src *memImage, sp image.Point, op draw.Op) {
+	if len(pts) == 0 {
+		return
+	}
+	r := image.Rectangle{pts[0], pts[0]}
+	for _, p := range pts[1:] {
+		r = r.Union(image.Rectangle{p, p})
+	}
+	cov, ok := newCoverage(dst, lineBounds(r.Min, r.Max, radius))
+	if !ok {
+		return
+	}
+	if len(pts) == 1 {
+		cov.line(pts[0], pts[0], end0, end1, radius)
+	}
+	for i := 1; i < len(pts); i++ {
+		e0, e1 := endDisc, endDisc
+		if i == 1 {
+			e0 = end0
+		}
+		if i == len(pts)-1 {
+			e1 = end1
+		}
+		cov.line(pts[i-1], pts[i], e0, e1, radius)
+	}
+	cov.draw(dst, src, pts[0], sp, op)
+}
+
+// memFillPoly fills the polygon, with wind as mask on the winding number. Point sp in src is aligned with pts[0].
+func memFillPoly(dst *memImage, pts []image.Point, wind int, src *memImage, sp image.Point, op draw.Op) {
+	if len(pts) == 0 {
+		return
+	}
+	r := image.Rectangle{pts[0], pts[0]}
+	fpts := make([]fpoint, len(pts))
+	for i, p := range pts {
+		r = r.Union(image.Rectangle{p, p})
+		fpts[i] = fpoint{float64(p.X), float64(p.Y)}
+	}
+	cov, ok := newCoverage(dst, r.Inset(-1))
+	if !ok {
+		return
+	}
+	cov.fillPolygon(fpts, wind)
+	cov.draw(dst, src, pts[0], sp, op)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func maximum(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,344 @@
+package duit
+
+import (
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+// Dialog is a modal dialog, drawn in the middle of the window over the dimmed UI, see DUI.ShowDialog.
+// While a dialog is shown, the UI below it, including other overlays, receives no mouse or key input.
+// An escape key that is not consumed by the dialog cancels it.
+type Dialog struct {
+	Title  string              // Shown in bold above UI, if not empty.
+	UI     UI                  // Contents of the dialog, e.g. a Grid with a Label and buttons.
+	Width  int                 // Width in lowDPI pixels available for UI. If 0, UI can use the width of the window. Set it for UIs that take all the width they get, like a Field.
+	Focus  UI                  // UI within UI that gets keyboard focus when the dialog is shown, e.g. a Field or the primary button. If nil, no UI has focus.
+	Closed func(canceled bool) // Called after the dialog was closed by CloseDialog, or canceled by escape or by closing a dialog below it. Not called when the window is closed.
+
+	overlay *Overlay
+}
+
+// dialogShown returns whether a dialog is shown, in any of the overlays.
+func (d *DUI) dialogShown() bool {
+	for _, o := range d.overlays {
+		if o.modal {
+			return true
+		}
+	}
+	return false
+}
+
+// ShowDialog shows dlg as a modal dialog in an overlay, on top of the UI and the overlays already shown, and gives keyboard focus to dlg.Focus.
+// The dialog stays open until it is closed with CloseDialog, typically from a button click in the dialog, or canceled with escape.
+// Dialogs can be shown from a dialog, each new dialog is modal for the dialogs below it.
+// ShowDialog must be called from the main loop, e.g. from a callback, see ShowDialogWait for goroutines.
+func (d *DUI) ShowDialog(dlg *Dialog) {
+	if dlg.overlay != nil {
+		return
+	}
+	if d.backdrop == nil {
+		img, err := d.Display.AllocImage(image.Rect(0, 0, 1, 1), draw.ARGB32, true, 0x00000040)
+		if d.error(err, "allocimage") {
+			return
+		}
+		d.backdrop = img
+	}
+
+	kids := NewKids(dlg.UI)
+	if dlg.Title != "" {
+		title := &Label{Text: dlg.Title, Font: d.FontStyle(FontStyle{Weight: FontBold})}
+		kids = append(NewKids(title), kids...)
+	}
+	padding := SpaceXY(12, 6)
+	ui := &dialogUI{
+		kid:   Kid{UI: &Grid{Kids: kids, Columns: 1, Padding: []Space{padding}}},
+		title: dlg.Title,
+	}
+	if dlg.Width > 0 {
+		ui.width = dlg.Width + padding.Size().X
+	}
+	o := &Overlay{Kid: Kid{UI: ui}, modal: true}
+	o.Dismissed = func() {
+		if dlg.overlay == o {
+			dlg.overlay = nil
+			if dlg.Closed != nil {
+				dlg.Closed(true)
+			}
+		}
+	}
+	dlg.overlay = o
+	d.AddOverlay(o)
+	o.UI.Layout(d, &o.Kid, d.Display.ScreenImage.R.Size(), true)
+	if dlg.Focus != nil {
+		d.setFocus(dlg.Focus, nil, true)
+	} else {
+		// Focus on the dialog itself, keys for the UI below are blocked, and focus is restored when the dialog closes.
+		d.setFocus(ui, nil, false)
+	}
+}
+
+// CloseDialog closes dlg, and calls its Closed function with canceled.
+// Overlays shown on top of dlg, like a dialog opened from dlg, are dismissed.
+// Keyboard focus returns to where it was before dlg was shown.
+func (d *DUI) CloseDialog(dlg *Dialog, canceled bool) {
+	o := dlg.overlay
+	if o == nil {
+		return
+	}
+	for i, x := range d.overlays {
+		if x != o {
+			continue
+		}
+		if i+1 < len(d.overlays) {
+			d.removeOverlays(i+1, true)
+		}
+		d.removeOverlays(i, false)
+		break
+	}
+	dlg.overlay = nil
+	if dlg.Closed != nil {
+		dlg.Closed(canceled)
+	}
+}
+
+// Confirm shows a dialog with text and a button for each of buttons, and calls done with the index of the button clicked.
+// The first button is the primary button, and has keyboard focus. If buttons is empty, a single button labeled OK is shown.
+// If the dialog is canceled with escape, done is called with -1.
+// Confirm must be called from the main loop, see ConfirmWait for goroutines.
+func (d *DUI) Confirm(text string, buttons []string, done func(choice int)) {
+	if len(buttons) == 0 {
+		buttons = []string{"OK"}
+	}
+	choice := -1
+	dlg := &Dialog{
+		Closed: func(canceled bool) {
+			if canceled {
+				choice = -1
+			}
+			done(choice)
+		},
+	}
+	row := &Box{Margin: image.Pt(6, 0)}
+	for i, s := range buttons {
+		i := i
+		b := &Button{
+			Text: s,
+			Click: func() (e Event) {
+				choice = i
+				d.CloseDialog(dlg, false)
+				return
+			},
+		}
+		if i == 0 {
+			b.Colorset = &d.Primary
+			dlg.Focus = b
+		}
+		row.Kids = append(row.Kids, &Kid{UI: b})
+	}
+	dlg.UI = &Grid{
+		Kids:    NewKids(&Label{Text: text}, row),
+		Columns: 1,
+		Padding: []Space{SpaceXY(0, 4)},
+	}
+	d.ShowDialog(dlg)
+}
+
+// Prompt shows a dialog with text, a field holding value, and buttons OK and Cancel, and calls done with the text of the field and ok set when the dialog was submitted with OK or enter.
+// If the dialog is canceled, with the Cancel button or escape, done is called with the text of the field and ok false.
+// Prompt must be called from the main loop, see PromptWait for goroutines.
+func (d *DUI) Prompt(text, value string, done func(value string, ok bool)) {
+	field := &Field{Text: value}
+	if value != "" {
+		// Selected, typing replaces the value.
+		field.SelectionStart1 = 1
+	}
+	dlg := &Dialog{
+		Width: 300,
+		Focus: field,
+		Closed: func(canceled bool) {
+			done(field.Text, !canceled)
+		},
+	}
+	submit := &Button{Text: "OK", Colorset: &d.Primary}
+	cancel := &Button{
+		Text: "Cancel",
+		Click: func() (e Event) {
+			d.CloseDialog(dlg, true)
+			return
+		},
+	}
+	dlg.UI = &Form{
+		Kid: Kid{
+			UI: &Grid{
+				Kids:    NewKids(&Label{Text: text}, field, &Box{Margin: image.Pt(6, 0), Kids: NewKids(submit, cancel)}),
+				Columns: 1,
+				Padding: []Space{SpaceXY(0, 4)},
+			},
+		},
+		Submit: submit,
+		Submitted: func() (e Event) {
+			d.CloseDialog(dlg, false)
+			return
+		},
+	}
+	d.ShowDialog(dlg)
+}
+
+// ShowDialogWait shows dlg like ShowDialog, and waits until it is closed, returning whether it was canceled.
+// If the window is closed while waiting, ShowDialogWait returns true.
+// ShowDialogWait is for goroutines outside the main loop: called from the main loop, e.g. from a callback, it blocks forever.
+func (d *DUI) ShowDialogWait(dlg *Dialog) (canceled bool) {
+	c := make(chan bool, 1)
+	ok := d.call(func() {
+		closed := dlg.Closed
+		dlg.Closed = func(canceled bool) {
+			dlg.Closed = closed
+			if closed != nil {
+				closed(canceled)
+			}
+			c <- canceled
+		}
+		d.ShowDialog(dlg)
+	})
+	if !ok {
+		return true
+	}
+	select {
+	case canceled = <-c:
+		return canceled
+	case <-d.done:
+		return true
+	}
+}
+
+// ConfirmWait is like Confirm, but waits for the dialog to be closed and returns the index of the button clicked, or -1 if the dialog was canceled or the window closed.
+// ConfirmWait is for goroutines outside the main loop, like ShowDialogWait.
+func (d *DUI) ConfirmWait(text string, buttons ...string) int {
+	c := make(chan int, 1)
+	ok := d.call(func() {
+		d.Confirm(text, buttons, func(choice int) {
+			c <- choice
+		})
+	})
+	if !ok {
+		return -1
+	}
+	select {
+	case choice := <-c:
+		return choice
+	case <-d.done:
+		return -1
+	}
+}
+
+// PromptWait is like Prompt, but waits for the dialog to be closed and returns the text of the field, with ok false if the dialog was canceled or the window closed.
+// PromptWait is for goroutines outside the main loop, like ShowDialogWait.
+func (d *DUI) PromptWait(text, value string) (string, bool) {
+	type result struct {
+		value string
+		ok    bool
+	}
+	c := make(chan result, 1)
+	ok := d.call(func() {
+		d.Prompt(text, value, func(value string, ok bool) {
+			c <- result{value, ok}
+		})
+	})
+	if !ok {
+		return value, false
+	}
+	select {
+	case r := <-c:
+		return r.value, r.ok
+	case <-d.done:
+		return value, false
+	}
+}
+
+// dialogUI is the UI of the overlay of a dialog. It covers the entire window, blocking input for the UI below, and draws the dialog box in the middle.
+type dialogUI struct {
+	kid   Kid             // Title and contents.
+	title string          // Of the dialog, for accessibility.
+	width int             // Of kid in lowDPI pixels, 0 for the width of the window.
+	box   image.Rectangle // Location of the dialog box, with border.
+}
+
+var _ UI = &dialogUI{}
+
+func (ui *dialogUI) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+	border := pt(2 * BorderSize)
+	avail := sizeAvail.Sub(border)
+	if ui.width > 0 {
+		avail.X = minimum(avail.X, dui.Scale(ui.width))
+	}
+	ui.kid.UI.Layout(dui, &ui.kid, avail, true)
+	size := ui.kid.R.Size().Add(border)
+	p := sizeAvail.Sub(size).Div(2)
+	ui.box = rect(size).Add(image.Pt(maximum(0, p.X), maximum(0, p.Y)))
+	ui.kid.R = rect(ui.kid.R.Size()).Add(ui.box.Min).Add(pt(BorderSize))
+	self.R = rect(sizeAvail)
+	self.Draw = Dirty
+}
+
+func (ui *dialogUI) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	dui.debugDraw(self)
+	r := ui.box.Add(orig)
+	img.Draw(r, dui.Background, nil, image.ZP)
+	drawRoundedBorder(img, r, dui.Regular.Normal.Border)
+	m.Point = m.Point.Sub(ui.kid.R.Min)
+	ui.kid.UI.Draw(dui, &ui.kid, img, orig.Add(ui.kid.R.Min), m, true)
+}
+
+func (ui *dialogUI) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	if !origM.Point.In(ui.kid.R) {
+		// A click on the backdrop keeps keyboard focus where it is, within the dialog.
+		r.Hit = dui.focus
+		return
+	}
+	return KidsMouse(dui, self, []*Kid{&ui.kid}, m, origM, orig)
+}
+
+func (ui *dialogUI) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	return KidsKey(dui, self, []*Kid{&ui.kid}, k, m, orig)
+}
+
+func (ui *dialogUI) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	return KidsFirstFocus(dui, self, []*Kid{&ui.kid})
+}
+
+func (ui *dialogUI) LastFocus(dui *DUI, self *Kid) *image.Point {
+	return KidsLastFocus(dui, self, []*Kid{&ui.kid})
+}
+
+func (ui *dialogUI) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	if o == ui {
+		// On the border of the box, keys are not delivered to the contents.
+		p := ui.box.Min
+		return &p
+	}
+	return KidsFocus(dui, self, []*Kid{&ui.kid}, o)
+}
+
+func (ui *dialogUI) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return KidsMark(self, []*Kid{&ui.kid}, o, forLayout)
+}
+
+func (ui *dialogUI) Children() []*Kid {
+	return []*Kid{&ui.kid}
+}
+
+func (ui *dialogUI) Print(self *Kid, indent int) {
+	PrintUI("dialog", self, indent)
+	ui.kid.UI.Print(&ui.kid, indent+1)
+}
+
+func (ui *dialogUI) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	return &AccessNode{
+		Role:     RoleDialog,
+		Name:     ui.title,
+		Rect:     ui.box.Add(orig),
+		Children: []*AccessNode{dui.AccessKid(&ui.kid, orig.Add(ui.kid.R.Min))},
+	}
+}
@@ -0,0 +1,196 @@
+package duit_test
+
+import (
+	"testing"
+	"time"
+
+	"9fans.net/go/draw"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/duittest"
+)
+
+// dialogTester returns a tester with a button below the dialogs, and a pointer to its number of clicks.
+func dialogTester(t *testing.T) (*duittest.Tester, *duit.Button, *int) {
+	clicks := new(int)
+	button := &duit.Button{Text: "below", Click: func() (e duit.Event) {
+		*clicks++
+		return
+	}}
+	dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(button)}, &duit.DUIOpts{Dimensions: "300x200"})
+	return dt, button, clicks
+}
+
+func TestConfirm(t *testing.T) {
+	dt, button, clicks := dialogTester(t)
+	defer dt.Close()
+	before := dt.Screenshot()
+
+	choice := -2
+	dt.DUI.Confirm("sure?", []string{"Yes", "No"}, func(c int) {
+		choice = c
+	})
+	dt.DUI.Render()
+	if c, prev := dt.Screenshot().RGBAAt(299, 199), before.RGBAAt(299, 199); c == prev {
+		t.Fatalf("ui below dialog not dimmed, pixel %v", c)
+	}
+
+	// The UI below receives no input.
+	dt.Click(dt.Center(button))
+	if *clicks != 0 {
+		t.Fatalf("button below dialog clicked")
+	}
+
+	// The primary button has focus, also with the mouse over the button below. Closing the dialog removes the dimming.
+	dt.Key('\n')
+	if choice != 0 || *clicks != 0 {
+		t.Fatalf("choice %d, clicks %d, expected 0 and 0", choice, *clicks)
+	}
+	if _, n := duittest.Diff(before, dt.Screenshot()); n != 0 {
+		t.Fatalf("%d pixels differ after closing dialog", n)
+	}
+	dt.Click(dt.Center(button))
+	if *clicks != 1 {
+		t.Fatalf("got %d clicks after closing dialog, expected 1", *clicks)
+	}
+}
+
+func TestConfirmEscape(t *testing.T) {
+	dt, _, _ := dialogTester(t)
+	defer dt.Close()
+
+	choice := -2
+	dt.DUI.Confirm("sure?", nil, func(c int) {
+		choice = c
+	})
+	dt.DUI.Render()
+	dt.Key(draw.KeyEscape)
+	if choice != -1 {
+		t.Fatalf("choice %d after escape, expected -1", choice)
+	}
+}
+
+func TestDialogCommands(t *testing.T) {
+	dt, _, _ := dialogTester(t)
+	defer dt.Close()
+	runs := 0
+	err := dt.DUI.AddCommand(&duit.Command{Name: "test.count", Keys: []rune{draw.KeyCmd + 'g'}, Run: func(dui *duit.DUI, ui duit.UI) {
+		runs++
+	}})
+	if err != nil {
+		t.Fatalf("add command: %s", err)
+	}
+
+	// Global commands and the palette are not run while the dialog is shown.
+	dt.DUI.Confirm("sure?", nil, func(c int) {})
+	dt.DUI.Render()
+	dt.Key(draw.KeyCmd + 'p')
+	dt.Key(draw.KeyCmd + 'g')
+	dt.Key(draw.KeyFn + 10)
+	if n := len(dt.DUI.Overlays()); n != 1 || runs != 0 {
+		t.Fatalf("%d overlays and %d runs with dialog, expected 1 and 0", n, runs)
+	}
+	dt.Key(draw.KeyEscape)
+	if n := len(dt.DUI.Overlays()); n != 0 {
+		t.Fatalf("%d overlays after escape, expected 0", n)
+	}
+	dt.Key(draw.KeyCmd + 'g')
+	if runs != 1 {
+		t.Fatalf("%d runs after closing dialog, expected 1", runs)
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	dt, _, _ := dialogTester(t)
+	defer dt.Close()
+
+	var value string
+	var ok bool
+	done := func(v string, o bool) {
+		value, ok = v, o
+	}
+	// The value is selected, typing replaces it.
+	dt.DUI.Prompt("name?", "ab", done)
+	dt.DUI.Render()
+	dt.Type("cd\n")
+	if value != "cd" || !ok {
+		t.Fatalf("prompt gave %q, ok %v, expected %q and ok", value, ok, "cd")
+	}
+
+	dt.DUI.Prompt("name?", "", done)
+	dt.DUI.Render()
+	dt.Type("e")
+	dt.Key(draw.KeyEscape)
+	if value != "e" || ok {
+		t.Fatalf("canceled prompt gave %q, ok %v, expected %q and not ok", value, ok, "e")
+	}
+}
+
+func TestDialogNested(t *testing.T) {
+	dt, _, _ := dialogTester(t)
+	defer dt.Close()
+
+	var closed []string
+	inner := &duit.Dialog{UI: &duit.Label{Text: "inner"}, Closed: func(canceled bool) {
+		closed = append(closed, "inner")
+	}}
+	open := &duit.Button{Text: "open", Click: func() (e duit.Event) {
+		dt.DUI.ShowDialog(inner)
+		return
+	}}
+	outer := &duit.Dialog{Title: "outer", UI: open, Focus: open, Closed: func(canceled bool) {
+		closed = append(closed, "outer")
+	}}
+	dt.DUI.ShowDialog(outer)
+	dt.DUI.Render()
+	dt.Key('\n')
+
+	// Closing the outer dialog cancels the inner dialog.
+	dt.DUI.CloseDialog(outer, false)
+	if len(closed) != 2 || closed[0] != "inner" || closed[1] != "outer" {
+		t.Fatalf("closed %v, expected inner and outer", closed)
+	}
+}
+
+func TestConfirmWait(t *testing.T) {
+	dt, _, _ := dialogTester(t)
+	defer dt.Close()
+
+	choice := make(chan int, 1)
+	go func() {
+		choice <- dt.DUI.ConfirmWait("sure?", "Yes", "No")
+	}()
+	// Handle the function showing the dialog.
+	e := <-dt.DUI.Inputs
+	dt.DUI.Input(e)
+	dt.Key('\t')
+	dt.Key('\n')
+	select {
+	case c := <-choice:
+		if c != 1 {
+			t.Fatalf("choice %d, expected 1", c)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("confirm wait did not return")
+	}
+}
+
+func TestConfirmWaitClose(t *testing.T) {
+	dt, _, _ := dialogTester(t)
+
+	choice := make(chan int, 1)
+	go func() {
+		choice <- dt.DUI.ConfirmWait("sure?")
+	}()
+	e := <-dt.DUI.Inputs
+	dt.DUI.Input(e)
+	dt.Close()
+	select {
+	case c := <-choice:
+		if c != -1 {
+			t.Fatalf("choice %d after closing window, expected -1", c)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("confirm wait did not return after close")
+	}
+}
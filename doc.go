@@ -11,10 +11,28 @@ The user interface consists of a hierarchy of "UIs" like Box, Scroll, Button, La
 
 UIs are kept/wrapped in a Kid, to track their layout/draw state. Use NewKids() to build up the UIs for your application. You won't see much of the Kid-types/functions otherwise, unless you implement a new UI.
 
-You are in charge of the main event loop, receiving mouse/keyboard/window events from the dui.Inputs channel, and typically passing them on unchanged to dui.Input. All callbacks and functions on UIs are called from inside dui.Input. From there you can also safely change the the UIs, no locking required. After changing a UI you are responsible for calling MarkLayout or MarkDraw to tell duit the UI needs a new layout or draw. This may sound like more work, but this tradeoff keeps the API small and easy to use. If you need to change the UI from a goroutine outside of the main loop, e.g. for blocking calls, you can send a function that makes those modifications on the dui.Call channel, which will be run on the main channel through dui.Inputs. After handling an input, duit will layout or draw as necessary, no need to render explicitly.
+You are in charge of the main event loop, receiving mouse/keyboard/window events from the dui.Inputs channel, and typically passing them on unchanged to dui.Input. Most programs let dui.Run run the main loop, until the window is closed or its context is canceled, with hooks for receiving from channels of the application. Programs with multiple windows create them with an App, whose Run serves all windows from a single loop. All callbacks and functions on UIs are called from inside dui.Input. From there you can also safely change the the UIs, no locking required. After changing a UI you are responsible for calling MarkLayout or MarkDraw to tell duit the UI needs a new layout or draw. This may sound like more work, but this tradeoff keeps the API small and easy to use. If you need to change the UI from a goroutine outside of the main loop, e.g. for blocking calls, you can send a function that makes those modifications on the dui.Call channel, which will be run on the main channel through dui.Inputs. For animations and other work at a later time, dui.After, dui.Every and dui.Frame schedule functions to run in the main loop. After handling an input, duit will layout or draw as necessary, no need to render explicitly.
+
+Instead of keeping UIs and your data in sync with Changed functions, you can bind UIs to observable values, like StringValue and BoolValue. Setting a value, from any goroutine, updates the bound UIs in the main loop. Changes made by the user in a bound UI update the value and the other UIs bound to it.
+
+Input in a Field can be checked by wrapping it in a Validation with validators, e.g. ValidateRequired. Invalid fields get a red border and an error message. A Form holding validations disables its submit button while a field is invalid.
+
+Modal dialogs are drawn inside the window, over the dimmed UI: dui.Confirm asks a question with a choice of buttons, dui.Prompt asks for a text, and dui.ShowDialog shows any UI. While a dialog is open, the UI below it receives no input. ConfirmWait, PromptWait and ShowDialogWait block until the dialog is closed, for use from goroutines outside the main loop.
+
+Keyboard shortcuts are commands registered with dui.AddCommand. Keys can be changed with DUI.KeyBindings or a keys.json file in the config directory of the application. F10 shows an overlay with all commands and their keys. Cmd-p opens a command palette for finding and running commands by name, built on Palette, a field with a list of values filtered by fuzzy search.
+
+Colors are set with a Theme, see DUI.SetTheme. LightTheme is the default, DarkTheme is built in as well. Users can choose a theme per application, see NewDUI.
+
+Fonts can be selected by style, e.g. a larger monospaced font, with dui.FontStyle, resolved through the font registry in dui.Fonts. Users can zoom all fonts with cmd-+ and cmd--.
 
 Embedding a UI into your own data structure is often an easy way to build up UI hiearchies.
 
+A UI tree can also be read from JSON, in the format that is written for a Kid with encoding/json. Each Kid has a Type field naming its UI type, registered with RegisterUI. Functions such as Click and Changed are set afterwards, on the UIs found by Kid.ID with KidsByID. ReadUI reads a more concise UI description, for UIs designed in a file. LoadUI loads such a file as Top UI, and can reload it during development when the file changes.
+
+For assistive technology like screen readers, dui.AccessTree describes the UI tree with roles, names, values and states of UIs that implement Accessible. dui.ServeAccessibility publishes the tree as JSON over a unix domain socket, see its documentation for the protocol. Setting $DUIT_ACCESSIBILITY to a directory makes every DUI serve its tree there.
+
+To reproduce problems, inputs can be recorded to a file with dui.StartRecording, with F11, or for every DUI by setting $DUIT_RECORD to a directory. dui.Replay feeds a recording back through dui.Input. With the headless backend of package devdraw, replaying recordings in tests turns them into regression tests, see duittest.Tester.Replay.
+
 Scrolling
 
 Scroll and Edit show a scrollbar. Use button 1 on the scrollbar to scroll up, button 3 to scroll down. If you click more near the top, you scroll less. More near the bottom, more. Button 2 scrolls to the absolute place, where you clicked. Button 4 and 5 are wheel up and wheel down, and also scroll less/more depending on position in the UI.
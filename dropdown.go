@@ -0,0 +1,483 @@
+package duit
+
+import (
+	"image"
+	"strings"
+	"time"
+	"unicode"
+
+	"9fans.net/go/draw"
+)
+
+// Dropdown shows the selected value from Values, and opens a list with all values to choose from when clicked.
+// The list is shown in an overlay below the dropdown, and scrolls if there are many values.
+// Typing while the dropdown has focus selects the first value starting with the typed text.
+//
+// If Editable is set, the dropdown is a combobox: the text is shown in a field and can be edited.
+// Editing opens the list with only the values that contain the text, ignoring case.
+//
+// Keys:
+//
+//	arrow down, open list, or select next value in list
+//	arrow up, select previous value in list
+//	enter, open list, or choose selected value in list
+//	escape, close list
+type Dropdown struct {
+	Values      []*ListValue                 // Values to choose from. The first value with Selected set is shown.
+	Editable    bool                         // If set, the text can be edited, and the list only shows values containing the text.
+	Placeholder string                       // Shown in lighter color when no value is selected.
+	Disabled    bool                         // If disabled, the list cannot be opened and colors indicate disabledness.
+	Font        *draw.Font                   `json:"-"` // For drawing the text and values.
+	Changed     func(v *ListValue) (e Event) `json:"-"` // Called after a value was chosen from the list. Value of v holds its auxiliary data.
+
+	field     *Field   // For Editable, created on first layout.
+	fieldKid  Kid      // Holds field.
+	list      *List    // In the overlay with values to choose from, nil while closed. Value of each ListValue is the index in Values.
+	overlay   *Overlay // Shows list.
+	changed   bool     // Whether the field text changed while handling a key.
+	typed     string   // For selecting values by typing.
+	typedTime time.Time
+	size      image.Point
+	m         draw.Mouse
+}
+
+var _ UI = &Dropdown{}
+
+func (ui *Dropdown) font(dui *DUI) *draw.Font {
+	return dui.Font(ui.Font)
+}
+
+func (ui *Dropdown) space(dui *DUI) image.Point {
+	// padding + border
+	fontHeight := ui.font(dui).Height
+	return image.Pt(fontHeight/4, fontHeight/4).Add(pt(BorderSize))
+}
+
+// arrowWidth is the width of the area with the arrow at the right side of the dropdown.
+func (ui *Dropdown) arrowWidth(dui *DUI) int {
+	return ui.font(dui).Height
+}
+
+func (ui *Dropdown) selected() int {
+	for i, v := range ui.Values {
+		if v.Selected {
+			return i
+		}
+	}
+	return -1
+}
+
+func (ui *Dropdown) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+
+	font := ui.font(dui)
+	space := ui.space(dui)
+	arrowWidth := ui.arrowWidth(dui)
+	if ui.Editable {
+		if ui.field == nil {
+			ui.field = &Field{
+				Changed: func(text string) (e Event) {
+					ui.changed = true
+					return
+				},
+			}
+			if i := ui.selected(); i >= 0 {
+				ui.field.Text = ui.Values[i].Text
+			}
+			ui.fieldKid.UI = ui.field
+		}
+		ui.field.Placeholder = ui.Placeholder
+		ui.field.Disabled = ui.Disabled
+		ui.field.Font = ui.Font
+		ui.field.Layout(dui, &ui.fieldKid, image.Pt(sizeAvail.X-arrowWidth, sizeAvail.Y), force)
+		ui.size = image.Pt(sizeAvail.X, ui.fieldKid.R.Dy())
+	} else {
+		width := font.StringWidth(ui.Placeholder)
+		for _, v := range ui.Values {
+			width = maximum(width, font.StringWidth(v.Text))
+		}
+		ui.size = image.Pt(width+2*space.X+arrowWidth, font.Height+2*space.Y)
+		ui.size.X = minimum(ui.size.X, sizeAvail.X)
+	}
+	self.R = rect(ui.size)
+}
+
+func (ui *Dropdown) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	dui.debugDraw(self)
+
+	r := rect(ui.size)
+	hover := m.In(r)
+	colors := dui.Regular.Normal
+	if ui.Disabled {
+		colors = dui.Disabled
+	} else if hover {
+		colors = dui.Regular.Hover
+	}
+	arrowR := r
+	arrowR.Min.X = arrowR.Max.X - ui.arrowWidth(dui)
+	r = r.Add(orig)
+	arrowR = arrowR.Add(orig)
+
+	if ui.Editable {
+		mm := m
+		mm.Point = mm.Point.Sub(ui.fieldKid.R.Min)
+		ui.field.Draw(dui, &ui.fieldKid, img, orig.Add(ui.fieldKid.R.Min), mm, true)
+		img.Draw(arrowR, colors.Background, nil, image.ZP)
+		drawRoundedBorder(img, arrowR, colors.Border)
+	} else {
+		img.Draw(r, colors.Background, nil, image.ZP)
+		drawRoundedBorder(img, r, colors.Border)
+		text := ""
+		if i := ui.selected(); i >= 0 {
+			text = ui.Values[i].Text
+		} else if !ui.Disabled {
+			text = ui.Placeholder
+			colors.Text = dui.Placeholder.Text
+		}
+		clipr := img.Clipr
+		img.ReplClipr(false, image.Rect(r.Min.X, r.Min.Y, arrowR.Min.X, r.Max.Y).Intersect(clipr))
+		img.String(r.Min.Add(ui.space(dui)), colors.Text, image.ZP, ui.font(dui), text)
+		img.ReplClipr(false, clipr)
+	}
+
+	// Triangle pointing down, drawn as rows of decreasing width.
+	size := ui.font(dui).Height / 4
+	p := arrowR.Min.Add(arrowR.Size().Div(2)).Sub(image.Pt(0, size/2))
+	for i := 0; i < size; i++ {
+		w := size - i
+		img.Draw(image.Rect(p.X-w, p.Y+i, p.X+w, p.Y+i+1), colors.Text, nil, image.ZP)
+	}
+}
+
+// open shows the list below the dropdown, with the values at indices.
+// The value shown in the dropdown is selected in the list, if present.
+func (ui *Dropdown) open(dui *DUI, orig image.Point, indices []int) {
+	if ui.overlay != nil {
+		dui.RemoveOverlay(ui.overlay)
+	}
+	sel := ui.selected()
+	values := make([]*ListValue, len(indices))
+	for i, index := range indices {
+		values[i] = &ListValue{Text: ui.Values[index].Text, Value: index, Selected: index == sel}
+	}
+	ui.list = &List{
+		Values: values,
+		Font:   ui.Font,
+		Click: func(index int, m draw.Mouse) (e Event) {
+			if m.Buttons == Button1 {
+				// The list is in an overlay, not below the dropdown, so the event of the dropdown is applied by marking it.
+				ce := ui.choose(dui, ui.list.Values[index].Value.(int))
+				if ce.NeedLayout {
+					dui.MarkLayout(ui)
+				}
+			}
+			e.Consumed = true
+			return
+		},
+	}
+	rows := minimum(len(values), 10)
+	ui.overlay = &Overlay{
+		Kid: Kid{UI: &dropdownList{
+			Kid:  Kid{UI: &Scroll{Kid: Kid{UI: ui.list}}},
+			list: ui.list,
+			size: image.Pt(ui.size.X, rows*ui.list.rowHeight(dui)+2*BorderSize),
+		}},
+		At: orig.Add(image.Pt(0, ui.size.Y)),
+		Dismissed: func() {
+			ui.list = nil
+			ui.overlay = nil
+		},
+	}
+	dui.AddOverlay(ui.overlay)
+}
+
+// close removes the list, if open.
+func (ui *Dropdown) close(dui *DUI) {
+	if ui.overlay != nil {
+		dui.RemoveOverlay(ui.overlay)
+	}
+	ui.list = nil
+	ui.overlay = nil
+}
+
+// all returns the indices of all values.
+func (ui *Dropdown) all() []int {
+	l := make([]int, len(ui.Values))
+	for i := range l {
+		l[i] = i
+	}
+	return l
+}
+
+// filter opens the list with the values that contain the text of the field, or closes the list if there are none.
+func (ui *Dropdown) filter(dui *DUI, orig image.Point) {
+	text := strings.ToLower(ui.field.Text)
+	var l []int
+	for i, v := range ui.Values {
+		if strings.Contains(strings.ToLower(v.Text), text) {
+			l = append(l, i)
+		}
+	}
+	if len(l) == 0 {
+		ui.close(dui)
+		return
+	}
+	ui.open(dui, orig, l)
+	if ui.listSelected() < 0 {
+		ui.list.Values[0].Selected = true
+	}
+}
+
+// listSelected returns the index of the selected value in the list, or -1.
+func (ui *Dropdown) listSelected() int {
+	return ui.list.firstSelected()
+}
+
+// selectList selects the value at index in the list, scrolling it into view.
+func (ui *Dropdown) selectList(dui *DUI, index int) {
+	for i, v := range ui.list.Values {
+		v.Selected = i == index
+	}
+	dui.MarkDraw(ui.list)
+	ui.reveal(dui)
+}
+
+// reveal scrolls the selected value in the list into view.
+func (ui *Dropdown) reveal(dui *DUI) {
+	o := ui.overlay
+	o.UI.Focus(dui, &o.Kid, ui.list)
+}
+
+// choose selects the value at index in Values, closes the list and calls Changed, returning its event.
+func (ui *Dropdown) choose(dui *DUI, index int) (e Event) {
+	for i, v := range ui.Values {
+		v.Selected = i == index
+	}
+	v := ui.Values[index]
+	if ui.Editable {
+		ui.field.Text = v.Text
+		ui.field.Cursor1 = 0
+		ui.field.SelectionStart1 = 0
+	}
+	ui.close(dui)
+	dui.MarkDraw(ui)
+	if ui.Changed != nil {
+		e = ui.Changed(v)
+	}
+	return
+}
+
+func (ui *Dropdown) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	r.Hit = ui
+	if ui.Disabled {
+		return
+	}
+	if ui.Editable && origM.X < ui.fieldKid.R.Max.X {
+		fm := m
+		fm.Point = fm.Point.Sub(ui.fieldKid.R.Min)
+		forigM := origM
+		forigM.Point = forigM.Point.Sub(ui.fieldKid.R.Min)
+		r = ui.field.Mouse(dui, &ui.fieldKid, fm, forigM, orig.Add(ui.fieldKid.R.Min))
+		if ui.fieldKid.Draw != Clean {
+			self.Draw = Dirty
+			ui.fieldKid.Draw = Clean
+		}
+		r.Hit = ui
+		return
+	}
+	if ui.m.Buttons != m.Buttons {
+		self.Draw = Dirty
+	}
+	if ui.m.Buttons == 0 && m.Buttons == Button1 && m.In(rect(ui.size)) {
+		// A press while the list is open does not get here, it closes the list as a click outside the overlay.
+		ui.open(dui, orig, ui.all())
+		r.Consumed = true
+	}
+	ui.m = m
+	return
+}
+
+func (ui *Dropdown) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	if ui.Disabled {
+		return
+	}
+	r.Hit = ui
+	switch k {
+	case draw.KeyDown, draw.KeyUp:
+		if ui.list == nil {
+			if k == draw.KeyDown {
+				ui.open(dui, orig, ui.all())
+			}
+		} else if len(ui.list.Values) > 0 {
+			i := ui.listSelected()
+			if k == draw.KeyDown {
+				i = minimum(i+1, len(ui.list.Values)-1)
+			} else {
+				i = maximum(i-1, 0)
+			}
+			ui.selectList(dui, i)
+		}
+		r.Consumed = true
+		return
+	case '\n':
+		if ui.list == nil {
+			ui.open(dui, orig, ui.all())
+		} else if i := ui.listSelected(); i >= 0 {
+			propagateEvent(self, &r, ui.choose(dui, ui.list.Values[i].Value.(int)))
+		}
+		r.Consumed = true
+		return
+	case '\t', dui.BackTab:
+		ui.close(dui)
+	}
+
+	if ui.Editable {
+		m.Point = m.Point.Sub(ui.fieldKid.R.Min)
+		ui.changed = false
+		r = ui.field.Key(dui, &ui.fieldKid, k, m, orig.Add(ui.fieldKid.R.Min))
+		if ui.fieldKid.Draw != Clean {
+			self.Draw = Dirty
+			ui.fieldKid.Draw = Clean
+		}
+		if ui.changed {
+			ui.filter(dui, orig)
+		}
+		r.Hit = ui
+		return
+	}
+
+	if !unicode.IsPrint(k) {
+		return
+	}
+	if time.Since(ui.typedTime) > time.Second {
+		ui.typed = ""
+	}
+	ui.typed += strings.ToLower(string(k))
+	ui.typedTime = time.Now()
+	r.Consumed = true
+	for i, v := range ui.Values {
+		if !strings.HasPrefix(strings.ToLower(v.Text), ui.typed) {
+			continue
+		}
+		if ui.list == nil {
+			propagateEvent(self, &r, ui.choose(dui, i))
+			return
+		}
+		for j, lv := range ui.list.Values {
+			if lv.Value.(int) == i {
+				ui.selectList(dui, j)
+				return
+			}
+		}
+	}
+	return
+}
+
+func (ui *Dropdown) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	if ui.Disabled {
+		return nil
+	}
+	p := ui.space(dui)
+	return &p
+}
+
+func (ui *Dropdown) LastFocus(dui *DUI, self *Kid) *image.Point {
+	return ui.FirstFocus(dui, self)
+}
+
+func (ui *Dropdown) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	if o != ui {
+		return nil
+	}
+	p := ui.space(dui)
+	return &p
+}
+
+func (ui *Dropdown) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return self.Mark(o, forLayout)
+}
+
+// Children returns the kid with the field of an editable dropdown, once it was layed out.
+func (ui *Dropdown) Children() []*Kid {
+	if !ui.Editable || ui.field == nil {
+		return nil
+	}
+	return []*Kid{&ui.fieldKid}
+}
+
+func (ui *Dropdown) Print(self *Kid, indent int) {
+	PrintUI("Dropdown", self, indent)
+}
+
+// dropdownList shows the list of a Dropdown with a border, in an overlay.
+type dropdownList struct {
+	Kid  Kid         // Scroll with the List.
+	list *List       // Its selected value is scrolled into view after layout.
+	size image.Point // Width, and maximum height, including border.
+}
+
+func (ui *dropdownList) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+	border := pt(2 * BorderSize)
+	ui.Kid.UI.Layout(dui, &ui.Kid, ui.size.Sub(border), true)
+	ui.Kid.R = ui.Kid.R.Add(pt(BorderSize))
+	self.R = rect(ui.Kid.R.Size().Add(border))
+	ui.Kid.UI.Focus(dui, &ui.Kid, ui.list)
+}
+
+func (ui *dropdownList) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	KidsDraw(dui, self, []*Kid{&ui.Kid}, self.R.Size(), nil, img, orig, m, force)
+	drawRoundedBorder(img, rect(self.R.Size()).Add(orig), dui.Regular.Normal.Border)
+}
+
+func (ui *dropdownList) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	return KidsMouse(dui, self, []*Kid{&ui.Kid}, m, origM, orig)
+}
+
+func (ui *dropdownList) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	return KidsKey(dui, self, []*Kid{&ui.Kid}, k, m, orig)
+}
+
+func (ui *dropdownList) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	return nil
+}
+
+func (ui *dropdownList) LastFocus(dui *DUI, self *Kid) *image.Point {
+	return nil
+}
+
+func (ui *dropdownList) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	return KidsFocus(dui, self, []*Kid{&ui.Kid}, o)
+}
+
+func (ui *dropdownList) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return KidsMark(self, []*Kid{&ui.Kid}, o, forLayout)
+}
+
+func (ui *dropdownList) Children() []*Kid {
+	return []*Kid{&ui.Kid}
+}
+
+func (ui *dropdownList) Print(self *Kid, indent int) {
+	PrintUI("dropdownList", self, indent)
+	ui.Kid.UI.Print(&ui.Kid, indent+1)
+}
+
+// Access returns a combobox node with the shown text as value, see Accessible.
+// While open, the list of values is in an overlay, and part of the tree returned by DUI.AccessTree.
+func (ui *Dropdown) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	n := &AccessNode{Role: RoleCombobox, Name: ui.Placeholder, State: AccessState{Disabled: ui.Disabled, Expanded: ui.overlay != nil}}
+	if ui.Editable && ui.field != nil {
+		n.Value = ui.field.Text
+	} else if i := ui.selected(); i >= 0 {
+		n.Value = ui.Values[i].Text
+	}
+	return n
+}
+
+// KeepState copies the selected value of old, for LoadUI.
+func (ui *Dropdown) KeepState(old UI) {
+	if o, ok := old.(*Dropdown); ok {
+		keepSelected(ui.Values, o.Values)
+	}
+}
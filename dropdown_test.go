@@ -0,0 +1,95 @@
+package duit_test
+
+import (
+	"testing"
+
+	"9fans.net/go/draw"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/duittest"
+)
+
+// dropdownTester returns a tester with dropdown focused, and a pointer to the text of the last value passed to Changed.
+func dropdownTester(t *testing.T, dropdown *duit.Dropdown) (*duittest.Tester, *string) {
+	for _, s := range []string{"apple", "banana", "cherry", "blueberry"} {
+		dropdown.Values = append(dropdown.Values, &duit.ListValue{Text: s})
+	}
+	changed := new(string)
+	dropdown.Changed = func(v *duit.ListValue) (e duit.Event) {
+		*changed = v.Text
+		e.NeedLayout = true
+		return
+	}
+	dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(dropdown)}, &duit.DUIOpts{Dimensions: "300x300"})
+	dt.Key('\t')
+	return dt, changed
+}
+
+func TestDropdownKeys(t *testing.T) {
+	dropdown := &duit.Dropdown{}
+	dt, changed := dropdownTester(t, dropdown)
+	defer dt.Close()
+
+	// Typing selects the first value starting with the typed text.
+	dt.Type("b")
+	if *changed != "banana" {
+		t.Fatalf("changed to %q after typing, expected banana", *changed)
+	}
+	dt.Type("l")
+	if *changed != "blueberry" || !dropdown.Values[3].Selected || dropdown.Values[1].Selected {
+		t.Fatalf("changed to %q after typing more, expected blueberry", *changed)
+	}
+
+	// Arrow down opens the list at the selected value, enter chooses from the list.
+	dt.Key(draw.KeyDown)
+	if n := len(dt.DUI.Overlays()); n != 1 {
+		t.Fatalf("%d overlays after arrow down, expected 1", n)
+	}
+	dt.Key(draw.KeyUp)
+	dt.Key('\n')
+	if n := len(dt.DUI.Overlays()); n != 0 || *changed != "cherry" {
+		t.Fatalf("%d overlays, changed to %q, expected 0 and cherry", n, *changed)
+	}
+	dt.CheckState(dropdown, duit.Clean, duit.Clean)
+
+	// Escape closes the list without choosing.
+	dt.Key('\n')
+	dt.Key(draw.KeyDown)
+	dt.Key(draw.KeyEscape)
+	if n := len(dt.DUI.Overlays()); n != 0 || *changed != "cherry" {
+		t.Fatalf("%d overlays, changed to %q after escape, expected 0 and cherry", n, *changed)
+	}
+}
+
+func TestDropdownFilter(t *testing.T) {
+	dropdown := &duit.Dropdown{Editable: true}
+	dt, changed := dropdownTester(t, dropdown)
+	defer dt.Close()
+
+	// The list shows the values containing the text, the first selected.
+	dt.Type("an")
+	if n := len(dt.DUI.Overlays()); n != 1 {
+		t.Fatalf("%d overlays after typing, expected 1", n)
+	}
+	dt.Key('\n')
+	if *changed != "banana" || !dropdown.Values[1].Selected {
+		t.Fatalf("changed to %q, expected banana", *changed)
+	}
+
+	// Matching ignores case, arrow down moves through the filtered values.
+	for range "banana" {
+		dt.Key(draw.KeyBackspace)
+	}
+	dt.Type("RR")
+	dt.Key(draw.KeyDown)
+	dt.Key('\n')
+	if *changed != "blueberry" {
+		t.Fatalf("changed to %q, expected blueberry", *changed)
+	}
+
+	// Without matching values, the list is closed.
+	dt.Type("zz")
+	if n := len(dt.DUI.Overlays()); n != 0 {
+		t.Fatalf("%d overlays without matches, expected 0", n)
+	}
+}
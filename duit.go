@@ -10,6 +10,7 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"9fans.net/go/draw"
@@ -59,7 +60,7 @@ type Event struct {
 type Result struct {
 	Hit      UI           // the UI where the event ended up
 	Consumed bool         // whether event was consumed, and should not be further handled by upper UI's
-	Warp     *image.Point // if set, mouse will warp to location
+	Warp     *image.Point // if set, keyboard focus moves to the UI at location, and mouse will warp to location unless disabled with DUIOpts.NoWarp
 }
 
 // Colors represents the style in one state of the UI.
@@ -141,6 +142,24 @@ type DUI struct {
 	// Gutter color.
 	Gutter *draw.Image
 
+	// Focus ring color, drawn around the UI with keyboard focus after focus was changed with the keyboard or DUI.Focus.
+	FocusRing *draw.Image
+
+	// Key that moves keyboard focus backwards, like tab moves it forward. Devdraw does not deliver shift-tab, so the default is cmd-tab.
+	BackTab rune
+
+	// Time the mouse has to hover over a UI before its tooltip is shown, see Kid.Tooltip and SetTooltip.
+	TooltipDelay time.Duration
+
+	// Time between animation frames requested with Frame.
+	FrameInterval time.Duration
+
+	// Font registry, resolving font styles like "mono, larger" to fonts, see FontStyle.
+	Fonts Fonts
+
+	// Keys for commands by command name, overriding the default keys of commands, see Command. Read from $APPDATA/duit/<name>/keys.json, see NewDUI.
+	KeyBindings map[string][]rune
+
 	Debug       bool          // Log errors interesting to developers.
 	DebugDraw   int           // If 1, UIs print each draw they do. If 2, UIs print all calls to their Draw function. Cycle through 0-2 with F7.
 	DebugLayout int           // If 1, UIs print each Layout they do. If 2, UIs print all calls to their Layout function. Cycle through 0-2 with F8.
@@ -159,6 +178,25 @@ type DUI struct {
 	mouse                   draw.Mouse             // Latest mouse event.
 	origMouse               draw.Mouse             // Mouse that determines where new mouse events are delivered. Unchanged while button is pressed.
 	lastMouseUI             UI                     // Where last mouse was delivered
+	overlays                []*Overlay             // Shown on top of Top, the last one is topmost.
+	ignoreMouse             bool                   // Set when a click dismissed overlays, until the buttons are released.
+	uncovered               bool                   // Set when an overlay was removed or moved, the UI tree must be drawn entirely.
+	tooltipNext             UI                     // Tooltip requested while delivering the current mouse event.
+	tooltipUI               UI                     // Tooltip scheduled or shown.
+	tooltipTimer            *Timer                 // For showing tooltipUI after a delay.
+	tooltip                 *Overlay               // Tooltip currently shown, drawn above the other overlays.
+	timers                  map[*Timer]struct{}    // Timers that have not been stopped.
+	frames                  map[UI]func(time.Time) // Pending frame requests.
+	frameTimer              *Timer                 // Fires for the next frame, if frames were requested.
+	viewport                *Scroll                // Scroll whose child is being drawn, nil when drawing on the screen. For UIs to find out whether they are in view.
+	paused                  map[UI]func()          // Called after the next draw, for UIs that paused because they were out of view.
+	focus                   UI                     // UI with keyboard focus, receiving key events. If nil, keys are delivered to the UI under the mouse.
+	focusDelta              image.Point            // Offset of key location from focus-point of focus, e.g. for the button within a Buttongroup that tab moved to.
+	focusLayer              *Kid                   // Layer, DUI.Top or an overlay, with focus.
+	focusAt                 image.Point            // Location in screen coordinates where keys are delivered for focus. Updated after drawing, see updateFocus.
+	focusNoScroll           bool                   // Set while updating focusAt, containers must not scroll to bring focus into view.
+	focusVisible            bool                   // Whether to draw a focus ring. Not after a mouse click, only after focus changes by keyboard or DUI.Focus.
+	noWarp                  bool                   // Never warp the mouse, see DUIOpts.NoWarp.
 	logInputs               bool                   // Print all input events. Toggled with F1.
 	logTiming               bool                   // Print timings for layout and draw.
 	drawDebug               bool                   // For draw.Display.SetDebug.
@@ -167,6 +205,18 @@ type DUI struct {
 	name                    string                 // Program name, also used for storing dimensions file.
 	settings                map[string][]byte      // Indexed by Kid.ID, holds JSON. Helps store per-UI state, such as Split sizes.
 	settingsWriters         map[string]*time.Timer // Delayed writes of settings.
+	access                  *accessServer          // Publishes the accessibility tree, see ServeAccessibility.
+	bindings                map[UI]*binding        // Bindings of UIs to observable values, see StringValue.
+	commands                []*Command             // Registered commands, see AddCommand.
+	shortcuts               *Overlay               // Shortcuts help, if shown.
+	palette                 *Overlay               // Command palette, if shown.
+	backend                 Backend                // Display backend, for resizing when replaying a recording.
+	recorder                *recorder              // Recording in progress, see StartRecording.
+	backdrop                *draw.Image            // Translucent, drawn below dialogs, see ShowDialog.
+	themeImages             []*draw.Image          // Color images allocated by SetTheme, freed when another theme is set.
+	done                    chan struct{}          // Closed when the DUI is closed, for goroutines sending to Call, see call.
+	doneOnce                sync.Once              // For closing done, by Close or when the window disappeared.
+	closed                  bool                   // Set by Close.
 }
 
 // DUIOpts exist mostly to make it easier to add changes in the future, and keep the NewDUI function signature sane.
@@ -174,8 +224,11 @@ type DUI struct {
 // DUIOpts are options for creating a new DUI.
 // Zero values have sane behaviour.
 type DUIOpts struct {
-	FontName   string // eg "/mnt/font/Lato-Regular/15a/font"
-	Dimensions string // eg "800x600", duit has a sane default and remembers size per application name after resize.
+	FontName   string  // eg "/mnt/font/Lato-Regular/15a/font"
+	Dimensions string  // eg "800x600", duit has a sane default and remembers size per application name after resize.
+	Backend    Backend // Display to connect to. If nil, devdraw is started. With another backend, dimensions are not remembered.
+	NoWarp     bool    // If set, the mouse pointer is never warped, e.g. on tab or DUI.Focus. Keyboard focus still moves.
+	Theme      *Theme  // Colors for the application, LightTheme if nil. Users can override it, see NewDUI.
 }
 
 // AppdataDir returns the directory where the application can store its files, like configuration.
@@ -198,6 +251,8 @@ func configDir() string {
 
 // NewDUI creates a DUI for an application called name, and optional opts. A DUI is a new window and its UI state.
 // Window dimensions and UI settings are automatically written to $APPDATA/duit/<name>, with $APPDATA being $HOME/lib on unix.
+// If $APPDATA/duit/<name>/theme.json exists, it is read with ReadTheme and used instead of opts.Theme, e.g. {"Base": "dark"} for the dark theme.
+// If $APPDATA/duit/<name>/keys.json exists, it is read with ReadKeyBindings into DUI.KeyBindings, for configuring the keys of commands, e.g. {"duit.close": ["cmd-q"]}.
 func NewDUI(name string, opts *DUIOpts) (dui *DUI, err error) {
 	lcheck, handle := errorHandler(func(xerr error) {
 		err = xerr
@@ -211,8 +266,13 @@ func NewDUI(name string, opts *DUIOpts) (dui *DUI, err error) {
 		opts.Dimensions = "800x600"
 	}
 
+	backend := opts.Backend
+	if backend == nil {
+		backend = devdrawBackend{}
+	}
+
 	var dimensionsPath string
-	if name != "" {
+	if name != "" && opts.Backend == nil {
 		dimensionsPath = fmt.Sprintf("%s/%s/dimensions", configDir(), name)
 		buf, err := ioutil.ReadFile(dimensionsPath)
 		if err != nil {
@@ -224,7 +284,7 @@ func NewDUI(name string, opts *DUIOpts) (dui *DUI, err error) {
 	}
 
 	errch := make(chan error, 1)
-	display, err := draw.Init(errch, opts.FontName, name, opts.Dimensions)
+	display, mousectl, keyctl, err := backend.Init(errch, opts.FontName, name, opts.Dimensions)
 	if err != nil {
 		return nil, err
 	}
@@ -236,119 +296,23 @@ func NewDUI(name string, opts *DUIOpts) (dui *DUI, err error) {
 	}
 
 	dui = &DUI{
-		mousectl: display.InitMouse(),
-		keyctl:   display.InitKeyboard(),
+		mousectl: mousectl,
+		keyctl:   keyctl,
+		backend:  backend,
 		stop:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
 		Inputs:   make(chan Input, 1),
 		Call:     make(chan func(), 1),
 		Error:    make(chan error, 1),
 
 		Display: display,
 
-		Disabled: Colors{
-			Text:       makeColor(0x888888ff),
-			Background: makeColor(0xf0f0f0ff),
-			Border:     makeColor(0xe0e0e0ff),
-		},
-		Inverse: Colors{
-			Text:       makeColor(0xeeeeeeff),
-			Background: makeColor(0x3272dcff),
-			Border:     makeColor(0x666666ff),
-		},
-		Selection: Colors{
-			Text:       makeColor(0xeeeeeeff),
-			Background: makeColor(0xbbbbbbff),
-			Border:     makeColor(0x666666ff),
-		},
-		SelectionHover: Colors{
-			Text:       makeColor(0xeeeeeeff),
-			Background: makeColor(0x3272dcff),
-			Border:     makeColor(0x666666ff),
-		},
-		Placeholder: Colors{
-			Text:       makeColor(0xaaaaaaff),
-			Background: makeColor(0xf8f8f8ff),
-			Border:     makeColor(0xbbbbbbff),
-		},
-		Striped: Colors{
-			Text:       makeColor(0x333333ff),
-			Background: makeColor(0xf2f2f2ff),
-			Border:     makeColor(0xbbbbbbff),
-		},
-
-		Regular: Colorset{
-			Normal: Colors{
-				Text:       makeColor(0x333333ff),
-				Background: makeColor(0xf8f8f8ff),
-				Border:     makeColor(0xbbbbbbff),
-			},
-			Hover: Colors{
-				Text:       makeColor(0x222222ff),
-				Background: makeColor(0xfafafaff),
-				Border:     makeColor(0x3272dcff),
-			},
-		},
-		Primary: Colorset{
-			Normal: Colors{
-				Text:       makeColor(0xffffffff),
-				Background: makeColor(0x007bffff),
-				Border:     makeColor(0x007bffff),
-			},
-			Hover: Colors{
-				Text:       makeColor(0xffffffff),
-				Background: makeColor(0x0062ccff),
-				Border:     makeColor(0x0062ccff),
-			},
-		},
-		Secondary: Colorset{
-			Normal: Colors{
-				Text:       makeColor(0xffffffff),
-				Background: makeColor(0x868e96ff),
-				Border:     makeColor(0x868e96ff),
-			},
-			Hover: Colors{
-				Text:       makeColor(0xffffffff),
-				Background: makeColor(0x727b84ff),
-				Border:     makeColor(0x6c757dff),
-			},
-		},
-		Success: Colorset{
-			Normal: Colors{
-				Text:       makeColor(0xffffffff),
-				Background: makeColor(0x28a745ff),
-				Border:     makeColor(0x28a745ff),
-			},
-			Hover: Colors{
-				Text:       makeColor(0xffffffff),
-				Background: makeColor(0x218838ff),
-				Border:     makeColor(0x1e7e34ff),
-			},
-		},
-		Danger: Colorset{
-			Normal: Colors{
-				Text:       makeColor(0xffffffff),
-				Background: makeColor(0xdc3545ff),
-				Border:     makeColor(0xdc3545ff),
-			},
-			Hover: Colors{
-				Text:       makeColor(0xffffffff),
-				Background: makeColor(0xc82333ff),
-				Border:     makeColor(0xbd2130ff),
-			},
-		},
-
-		BackgroundColor: draw.Color(0xfcfcfcff),
-		Background:      makeColor(0xfcfcfcff),
-
-		ScrollBGNormal:      makeColor(0xf4f4f4ff),
-		ScrollBGHover:       makeColor(0xf0f0f0ff),
-		ScrollVisibleNormal: makeColor(0xbbbbbbff),
-		ScrollVisibleHover:  makeColor(0x999999ff),
-
-		Gutter: makeColor(0xbbbbbbff),
+		BackTab: draw.KeyCmd + '\t',
 
-		CommandMode: makeColor(0x3272dcff),
-		VisualMode:  makeColor(0x5cb85cff),
+		TooltipDelay:  500 * time.Millisecond,
+		FrameInterval: time.Second / 60,
+		Fonts:         Fonts{Names: defaultFontNames()},
+		timers:        map[*Timer]struct{}{},
 
 		debugColors: []*draw.Image{
 			makeColor(0x40000040),
@@ -360,10 +324,50 @@ func NewDUI(name string, opts *DUIOpts) (dui *DUI, err error) {
 		name:            name,
 		settings:        map[string][]byte{},
 		settingsWriters: map[string]*time.Timer{},
+		noWarp:          opts.NoWarp,
 
 		Debug: true,
 	}
 
+	theme := opts.Theme
+	if theme == nil {
+		theme = LightTheme
+	}
+	if name != "" && opts.Backend == nil {
+		themePath := fmt.Sprintf("%s/%s/theme.json", configDir(), name)
+		if _, err := os.Stat(themePath); err == nil {
+			t, err := ReadThemePath(themePath)
+			if err != nil {
+				log.Printf("duit: %s, using default theme\n", err)
+			} else {
+				theme = t
+			}
+		}
+	}
+	lcheck(dui.SetTheme(theme), "set theme")
+
+	dui.addDefaultCommands()
+	if name != "" && opts.Backend == nil {
+		keysPath := fmt.Sprintf("%s/%s/keys.json", configDir(), name)
+		if _, err := os.Stat(keysPath); err == nil {
+			bindings, err := ReadKeyBindingsPath(keysPath)
+			if err != nil {
+				log.Printf("duit: %s, using default keys\n", err)
+			} else {
+				dui.KeyBindings = bindings
+			}
+		}
+	}
+
+	if os.Getenv("DUIT_ACCESSIBILITY") != "" {
+		lcheck(dui.ServeAccessibility(""), "serve accessibility")
+	}
+	if os.Getenv("DUIT_RECORD") != "" {
+		path, err := dui.StartRecordingFile("")
+		lcheck(err, "start recording")
+		log.Printf("duit: recording to %s\n", path)
+	}
+
 	// mousectl sends initial mouse position
 	dui.mouse = <-dui.mousectl.C
 
@@ -384,6 +388,7 @@ func NewDUI(name string, opts *DUIOpts) (dui *DUI, err error) {
 				if e == io.EOF {
 					// devdraw disappeared, typically because window was closed (either by user, or by duit)
 					close(dui.Error)
+					dui.closeDone()
 					return
 				}
 				dui.Inputs <- Input{Type: InputError, Error: e}
@@ -396,44 +401,67 @@ func NewDUI(name string, opts *DUIOpts) (dui *DUI, err error) {
 
 // Render calls Layout followed by Draw.
 // This only does a layout/draw for UIs marked as needing it. If you want to force a layout/draw, mark the top UI as requiring a layout/draw.
+// After Close, e.g. by a callback, nothing is rendered.
 func (d *DUI) Render() {
+	if d.closed {
+		return
+	}
 	d.Layout()
 	d.Draw()
 }
 
-// Layout the entire UI tree, as necessary.
+// Layout the entire UI tree and the overlays, as necessary.
 // Only UIs marked as requiring a layout are actually layed out.
 // UIs that receive a layout are marked as requiring a draw.
 func (d *DUI) Layout() {
-	if d.Top.Layout == Clean {
+	if d.Top.Layout == Clean && !d.overlayDirty(true) {
 		return
 	}
 	var t0 time.Time
 	if d.logTiming {
 		t0 = time.Now()
 	}
-	d.Top.UI.Layout(d, &d.Top, d.Display.ScreenImage.R.Size(), d.Top.Layout == Dirty)
-	d.Top.Layout = Clean
+	if d.Top.Layout != Clean {
+		d.Top.UI.Layout(d, &d.Top, d.Display.ScreenImage.R.Size(), d.Top.Layout == Dirty)
+		d.Top.Layout = Clean
+	}
+	d.layoutOverlays()
 	if d.logTiming {
 		log.Printf("duit: time layout: %d µs\n", time.Now().Sub(t0)/time.Microsecond)
 	}
 }
 
-// Draw the entire UI tree, as necessary.
+// Draw the entire UI tree and the overlays, as necessary.
 // Only UIs marked as requiring a draw are actually drawn, and their children.
+// Overlays are drawn after the UI tree, so they stay on top.
 func (d *DUI) Draw() {
-	if d.Top.Draw == Clean {
+	if d.uncovered {
+		// Set here, Top.Draw could have been changed to DirtyKid while handling an event.
+		d.Top.Draw = Dirty
+		d.uncovered = false
+	}
+	if d.modalDirty() {
+		d.Top.Draw = Dirty
+	}
+	if d.Top.Draw == Clean && !d.overlayDirty(false) {
 		return
 	}
 	var t0, t1 time.Time
 	if d.logTiming {
 		t0 = time.Now()
 	}
+	topDrawn := d.Top.Draw != Clean
 	if d.Top.Draw == Dirty {
 		d.Display.ScreenImage.Draw(d.Display.ScreenImage.R, d.Background, nil, image.ZP)
 	}
-	d.Top.UI.Draw(d, &d.Top, d.Display.ScreenImage, image.ZP, d.mouse, d.Top.Draw == Dirty)
-	d.Top.Draw = Clean
+	if topDrawn {
+		d.Top.UI.Draw(d, &d.Top, d.Display.ScreenImage, image.ZP, d.mouse, d.Top.Draw == Dirty)
+		d.drawFocus(d.Top.UI, d.Display.ScreenImage, d.Top.R)
+		d.Top.Draw = Clean
+	}
+	d.drawOverlays(topDrawn)
+	// UIs may have moved, by a layout or a scroll.
+	d.updateFocus()
 	if d.logTiming {
 		t1 = time.Now()
 	}
@@ -442,16 +470,18 @@ func (d *DUI) Draw() {
 		t2 := time.Now()
 		log.Printf("duit: time draw: draw %d µs flush %d µs\n", t1.Sub(t0)/time.Microsecond, t2.Sub(t1)/time.Microsecond)
 	}
+	d.resume()
+	d.accessChanged()
 }
 
-// MarkLayout marks ui as requiring a layout.
+// MarkLayout marks ui, in the UI tree or an overlay, as requiring a layout.
 // If you have access to the Kid that holds this UI, it is more efficient to change the Kid itself. MarkLayout is more convenient. Using it can cut down on bookkeeping.
 // If ui is nil, the top UI is marked.
 func (d *DUI) MarkLayout(ui UI) {
 	if ui == nil {
 		d.Top.Layout = Dirty
 	} else {
-		if !d.Top.UI.Mark(&d.Top, ui, true) {
+		if !d.mark(ui, true) {
 			log.Printf("duit: marklayout %T: nothing marked\n", ui)
 		}
 	}
@@ -462,7 +492,7 @@ func (d *DUI) MarkDraw(ui UI) {
 	if ui == nil {
 		d.Top.Draw = Dirty
 	} else {
-		if !d.Top.UI.Mark(&d.Top, ui, false) {
+		if !d.mark(ui, false) {
 			log.Printf("duit: markdraw %T: nothing marked\n", ui)
 		}
 	}
@@ -470,22 +500,35 @@ func (d *DUI) MarkDraw(ui UI) {
 
 func (d *DUI) apply(r Result) {
 	if r.Warp != nil {
-		err := d.Display.MoveTo(*r.Warp)
-		if err != nil {
-			log.Printf("duit: warp to %v: %s\n", r.Warp, err)
-		} else {
-			d.mouse.Point = *r.Warp
-			d.mouse.Buttons = 0
-			d.origMouse = d.mouse
-			r = d.Top.UI.Mouse(d, &d.Top, d.mouse, d.origMouse, image.ZP)
+		p := *r.Warp
+		warped := false
+		if !d.noWarp {
+			err := d.Display.MoveTo(p)
+			if err != nil {
+				log.Printf("duit: warp to %v: %s\n", p, err)
+			} else {
+				d.mouse.Point = p
+				d.mouse.Buttons = 0
+				d.origMouse = d.mouse
+				r = d.deliverMouse(d.mouse, d.origMouse)
+				warped = true
+			}
 		}
+		focus := d.focus
+		if warped {
+			focus = r.Hit
+		} else if d.mouse.Buttons == 0 {
+			focus = d.hit(p)
+		}
+		d.setFocus(focus, &p, true)
 	}
 	if r.Hit != d.lastMouseUI {
+		// Either UI may have been removed while handling the event, e.g. a menu that closed.
 		if r.Hit != nil {
-			d.MarkDraw(r.Hit)
+			d.mark(r.Hit, false)
 		}
 		if d.lastMouseUI != nil {
-			d.MarkDraw(d.lastMouseUI)
+			d.mark(d.lastMouseUI, false)
 		}
 	}
 	d.lastMouseUI = r.Hit
@@ -493,14 +536,129 @@ func (d *DUI) apply(r Result) {
 	d.Render()
 }
 
-// Mouse delivers a mouse event to the UI tree.
+// hit returns the UI at p, in screen coordinates.
+// It delivers a mouse event without buttons at p, followed by the current mouse to restore hover states.
+// Must only be called when no mouse button is down.
+func (d *DUI) hit(p image.Point) UI {
+	m := draw.Mouse{Point: p}
+	r := d.deliverMouse(m, m)
+	d.deliverMouse(d.mouse, d.origMouse)
+	return r.Hit
+}
+
+// Focused returns the UI with keyboard focus, or nil.
+// Key events are delivered to the focused UI, regardless of the location of the mouse pointer.
+// Focus changes with a mouse click, with tab, and with Focus. Without focused UI, keys are delivered to the UI under the mouse.
+func (d *DUI) Focused() UI {
+	return d.focus
+}
+
+// setFocus gives ui keyboard focus, with keys delivered at p (in screen coordinates) from then on.
+// If p is nil, keys are delivered at the focus-point of ui.
+// If ui is not in the UI tree, no UI has focus.
+// Visible indicates whether a focus ring is drawn.
+func (d *DUI) setFocus(ui UI, p *image.Point, visible bool) {
+	if ui != d.focus || visible != d.focusVisible {
+		d.markFocus()
+		d.focus = ui
+		d.focusVisible = visible
+		d.markFocus()
+	}
+	d.focusDelta = image.ZP
+	if ui == nil {
+		return
+	}
+	layer, fp := d.findFocus(ui)
+	if fp == nil {
+		d.focus = nil
+		return
+	}
+	if p != nil {
+		d.focusDelta = p.Sub(*fp)
+	}
+	d.focusLayer = layer
+	d.focusAt = fp.Add(d.focusDelta)
+}
+
+// updateFocus finds the location of the UI with focus again, after it may have moved, e.g. by a layout or scroll.
+// Unlike setFocus, containers do not bring the UI into view: a Scroll the user scrolled away from the UI stays where it is.
+// If the UI is no longer in the UI tree, no UI has focus.
+func (d *DUI) updateFocus() {
+	if d.focus == nil {
+		return
+	}
+	d.focusNoScroll = true
+	layer, p := d.findFocus(d.focus)
+	d.focusNoScroll = false
+	if p == nil {
+		d.focus = nil
+		return
+	}
+	d.focusLayer = layer
+	d.focusAt = p.Add(d.focusDelta)
+}
+
+// markFocus marks the UI with focus as needing a draw, for adding or removing the focus ring.
+func (d *DUI) markFocus() {
+	if d.focus != nil && d.focusVisible {
+		d.mark(d.focus, false)
+	}
+}
+
+// focusPoint returns the layer (DUI.Top or an overlay) with the UI with focus, and the location in screen coordinates where keys are delivered for that UI, as found when focus was set or after the last draw.
+// If no UI has focus, nil is returned.
+func (d *DUI) focusPoint() (*Kid, *image.Point) {
+	if d.focus == nil {
+		return nil, nil
+	}
+	p := d.focusAt
+	return d.focusLayer, &p
+}
+
+// drawFocus draws a focus ring on the inside of r, if ui has keyboard focus that should be shown.
+// Called by UIs after drawing a kid.
+func (d *DUI) drawFocus(ui UI, img *draw.Image, r image.Rectangle) {
+	if d.focus == nil || ui != d.focus || !d.focusVisible || d.FocusRing == nil || r.Dx() < 4 || r.Dy() < 4 {
+		return
+	}
+	drawRoundedBorder(img, r, d.FocusRing)
+	drawRoundedBorder(img, r.Inset(1), d.FocusRing)
+}
+
+// Mouse delivers a mouse event to the overlay under the mouse, or the UI tree.
 // Mouse is typically called by Input.
+// A button click gives keyboard focus to the UI clicked on, unless that UI moved focus itself, e.g. to a Menu it opened.
+// A click outside an overlay dismisses it. That click is not delivered.
 func (d *DUI) Mouse(m draw.Mouse) {
+	pressed := d.mouse.Buttons == 0 && m.Buttons&(Button1|Button2|Button3) != 0
 	if m.Buttons == 0 || d.origMouse.Buttons == 0 {
 		d.origMouse = m
 	}
 	d.mouse = m
-	r := d.Top.UI.Mouse(d, &d.Top, m, d.origMouse, image.ZP)
+	if pressed && d.dismissBelow(m.Point) {
+		d.ignoreMouse = true
+	}
+	if d.ignoreMouse {
+		if m.Buttons != 0 {
+			d.Render()
+			return
+		}
+		d.ignoreMouse = false
+	}
+	focus, n := d.focus, len(d.overlays)
+	r := d.deliverMouse(m, d.origMouse)
+	d.updateTooltip()
+	// If the overlay clicked in was removed, e.g. a list after choosing a value, focus was already restored while removing it.
+	if pressed && n <= len(d.overlays) {
+		if d.focus == focus {
+			d.setFocus(r.Hit, nil, false)
+		} else if n < len(d.overlays) {
+			// The UI gave focus to an overlay it opened, e.g. a Menu. When the overlay is removed, focus returns to the UI clicked on.
+			for _, o := range d.overlays[n:] {
+				o.focus = r.Hit
+			}
+		}
+	}
 	d.apply(r)
 }
 
@@ -513,6 +671,9 @@ func (d *DUI) Resize() {
 
 	d.Top.Layout = Dirty
 	d.Top.Draw = Dirty
+	for _, o := range d.overlays {
+		o.Layout = Dirty
+	}
 	d.Render()
 	if d.dimensionsPath != "" {
 		if d.dimensionsDelayedWriter != nil {
@@ -530,87 +691,91 @@ func (d *DUI) Resize() {
 	}
 }
 
-// Key delivers a key press event to the UI tree.
+// Key delivers a key press event to the UI tree or an overlay.
+// The key goes to the UI with keyboard focus, or if there is none, to the UI under the mouse.
+// An escape key that is not consumed dismisses the topmost overlay.
 // Key is typically called by Input.
 func (d *DUI) Key(k rune) {
-	switch k {
-	case draw.KeyFn + 1:
-		d.logInputs = !d.logInputs
-		log.Println("duit: logInputs now", d.logInputs)
+	k, ok := d.commandKey(k)
+	if d.closed {
 		return
-	case draw.KeyFn + 2:
-		d.logTiming = !d.logTiming
-		log.Println("duit: logTiming now", d.logTiming)
-		return
-	case draw.KeyFn + 3:
-		d.Top.UI.Print(&d.Top, 0)
-		return
-	case draw.KeyFn + 4:
-		d.drawDebug = !d.drawDebug
-		d.Display.SetDebug(d.drawDebug)
-		log.Println("duit: drawDebug now", d.drawDebug)
-		return
-	case draw.KeyFn + 5:
-		d.DebugKids = !d.DebugKids
-		log.Println("duit: debugKids now", d.DebugKids)
-		return
-	case draw.KeyFn + 6:
-		log.Println("duit: rendering entire ui")
-		d.Top.Layout = Dirty
-		d.Top.Draw = Dirty
+	}
+	if !ok {
+		// A command may have changed the UI, e.g. shown an overlay.
 		d.Render()
 		return
-	case draw.KeyFn + 7:
-		d.DebugDraw = (d.DebugDraw + 1) % 3
-		log.Println("duit: DebugDraw now", d.DebugDraw)
-		return
-	case draw.KeyFn + 8:
-		d.DebugLayout = (d.DebugLayout + 1) % 3
-		log.Println("duit: DebugLayout now", d.DebugLayout)
-		return
-	case draw.KeyFn + 9:
-		err := json.NewEncoder(os.Stderr).Encode(&d.Top)
-		if err != nil {
-			log.Printf("encoding d.Top: %s\n", err)
-		}
-		return
 	}
-	r := d.Top.UI.Key(d, &d.Top, k, d.mouse, image.ZP)
+	d.hideTooltip()
+	m := d.mouse
+	layer, p := d.focusPoint()
+	if p != nil {
+		m.Point = *p
+	} else {
+		layer = d.layer(m.Point)
+	}
+	orig := d.layerOrig(layer)
+	m.Point = m.Point.Sub(orig)
+	r := layer.UI.Key(d, layer, k, m, orig)
 	if !r.Consumed {
 		switch k {
 		case '\t':
-			first := d.Top.UI.FirstFocus(d, &d.Top)
+			// Wrap around from the last UI to the first.
+			first := layer.UI.FirstFocus(d, layer)
 			if first != nil {
-				r.Warp = first
+				p := first.Add(orig)
+				r.Warp = &p
 				r.Consumed = true
 			}
-		case draw.KeyCmd + 'w':
-			close(d.Error)
-			d.Close()
-			return
+		case d.BackTab:
+			last := layer.UI.LastFocus(d, layer)
+			if last != nil {
+				p := last.Add(orig)
+				r.Warp = &p
+				r.Consumed = true
+			}
+		case draw.KeyEscape:
+			if len(d.overlays) > 0 {
+				d.removeOverlays(len(d.overlays)-1, true)
+				r.Consumed = true
+			}
+		default:
+			ui := d.keyUI()
+			if c := d.keyCommand(k, fmt.Sprintf("%T", ui), true); c != nil && c.Run != nil {
+				c.Run(d, ui)
+				r.Consumed = true
+				if d.closed {
+					return
+				}
+			}
 		}
 	}
 	d.apply(r)
 }
 
-// Focus renders the UI, then changes focus to ui by warping the mouse pointer to it.
+// Focus renders the UI, then gives ui keyboard focus and warps the mouse pointer to it, unless disabled with DUIOpts.NoWarp.
 // Container UIs ensure the UI is in place, e.g. scrolling if necessary.
 func (d *DUI) Focus(ui UI) {
 	d.Render()
-	p := d.Top.UI.Focus(d, &d.Top, ui)
+	_, p := d.findFocus(ui)
 	if p == nil {
 		log.Printf("duit: focus: no ui found for %T %p\n", ui, ui)
 		return
 	}
+	d.setFocus(ui, p, true)
+	if d.noWarp {
+		d.Render()
+		return
+	}
 	err := d.Display.MoveTo(*p)
 	if err != nil {
 		log.Printf("duit: move mouse to %v: %v\n", *p, err)
+		d.Render()
 		return
 	}
 	d.mouse.Point = *p
 	d.mouse.Buttons = 0
 	d.origMouse = d.mouse
-	r := d.Top.UI.Mouse(d, &d.Top, d.mouse, d.origMouse, image.ZP)
+	r := d.deliverMouse(d.mouse, d.origMouse)
 	d.apply(r)
 }
 
@@ -669,24 +834,27 @@ func (d *DUI) Scale(n int) int {
 // Mouse and key events are delivered the right UIs.
 // Resize is handled by reattaching to devdraw and doing a layout and draw.
 // Func calls the function.
-// Error implies an error from devdraw and terminates the program.
+// Error implies an error from devdraw and terminates the program. Run passes such errors to RunOpts.Error instead of calling Input.
 func (d *DUI) Input(e Input) {
 	switch e.Type {
 	case InputMouse:
 		if d.logInputs {
 			log.Printf("duit: mouse %v, %b\n", e.Mouse, e.Mouse.Buttons)
 		}
+		d.record(e)
 		d.Mouse(e.Mouse)
 	case InputKey:
 		if d.logInputs {
 			log.Printf("duit: key %c, %x\n", e.Key, e.Key)
 		}
+		d.record(e)
 		d.Key(e.Key)
 	case InputResize:
 		if d.logInputs {
 			log.Printf("duit: resize")
 		}
 		d.Resize()
+		d.record(e)
 	case InputFunc:
 		if d.logInputs {
 			log.Printf("duit: func")
@@ -704,10 +872,36 @@ func (d *DUI) Input(e Input) {
 // Close stops mouse/keyboard event reading and closes the window.
 // After closing a DUI you should no longer call functions on it.
 func (d *DUI) Close() {
+	d.hideTooltip()
+	d.closed = true
+	d.stopAccess()
+	if err := d.StopRecording(); err != nil {
+		log.Printf("duit: recording: %s\n", err)
+	}
+	d.stopTimers()
+	d.closeDone()
 	d.stop <- struct{}{}
 	d.Display.Close()
 }
 
+// call sends fn to the main loop through Call, for goroutines like timers and dialog waiters.
+// It returns false if the DUI was closed before fn could be sent, instead of blocking forever.
+func (d *DUI) call(fn func()) bool {
+	select {
+	case d.Call <- fn:
+		return true
+	case <-d.done:
+		return false
+	}
+}
+
+// closeDone closes done, once.
+func (d *DUI) closeDone() {
+	d.doneOnce.Do(func() {
+		close(d.done)
+	})
+}
+
 // ScaleSpace is like Scale, but for a Space.
 func (d *DUI) ScaleSpace(s Space) Space {
 	return Space{
@@ -719,11 +913,18 @@ func (d *DUI) ScaleSpace(s Space) Space {
 }
 
 // Font is a helper function for UI implementations. It returns the passed font. Unless font is nil, then it returns the default font.
+// When the fonts are zoomed, the default font and fonts returned by FontStyle are replaced by their zoomed versions.
 func (d *DUI) Font(font *draw.Font) *draw.Font {
-	if font != nil {
-		return font
+	if font == nil {
+		if d.Fonts.Zoom == 0 {
+			return d.Display.DefaultFont
+		}
+		return d.FontStyle(FontStyle{})
+	}
+	if style, ok := d.Fonts.styles[font]; ok {
+		return d.FontStyle(style)
 	}
-	return d.Display.DefaultFont
+	return font
 }
 
 // WriteSnarf writes the snarf buffer and logs an error in case of failure.
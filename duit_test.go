@@ -0,0 +1,69 @@
+package duit_test
+
+import (
+	"image"
+	"os"
+	"testing"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/devdraw"
+	"github.com/mjl-/duit/duittest"
+)
+
+func TestMain(m *testing.M) {
+	devdraw.Main()
+	os.Exit(m.Run())
+}
+
+func TestFocusKeys(t *testing.T) {
+	first := &duit.Field{}
+	second := &duit.Field{}
+	dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(first, second)}, &duit.DUIOpts{Dimensions: "200x100"})
+	defer dt.Close()
+
+	// Keys go to the field with focus, not to the UI under the mouse.
+	dt.Click(dt.Center(first))
+	dt.Move(dt.Center(second))
+	dt.Type("a")
+	dt.Key('\t')
+	dt.Type("b")
+	if first.Text != "a" || second.Text != "b" {
+		t.Fatalf("fields %q and %q, expected %q and %q", first.Text, second.Text, "a", "b")
+	}
+}
+
+func TestFocusScrolledAway(t *testing.T) {
+	field := &duit.Field{}
+	var kids []*duit.Kid
+	kids = append(kids, duit.NewKids(field)...)
+	for i := 0; i < 40; i++ {
+		kids = append(kids, duit.NewKids(&duit.Label{Text: "line"})...)
+	}
+	scroll := duit.NewScroll(&duit.Grid{Kids: kids, Columns: 1})
+	dt := duittest.New(t, scroll, &duit.DUIOpts{Dimensions: "200x100"})
+	defer dt.Close()
+
+	dt.Click(image.Pt(100, 5))
+	dt.Type("a")
+	if field.Text != "a" {
+		t.Fatalf("field text %q, expected %q", field.Text, "a")
+	}
+
+	// Scrolling the field out of view, a key must not scroll it back, and not go into the field.
+	dt.Wheel(image.Pt(100, 50), 10)
+	scrolled := dt.Screenshot()
+	dt.Type("b")
+	if _, n := duittest.Diff(scrolled, dt.Screenshot()); n != 0 {
+		t.Fatalf("key scrolled the scroll back")
+	}
+	if field.Text != "a" {
+		t.Fatalf("field text %q for key while scrolled away, expected %q", field.Text, "a")
+	}
+
+	// Back in view, keys go to the field again.
+	dt.Wheel(image.Pt(100, 50), -20)
+	dt.Type("c")
+	if field.Text != "ac" {
+		t.Fatalf("field text %q after scrolling back, expected %q", field.Text, "ac")
+	}
+}
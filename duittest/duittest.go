@@ -0,0 +1,335 @@
+// Package duittest helps test duit UIs.
+//
+// A Tester runs a DUI with a UI tree on a headless backend, drawing into memory instead of a window.
+// Tests send input, like clicks and typed text, and check the results: the layout of Kids, their layout/draw state, and the pixels on the screen, compared against golden PNG files.
+//
+// Typical use:
+//
+//	func TestButton(t *testing.T) {
+//		clicked := false
+//		button := &duit.Button{Text: "ok", Click: func() (e duit.Event) { clicked = true; return }}
+//		dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(button)}, &duit.DUIOpts{Dimensions: "200x100"})
+//		defer dt.Close()
+//		dt.Click(dt.Center(button))
+//		if !clicked {
+//			t.Fatalf("button not clicked")
+//		}
+//		dt.Golden("button-clicked")
+//	}
+//
+// The headless backend starts the test binary again as devdraw relay. The test binary must call devdraw.Main from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		devdraw.Main()
+//		os.Exit(m.Run())
+//	}
+package duittest
+
+import (
+	"image"
+	"testing"
+
+	"9fans.net/go/draw"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/devdraw"
+)
+
+// Tester is a DUI on a headless backend, with functions to send input and check the results.
+// Failed checks are reported through T.
+type Tester struct {
+	T        testing.TB
+	DUI      *duit.DUI
+	Headless *devdraw.Headless
+
+	// If set, AfterInput is called after each input has been handled, e.g. to check invariants on layout/draw states.
+	AfterInput func()
+
+	mouse draw.Mouse // Last mouse sent.
+}
+
+// New creates a new DUI with ui as top UI, on a headless backend, and renders it.
+// Opts are passed to duit.NewDUI and may be nil. Its Backend is replaced by a new headless backend.
+func New(t testing.TB, ui duit.UI, opts *duit.DUIOpts) *Tester {
+	t.Helper()
+
+	o := duit.DUIOpts{}
+	if opts != nil {
+		o = *opts
+	}
+	h := devdraw.NewHeadless()
+	o.Backend = h
+	dui, err := duit.NewDUI("duittest", &o)
+	if err != nil {
+		t.Fatalf("new dui: %s", err)
+	}
+	dui.Top.UI = ui
+	dui.Render()
+	return &Tester{T: t, DUI: dui, Headless: h}
+}
+
+// Close closes the DUI.
+func (t *Tester) Close() {
+	t.DUI.Close()
+}
+
+// handle reads inputs from the DUI and handles them, until an input of type typ has been handled.
+// Other inputs, such as functions sent on DUI.Call, are handled along the way.
+func (t *Tester) handle(typ duit.InputType) {
+	t.T.Helper()
+	for {
+		select {
+		case e := <-t.DUI.Inputs:
+			t.DUI.Input(e)
+			if t.AfterInput != nil {
+				t.AfterInput()
+			}
+			if e.Type == typ {
+				t.checkError()
+				return
+			}
+		case err, ok := <-t.DUI.Error:
+			if !ok {
+				t.T.Fatalf("dui closed")
+			}
+			t.T.Fatalf("dui error: %s", err)
+		}
+	}
+}
+
+func (t *Tester) checkError() {
+	t.T.Helper()
+	select {
+	case err, ok := <-t.DUI.Error:
+		if !ok {
+			t.T.Fatalf("dui closed")
+		}
+		t.T.Fatalf("dui error: %s", err)
+	default:
+	}
+}
+
+// Input delivers a mouse, key or resize input event, as if coming from the display, and waits until it has been handled.
+// Other input types are handled by the DUI directly.
+func (t *Tester) Input(e duit.Input) {
+	t.T.Helper()
+	switch e.Type {
+	case duit.InputMouse:
+		t.mouse = e.Mouse
+		t.Headless.SendMouse(e.Mouse)
+	case duit.InputKey:
+		t.Headless.SendKey(e.Key)
+	case duit.InputResize:
+		t.T.Fatalf("use Resize for resize input")
+	default:
+		t.DUI.Input(e)
+		if t.AfterInput != nil {
+			t.AfterInput()
+		}
+		t.checkError()
+		return
+	}
+	t.handle(e.Type)
+}
+
+// Mouse delivers a mouse event.
+func (t *Tester) Mouse(m draw.Mouse) {
+	t.T.Helper()
+	t.Input(duit.Input{Type: duit.InputMouse, Mouse: m})
+}
+
+// Move moves the mouse to p, keeping the buttons pressed as they are.
+func (t *Tester) Move(p image.Point) {
+	t.T.Helper()
+	t.Mouse(draw.Mouse{Point: p, Buttons: t.mouse.Buttons, Msec: t.mouse.Msec + 1})
+}
+
+// Press moves the mouse to p and presses the buttons, eg duit.Button1.
+func (t *Tester) Press(p image.Point, buttons int) {
+	t.T.Helper()
+	t.Mouse(draw.Mouse{Point: p, Buttons: t.mouse.Buttons | buttons, Msec: t.mouse.Msec + 1})
+}
+
+// Release moves the mouse to p and releases the buttons.
+func (t *Tester) Release(p image.Point, buttons int) {
+	t.T.Helper()
+	t.Mouse(draw.Mouse{Point: p, Buttons: t.mouse.Buttons &^ buttons, Msec: t.mouse.Msec + 1})
+}
+
+// Click moves the mouse to p, and clicks button 1.
+func (t *Tester) Click(p image.Point) {
+	t.T.Helper()
+	t.ClickButton(p, duit.Button1)
+}
+
+// ClickButton moves the mouse to p and clicks the buttons.
+func (t *Tester) ClickButton(p image.Point, buttons int) {
+	t.T.Helper()
+	t.Move(p)
+	t.Press(p, buttons)
+	t.Release(p, buttons)
+}
+
+// Wheel scrolls with the mouse at p. Negative n scrolls up, positive n down, by |n| wheel clicks.
+func (t *Tester) Wheel(p image.Point, n int) {
+	t.T.Helper()
+	button := duit.Button5
+	if n < 0 {
+		button = duit.Button4
+		n = -n
+	}
+	t.Move(p)
+	for i := 0; i < n; i++ {
+		t.Press(p, button)
+		t.Release(p, button)
+	}
+}
+
+// Key delivers a key press, e.g. draw.KeyUp or '\n'.
+func (t *Tester) Key(k rune) {
+	t.T.Helper()
+	t.Input(duit.Input{Type: duit.InputKey, Key: k})
+}
+
+// Type delivers a key press for each character in s.
+func (t *Tester) Type(s string) {
+	t.T.Helper()
+	for _, c := range s {
+		t.Key(c)
+	}
+}
+
+// Resize resizes the screen, as if the user resized the window, and waits until the DUI has handled the resize.
+// The mouse event that the display sends after a resize is handled too.
+func (t *Tester) Resize(size image.Point) {
+	t.T.Helper()
+	if err := t.Headless.Resize(size); err != nil {
+		t.T.Fatalf("resize: %s", err)
+	}
+	t.handle(duit.InputResize)
+	t.handle(duit.InputMouse)
+}
+
+// Replay reads the recording at path, as written by duit.DUI.StartRecording, and delivers its inputs with Input and Resize, after resizing the screen to the size at the start of the recording.
+// Combined with Golden, a recorded session becomes a regression test.
+func (t *Tester) Replay(path string) {
+	t.T.Helper()
+	rec, err := duit.ReadRecordingPath(path)
+	if err != nil {
+		t.T.Fatalf("replay: %s", err)
+	}
+	if rec.Size != t.DUI.Display.ScreenImage.R.Size() {
+		t.Resize(rec.Size)
+	}
+	for _, ri := range rec.Inputs {
+		switch ri.Type {
+		case duit.InputMouse:
+			t.Mouse(ri.Mouse)
+		case duit.InputKey:
+			t.Key(ri.Key)
+		case duit.InputResize:
+			t.Resize(ri.Size)
+		}
+	}
+}
+
+// Screenshot returns the current contents of the screen.
+func (t *Tester) Screenshot() *image.RGBA {
+	return t.Headless.Screenshot()
+}
+
+// Kid returns the Kid holding ui, searching the UI tree from DUI.Top.
+// Kids are found through UIs that implement duit.Parent.
+// Kid fails the test if ui cannot be found.
+func (t *Tester) Kid(ui duit.UI) *duit.Kid {
+	t.T.Helper()
+	path := t.path(ui)
+	return path[len(path)-1]
+}
+
+// path returns the Kids from DUI.Top or an overlay down to the Kid holding ui.
+func (t *Tester) path(ui duit.UI) []*duit.Kid {
+	t.T.Helper()
+	var path []*duit.Kid
+	seen := map[*duit.Kid]bool{}
+	overlays := t.DUI.Overlays()
+	for i := len(overlays) - 1; i >= 0; i-- {
+		if kidPath(&overlays[i].Kid, ui, seen, &path) {
+			return path
+		}
+	}
+	if !kidPath(&t.DUI.Top, ui, seen, &path) {
+		t.T.Fatalf("no kid found for %T %p", ui, ui)
+	}
+	return path
+}
+
+// Rect returns the screen rectangle of ui, its Kid.R translated by the origins of the Kids above it.
+// Rect assumes UIs draw their kids at Kid.R, which holds for most UIs but not for UIs that scroll their content.
+func (t *Tester) Rect(ui duit.UI) image.Rectangle {
+	t.T.Helper()
+	path := t.path(ui)
+	r := path[len(path)-1].R
+	for _, k := range path[:len(path)-1] {
+		r = r.Add(k.R.Min)
+	}
+	return r
+}
+
+// kidPath finds the kids from k down to the kid holding ui.
+func kidPath(k *duit.Kid, ui duit.UI, seen map[*duit.Kid]bool, path *[]*duit.Kid) bool {
+	if seen[k] {
+		return false
+	}
+	seen[k] = true
+	*path = append(*path, k)
+	if k.UI == ui {
+		return true
+	}
+	if p, ok := k.UI.(duit.Parent); ok {
+		for _, c := range p.Children() {
+			if kidPath(c, ui, seen, path) {
+				return true
+			}
+		}
+	}
+	*path = (*path)[:len(*path)-1]
+	return false
+}
+
+// Center returns the center of ui on the screen, see Rect.
+func (t *Tester) Center(ui duit.UI) image.Point {
+	t.T.Helper()
+	r := t.Rect(ui)
+	return r.Min.Add(r.Size().Div(2))
+}
+
+// CheckRect checks that the Kid of ui has rectangle r, relative to its parent, as set during layout.
+func (t *Tester) CheckRect(ui duit.UI, r image.Rectangle) {
+	t.T.Helper()
+	k := t.Kid(ui)
+	if k.R != r {
+		t.T.Errorf("rectangle of %T: got %v, expected %v", ui, k.R, r)
+	}
+}
+
+// CheckState checks the layout and draw state of the Kid of ui.
+func (t *Tester) CheckState(ui duit.UI, layout, draw duit.State) {
+	t.T.Helper()
+	k := t.Kid(ui)
+	if k.Layout != layout || k.Draw != draw {
+		t.T.Errorf("state of %T: got layout %s, draw %s, expected layout %s, draw %s", ui, stateString(k.Layout), stateString(k.Draw), stateString(layout), stateString(draw))
+	}
+}
+
+func stateString(s duit.State) string {
+	switch s {
+	case duit.Dirty:
+		return "dirty"
+	case duit.DirtyKid:
+		return "dirtykid"
+	case duit.Clean:
+		return "clean"
+	}
+	return "unknown"
+}
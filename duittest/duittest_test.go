@@ -0,0 +1,133 @@
+package duittest_test
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"testing"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/devdraw"
+	"github.com/mjl-/duit/duittest"
+)
+
+func TestMain(m *testing.M) {
+	devdraw.Main()
+	os.Exit(m.Run())
+}
+
+func TestNew(t *testing.T) {
+	label := &duit.Label{Text: "hello"}
+	dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(label)}, &duit.DUIOpts{Dimensions: "200x100"})
+	defer dt.Close()
+
+	if size := dt.Screenshot().Bounds().Size(); size != image.Pt(200, 100) {
+		t.Fatalf("screen size %v, expected 200x100", size)
+	}
+	if dt.DUI.Top.Layout != duit.Clean || dt.DUI.Top.Draw != duit.Clean {
+		t.Fatalf("top not clean after New")
+	}
+	if r := dt.Rect(label); r.Min != image.ZP || r.Empty() {
+		t.Fatalf("label at %v, expected at origin", r)
+	}
+}
+
+func TestClick(t *testing.T) {
+	clicks := 0
+	button := &duit.Button{Text: "ok", Click: func() (e duit.Event) {
+		clicks++
+		return
+	}}
+	dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(button)}, &duit.DUIOpts{Dimensions: "200x100"})
+	defer dt.Close()
+
+	dt.Click(dt.Center(button))
+	dt.Click(dt.Center(button))
+	if clicks != 2 {
+		t.Fatalf("got %d clicks, expected 2", clicks)
+	}
+	dt.Click(image.Pt(190, 90))
+	if clicks != 2 {
+		t.Fatalf("click outside button clicked it")
+	}
+}
+
+func TestType(t *testing.T) {
+	field := &duit.Field{}
+	dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(field)}, &duit.DUIOpts{Dimensions: "200x100"})
+	defer dt.Close()
+
+	dt.Click(dt.Center(field))
+	dt.Type("duit")
+	if field.Text != "duit" {
+		t.Fatalf("field text %q, expected %q", field.Text, "duit")
+	}
+}
+
+func TestResize(t *testing.T) {
+	clicks := 0
+	button := &duit.Button{Text: "ok", Click: func() (e duit.Event) {
+		clicks++
+		return
+	}}
+	dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(button)}, &duit.DUIOpts{Dimensions: "200x100"})
+	defer dt.Close()
+
+	dt.Resize(image.Pt(300, 150))
+	if size := dt.Screenshot().Bounds().Size(); size != image.Pt(300, 150) {
+		t.Fatalf("screen size %v after resize, expected 300x150", size)
+	}
+
+	// The mouse event following the resize must not be mistaken for the click.
+	dt.Click(dt.Center(button))
+	if clicks != 1 {
+		t.Fatalf("got %d clicks after resize, expected 1", clicks)
+	}
+}
+
+func TestRectValidation(t *testing.T) {
+	// The field is held in an unexported kid of the validation.
+	field := &duit.Field{}
+	validation := &duit.Validation{Field: field, Validators: []duit.Validator{duit.ValidateRequired("")}}
+	dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(validation)}, &duit.DUIOpts{Dimensions: "200x100"})
+	defer dt.Close()
+
+	dt.Click(dt.Center(field))
+	dt.Type("x")
+	if field.Text != "x" {
+		t.Fatalf("field text %q, expected %q", field.Text, "x")
+	}
+}
+
+func TestGolden(t *testing.T) {
+	button := &duit.Button{Text: "ok"}
+	label := &duit.Label{Text: "golden"}
+	dt := duittest.New(t, &duit.Box{Padding: duit.SpaceXY(4, 4), Margin: image.Pt(4, 0), Kids: duit.NewKids(button, label)}, &duit.DUIOpts{Dimensions: "120x40"})
+	defer dt.Close()
+
+	dt.Golden("golden")
+	dt.Click(dt.Center(button))
+	dt.Golden("golden-clicked")
+}
+
+func TestDiff(t *testing.T) {
+	a := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	b := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	if _, n := duittest.Diff(a, b); n != 0 {
+		t.Fatalf("equal images differ in %d pixels", n)
+	}
+
+	b.Set(1, 2, color.RGBA{0xff, 0xff, 0xff, 0xff})
+	diff, n := duittest.Diff(a, b)
+	if n != 1 {
+		t.Fatalf("got %d differing pixels, expected 1", n)
+	}
+	if c := diff.RGBAAt(1, 2); c != (color.RGBA{0xff, 0, 0, 0xff}) {
+		t.Fatalf("differing pixel is %v, expected red", c)
+	}
+
+	c := image.NewRGBA(image.Rect(0, 0, 4, 5))
+	if _, n := duittest.Diff(a, c); n != 4 {
+		t.Fatalf("got %d differing pixels for different sizes, expected 4", n)
+	}
+}
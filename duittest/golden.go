@@ -0,0 +1,110 @@
+package duittest
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+)
+
+var update = flag.Bool("duittest.update", false, "write screenshots as new golden files instead of comparing")
+
+// GoldenDir is the directory holding golden files, relative to the package being tested.
+var GoldenDir = "testdata"
+
+// Golden compares the screen against the golden file GoldenDir/<name>.png.
+// On mismatch, the test fails, and the screen and an image highlighting the differences are written to GoldenDir/<name>.failed.png and GoldenDir/<name>.diff.png.
+// In the diff image, differing pixels are red, equal pixels are a faded version of the golden image.
+// Run the test with flag -duittest.update to write the current screen as golden file.
+func (t *Tester) Golden(name string) {
+	t.T.Helper()
+
+	img := t.Screenshot()
+	path := filepath.Join(GoldenDir, name+".png")
+	if *update {
+		if err := writePNG(path, img); err != nil {
+			t.T.Fatalf("writing golden file: %s", err)
+		}
+		return
+	}
+
+	exp, err := readPNG(path)
+	if err != nil {
+		t.T.Fatalf("reading golden file (run with -duittest.update to create): %s", err)
+	}
+	diff, n := Diff(exp, img)
+	if n == 0 {
+		return
+	}
+	failedPath := filepath.Join(GoldenDir, name+".failed.png")
+	diffPath := filepath.Join(GoldenDir, name+".diff.png")
+	if err := writePNG(failedPath, img); err != nil {
+		t.T.Errorf("writing screenshot: %s", err)
+	}
+	if err := writePNG(diffPath, diff); err != nil {
+		t.T.Errorf("writing diff: %s", err)
+	}
+	var size string
+	if exp.Bounds() != img.Bounds() {
+		size = fmt.Sprintf(", size %v, expected %v", img.Bounds(), exp.Bounds())
+	}
+	t.T.Errorf("screen differs from %s in %d pixels%s, see %s and %s", path, n, size, failedPath, diffPath)
+}
+
+// Diff compares two images, returning an image highlighting the differences and the number of differing pixels.
+// Pixels outside either image count as differing.
+func Diff(exp, got image.Image) (*image.RGBA, int) {
+	r := exp.Bounds().Union(got.Bounds())
+	diff := image.NewRGBA(r)
+	n := 0
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			p := image.Pt(x, y)
+			if !p.In(exp.Bounds()) || !p.In(got.Bounds()) {
+				diff.Set(x, y, red)
+				n++
+				continue
+			}
+			er, eg, eb, ea := exp.At(x, y).RGBA()
+			gr, gg, gb, ga := got.At(x, y).RGBA()
+			if er != gr || eg != gg || eb != gb || ea != ga {
+				diff.Set(x, y, red)
+				n++
+				continue
+			}
+			fade := func(v uint32) uint8 {
+				return uint8(0xc0 + (v>>8)/4)
+			}
+			diff.Set(x, y, color.RGBA{fade(er), fade(eg), fade(eb), 0xff})
+		}
+	}
+	return diff, n
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(f, img)
+	if xerr := f.Close(); err == nil {
+		err = xerr
+	}
+	return err
+}
@@ -80,7 +80,7 @@ type Cursor struct {
 type Edit struct {
 	NoScrollbar  bool                                       // If set, no scrollbar is shown. Content will still scroll.
 	LastSearch   string                                     // If starting with slash, the remainder is interpreted as regexp. used by cmd+[/?] and vi [*nN] commands. Literal text search should start with a space.
-	Error        chan error                                 // If set, errors from Edit (including read errors from underlying files) are sent here. If nil, errors go to dui.Error.
+	Error        chan error                                 `json:"-"` // If set, errors from Edit (including read errors from underlying files) are sent here. If nil, errors go to dui.Error.
 	Colors       *EditColors                                `json:"-"` // Colors to use for drawing the Edit UI, allows for creating an acme look.
 	Font         *draw.Font                                 `json:"-"` // Used for drawing all text.
 	Keys         func(k rune, m draw.Mouse) (e Event)       `json:"-"` // Called before handling keys. If you set e.Consumed, the key is not handled further.
@@ -1321,6 +1321,10 @@ func (ui *Edit) FirstFocus(dui *DUI, self *Kid) (warp *image.Point) {
 	return &p
 }
 
+func (ui *Edit) LastFocus(dui *DUI, self *Kid) (warp *image.Point) {
+	return ui.FirstFocus(dui, self)
+}
+
 func (ui *Edit) Focus(dui *DUI, self *Kid, o UI) (warp *image.Point) {
 	if o != ui {
 		return nil
@@ -1335,3 +1339,13 @@ func (ui *Edit) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 func (ui *Edit) Print(self *Kid, indent int) {
 	PrintUI("Edit", self, indent)
 }
+
+// Access returns a textarea node with the text as value, see Accessible.
+func (ui *Edit) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	n := &AccessNode{Role: RoleTextArea}
+	buf, err := ui.Text()
+	if !dui.error(err, "reading text for accessibility") {
+		n.Value = string(buf)
+	}
+	return n
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"image"
 	"log"
 	"time"
@@ -75,26 +76,12 @@ func main() {
 			login,
 		),
 	}
-	// first time the entire UI is drawn
-	dui.Render()
-
-	// our main loop
-	for {
-		// where we listen on two channels
-		select {
-		case e := <-dui.Inputs:
-			// inputs are: mouse events, keyboard events, window resize events,
-			// functions to call, recoverable errors
-			dui.Input(e)
-
-		case warn, ok := <-dui.Error:
-			// on window close (clicking the X in the top corner),
-			// the channel is closed and the application should quit.
-			// otherwise, err is a warning or recoverable error.
-			if !ok {
-				return
-			}
-			log.Printf("duit: %s\n", warn)
-		}
+	// our main loop: the ui is drawn, and inputs are handled,
+	// such as mouse events, keyboard events, window resize events and
+	// functions to call. recoverable errors are logged.
+	// run returns when the window is closed (clicking the X in the top corner).
+	err = dui.Run(context.Background(), nil)
+	if err != nil {
+		log.Fatalf("run: %s\n", err)
 	}
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"image"
 	_ "image/jpeg"
@@ -188,23 +189,18 @@ func main() {
 			},
 		),
 	}
-	dui.Render()
-
-	for {
-		select {
-		case e := <-dui.Inputs:
-			dui.Input(e)
-
-		case err, ok := <-dui.Error:
-			if !ok {
-				return
-			}
-			log.Printf("duit: %s\n", err)
-
-		case <-tick:
-			count++
-			counter.Text = fmt.Sprintf("%d", count)
-			dui.Render()
-		}
-	}
+	err = dui.Run(context.Background(), &duit.RunOpts{
+		Chans: []duit.RunChan{
+			{
+				Chan: tick,
+				Receive: func(v interface{}, ok bool) error {
+					count++
+					counter.Text = fmt.Sprintf("%d", count)
+					dui.MarkLayout(counter)
+					return nil
+				},
+			},
+		},
+	})
+	check(err, "run")
 }
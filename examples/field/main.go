@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"image"
 	"log"
 
@@ -27,18 +28,6 @@ func main() {
 			},
 		),
 	}
-	dui.Render()
-
-	for {
-		select {
-		case e := <-dui.Inputs:
-			dui.Input(e)
-
-		case err, ok := <-dui.Error:
-			if !ok {
-				return
-			}
-			log.Printf("duit: %s\n", err)
-		}
-	}
+	err = dui.Run(context.Background(), nil)
+	check(err, "run")
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"image"
 	"log"
 	"os/exec"
@@ -92,18 +93,6 @@ a b c d e f g h i j k l m n o p q r s t u v w x y z`))
 			edit,
 		),
 	}
-	dui.Render()
-
-	for {
-		select {
-		case e := <-dui.Inputs:
-			dui.Input(e)
-
-		case err, ok := <-dui.Error:
-			if !ok {
-				return
-			}
-			log.Printf("duit: %s\n", err)
-		}
-	}
+	err = dui.Run(context.Background(), nil)
+	check(err, "run")
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	_ "image/gif"
 	_ "image/jpeg"
@@ -8,7 +9,6 @@ import (
 	"log"
 	"os"
 
-	"9fans.net/go/draw"
 	"github.com/mjl-/duit"
 )
 
@@ -20,8 +20,9 @@ func check(err error, msg string) {
 
 func main() {
 	log.SetFlags(0)
+	scale := flag.String("scale", "none", "scale mode: none, fixed, fit, fill or stretch")
 	flag.Usage = func() {
-		log.Println("duitimage path")
+		log.Println("duitimage [flags] path")
 		flag.PrintDefaults()
 	}
 	flag.Parse()
@@ -31,30 +32,30 @@ func main() {
 		os.Exit(2)
 	}
 
+	modes := map[string]duit.ImageScale{
+		"none":    duit.ScaleNone,
+		"fixed":   duit.ScaleFixed,
+		"fit":     duit.ScaleFit,
+		"fill":    duit.ScaleFill,
+		"stretch": duit.ScaleStretch,
+	}
+	mode, ok := modes[*scale]
+	if !ok {
+		flag.Usage()
+		os.Exit(2)
+	}
+
 	dui, err := duit.NewDUI("ex/image", nil)
 	check(err, "new dui")
 
-	readImagePath := func(path string) *draw.Image {
-		img, err := duit.ReadImagePath(dui.Display, path)
-		check(err, "read image")
-		return img
-	}
+	// Animated GIFs and APNGs are played, other images have a single frame.
+	anim, err := duit.ReadAnimationPath(dui.Display, args[0])
+	check(err, "read image")
 
 	dui.Top.UI = &duit.Image{
-		Image: readImagePath(args[0]),
-	}
-	dui.Render()
-
-	for {
-		select {
-		case e := <-dui.Inputs:
-			dui.Input(e)
-
-		case err, ok := <-dui.Error:
-			if !ok {
-				return
-			}
-			log.Printf("duit: %s\n", err)
-		}
+		Animation: anim,
+		Scale:     mode,
 	}
+	err = dui.Run(context.Background(), nil)
+	check(err, "run")
 }
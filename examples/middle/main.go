@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"github.com/mjl-/duit"
@@ -17,18 +18,6 @@ func main() {
 	check(err, "new dui")
 
 	dui.Top.UI = duit.NewMiddle(duit.SpaceXY(10, 10), &duit.Label{Text: "this label is centered vertically and horizontally"})
-	dui.Render()
-
-	for {
-		select {
-		case e := <-dui.Inputs:
-			dui.Input(e)
-
-		case err, ok := <-dui.Error:
-			if !ok {
-				return
-			}
-			log.Printf("duit: %s\n", err)
-		}
-	}
+	err = dui.Run(context.Background(), nil)
+	check(err, "run")
 }
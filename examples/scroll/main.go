@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"github.com/mjl-/duit"
@@ -23,18 +24,6 @@ func main() {
 Est an partiendo prodesset, qui ea incorrupte efficiendi. Ei eam suavitate consectetuer. No est dictas singulis complectitur. Sit eius meliore constituto ea, eruditi percipit suscipiantur mei ex. Eu sea eruditi phaedrum recteque. Quot prompta ius eu, cu nec imperdiet signiferumque. Facete invidunt sed in, ne cum affert dolorem, an nam regione verterem.`,
 		},
 	)
-	dui.Render()
-
-	for {
-		select {
-		case e := <-dui.Inputs:
-			dui.Input(e)
-
-		case err, ok := <-dui.Error:
-			if !ok {
-				return
-			}
-			log.Printf("duit: %s\n", err)
-		}
-	}
+	err = dui.Run(context.Background(), nil)
+	check(err, "run")
 }
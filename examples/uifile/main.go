@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/mjl-/duit"
+)
+
+func check(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s\n", msg, err)
+	}
+}
+
+func main() {
+	log.SetFlags(0)
+	watch := flag.Bool("watch", false, "reload the ui description when it changes")
+	flag.Usage = func() {
+		log.Println("duituifile [flags] [ui.json]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	args := flag.Args()
+	path := "ui.json"
+	switch len(args) {
+	case 0:
+	case 1:
+		path = args[0]
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dui, err := duit.NewDUI("ex/uifile", nil)
+	check(err, "new dui")
+
+	// Called for the initial UI, and with -watch again after each change of the file.
+	err = dui.LoadUI(path, *watch, func(top *duit.Kid, ids map[string]duit.UI) {
+		name := ids["name"].(*duit.Field)
+		subscribe := ids["subscribe"].(*duit.Checkbox)
+		ids["save"].(*duit.Button).Click = func() (e duit.Event) {
+			log.Printf("name %q, subscribe %v\n", name.Text, subscribe.Checked)
+			return
+		}
+	})
+	check(err, "load ui")
+	err = dui.Run(context.Background(), nil)
+	check(err, "run")
+}
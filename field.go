@@ -18,6 +18,7 @@ type Field struct {
 	Cursor1         int                                  // Index in string of cursor in bytes, start at 1, 0 means end of string.
 	SelectionStart1 int                                  // If > 0, 1 beyond the start of the selection in bytes, with Cursor being the end.
 	Password        bool                                 // Render text as bullet items to hide the password (but not length).
+	Invalid         bool                                 // If set, the border is drawn in DUI.Danger colors. Set by Validation.
 	Font            *draw.Font                           `json:"-"` // Font to use for drawing text.
 	Changed         func(text string) (e Event)          `json:"-"` // Called after contents of field have changed.
 	Keys            func(k rune, m draw.Mouse) (e Event) `json:"-"` // Called before handling key. If you consume the event, Changed will not be called.
@@ -153,6 +154,12 @@ func (ui *Field) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m
 		}
 		text, s, e, c0 = nt, ns, ne, nc0
 	}
+	if ui.Invalid && !ui.Disabled {
+		colors.Border = dui.Danger.Normal.Border
+		if hover {
+			colors.Border = dui.Danger.Hover.Border
+		}
+	}
 	img.Draw(r, colors.Background, nil, image.ZP)
 	drawRoundedBorder(img, r, colors.Border)
 
@@ -397,7 +404,7 @@ func (ui *Field) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point
 		return ui.cursor0()
 	}
 	switch k {
-	case draw.KeyPageUp, draw.KeyPageDown, draw.KeyUp, draw.KeyDown, '\t':
+	case draw.KeyPageUp, draw.KeyPageDown, draw.KeyUp, draw.KeyDown, '\t', draw.KeyEscape:
 		return
 	case draw.KeyLeft:
 		cursor0 = cursorPrev()
@@ -501,6 +508,9 @@ func (ui *Field) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point
 	ui.fixCursor()
 	r.Consumed = true
 	self.Draw = Dirty
+	if origText != ui.Text {
+		dui.valueChanged(ui)
+	}
 	if ui.Changed != nil && origText != ui.Text {
 		e := ui.Changed(ui.Text)
 		propagateEvent(self, &r, e)
@@ -516,6 +526,10 @@ func (ui *Field) FirstFocus(dui *DUI, self *Kid) *image.Point {
 	return &p
 }
 
+func (ui *Field) LastFocus(dui *DUI, self *Kid) *image.Point {
+	return ui.FirstFocus(dui, self)
+}
+
 func (ui *Field) Focus(dui *DUI, self *Kid, o UI) *image.Point {
 	if o != ui {
 		return nil
@@ -530,3 +544,21 @@ func (ui *Field) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 func (ui *Field) Print(self *Kid, indent int) {
 	PrintUI("Field", self, indent)
 }
+
+// Access returns a textfield node with the text as value, or without value for passwords, see Accessible.
+func (ui *Field) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	n := &AccessNode{Role: RoleTextField, Name: ui.Placeholder, State: AccessState{Disabled: ui.Disabled, Protected: ui.Password}}
+	if !ui.Password {
+		n.Value = ui.Text
+	}
+	return n
+}
+
+// KeepState copies the text, cursor and selection of old, for LoadUI.
+func (ui *Field) KeepState(old UI) {
+	if o, ok := old.(*Field); ok {
+		ui.Text = o.Text
+		ui.Cursor1 = o.Cursor1
+		ui.SelectionStart1 = o.SelectionStart1
+	}
+}
@@ -0,0 +1,198 @@
+package duit
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"9fans.net/go/draw"
+)
+
+// FontFamily is the kind of typeface of a FontStyle.
+type FontFamily byte
+
+const (
+	FontSans  FontFamily = iota // Sans-serif, the default.
+	FontSerif                   // Serif.
+	FontMono                    // Monospaced, e.g. for code.
+)
+
+// FontWeight is the thickness of the strokes of a font.
+type FontWeight byte
+
+const (
+	FontRegular FontWeight = iota // Normal weight, the default.
+	FontBold                      // Bold.
+)
+
+// FontStyle describes a font by its properties, e.g. FontStyle{Family: FontMono, Size: 1} for a larger monospaced font.
+// DUI.FontStyle resolves a style to a font using the registry in DUI.Fonts.
+type FontStyle struct {
+	Family FontFamily
+	Weight FontWeight
+	Italic bool
+	Size   int // Relative size in steps of 20%. 0 is the normal size, 1 is larger, -1 smaller.
+}
+
+// Fonts is the font registry of a DUI, for resolving font styles to fonts.
+// Set Names and Size before fonts are resolved, resolved fonts are cached.
+type Fonts struct {
+	// Font names for each family, weight and italic, keyed by a FontStyle with Size 0.
+	// A name without slash is a font served by fontsrv, e.g. "DejaVuSans-Bold", opened as /mnt/font/<name>/<size>a/font.
+	// Other names are paths to font files, with %d replaced by the size in pixels, e.g. "/lib/font/bit/lucsans/unicode.%d.font".
+	// Styles without a name fall back to the regular weight, then to non-italic, then to the sans family.
+	// If no name is found, the default font is used, at other sizes only if it is served by fontsrv.
+	Names map[FontStyle]string
+
+	// Normal font size in lowDPI pixels. If 0, the size of the default font is used.
+	Size int
+
+	// Steps, like FontStyle.Size, added to the size of all fonts resolved by DUI.Font. Change it with DUI.SetFontZoom.
+	// Only fonts that can be opened at other sizes are zoomed: fonts served by fontsrv, and font files with %d in their name.
+	// Without Names for a style and with a default font not served by fontsrv, such as the builtin font of the headless backend, zoom has no effect on that style.
+	Zoom int
+
+	resolved map[FontStyle]*draw.Font // Resolved fonts, keyed by style with zoom applied to Size.
+	opened   map[string]*draw.Font    // By font name, nil if opening failed.
+	styles   map[*draw.Font]FontStyle // Style that each font returned by DUI.FontStyle was resolved for, so DUI.Font can resolve it again after a zoom.
+}
+
+// defaultFontNames returns font names for the DejaVu fonts, commonly available. The regular sans font is the default font.
+func defaultFontNames() map[FontStyle]string {
+	names := map[FontStyle]string{}
+	add := func(family FontFamily, regular, bold, italic, boldItalic string) {
+		if regular != "" {
+			names[FontStyle{Family: family}] = regular
+		}
+		names[FontStyle{Family: family, Weight: FontBold}] = bold
+		names[FontStyle{Family: family, Italic: true}] = italic
+		names[FontStyle{Family: family, Weight: FontBold, Italic: true}] = boldItalic
+	}
+	add(FontSans, "", "DejaVuSans-Bold", "DejaVuSans-Oblique", "DejaVuSans-BoldOblique")
+	add(FontSerif, "DejaVuSerif", "DejaVuSerif-Bold", "DejaVuSerif-Italic", "DejaVuSerif-BoldItalic")
+	add(FontMono, "DejaVuSansMono", "DejaVuSansMono-Bold", "DejaVuSansMono-Oblique", "DejaVuSansMono-BoldOblique")
+	return names
+}
+
+// FontStyle returns the font for style, with the zoom of DUI.Fonts applied.
+// Fonts are opened at their lowDPI size, they are scaled automatically on hiDPI displays.
+// If a font cannot be opened, the default font is returned. With Debug set, the error is logged, once.
+// UIs can use the returned font like any other font, e.g. for their Font field. Since UIs get their fonts through DUI.Font, they follow changes of the zoom.
+func (d *DUI) FontStyle(style FontStyle) *draw.Font {
+	f := &d.Fonts
+	key := style
+	key.Size += f.Zoom
+	if font, ok := f.resolved[key]; ok {
+		return font
+	}
+	if f.resolved == nil {
+		f.resolved = map[FontStyle]*draw.Font{}
+		f.opened = map[string]*draw.Font{}
+		f.styles = map[*draw.Font]FontStyle{}
+	}
+
+	font := d.resolveFont(key)
+	f.resolved[key] = font
+	if font != d.Display.DefaultFont {
+		f.styles[font] = style
+	}
+	return font
+}
+
+// resolveFont opens the font for style, with zoom already applied to its Size.
+func (d *DUI) resolveFont(style FontStyle) *draw.Font {
+	f := &d.Fonts
+	defaultName, defaultSize, fontsrv := d.defaultFontName()
+	base := f.Size
+	if base <= 0 {
+		base = defaultSize
+	}
+	size := maximum(4, int(math.Round(float64(base)*math.Pow(1.2, float64(style.Size)))))
+
+	name := ""
+	for _, family := range []FontFamily{style.Family, FontSans} {
+		for _, s := range []FontStyle{
+			{family, style.Weight, style.Italic, 0},
+			{family, FontRegular, style.Italic, 0},
+			{family, style.Weight, false, 0},
+			{family, FontRegular, false, 0},
+		} {
+			if name = f.Names[s]; name != "" {
+				break
+			}
+		}
+		if name != "" {
+			break
+		}
+	}
+	if name == "" {
+		if !fontsrv || size == defaultSize {
+			return d.Display.DefaultFont
+		}
+		name = defaultName
+	}
+
+	var path string
+	switch {
+	case !strings.Contains(name, "/"):
+		path = fmt.Sprintf("/mnt/font/%s/%da/font", name, size)
+	case strings.Contains(name, "%d"):
+		// Low and high DPI version, the draw library picks the right one.
+		path = fmt.Sprintf(name, size) + "," + fmt.Sprintf(name, 2*size)
+	default:
+		path = name
+	}
+	font, ok := f.opened[path]
+	if !ok {
+		var err error
+		font, err = d.Display.OpenFont(path)
+		if err != nil && d.Debug {
+			log.Printf("duit: open font %s: %s, using default font\n", path, err)
+		}
+		f.opened[path] = font
+	}
+	if font == nil {
+		return d.Display.DefaultFont
+	}
+	return font
+}
+
+// defaultFontName returns the fontsrv name and lowDPI size of the default font, and whether it is a font served by fontsrv.
+// For other fonts, the size is estimated from the font height.
+func (d *DUI) defaultFontName() (name string, size int, fontsrv bool) {
+	font := d.Display.DefaultFont
+	height := font.Height
+	if d.Display.HiDPI() {
+		height /= 2
+	}
+	size = maximum(4, height*5/6)
+	// Font names look like /mnt/font/DejaVuSans/13a/font. On hiDPI displays, the size is doubled.
+	t := strings.Split(strings.TrimPrefix(font.Name, "/mnt/font/"), "/")
+	if !strings.HasPrefix(font.Name, "/mnt/font/") || len(t) != 3 || t[2] != "font" {
+		return "", size, false
+	}
+	v, err := strconv.Atoi(strings.TrimSuffix(t[1], "a"))
+	if err != nil {
+		return "", size, false
+	}
+	if d.Display.HiDPI() {
+		v /= 2
+	}
+	return t[0], v, true
+}
+
+// SetFontZoom sets the zoom of DUI.Fonts and marks the UI and overlays as needing a layout.
+// Zoom is also changed with cmd-+ (or cmd-=), cmd-- and cmd-0 for resetting, if the UI with focus does not consume those keys.
+// Zooming needs fonts that can be opened at other sizes, see Fonts.Zoom. Fonts are not scaled by their pixels.
+func (d *DUI) SetFontZoom(zoom int) {
+	d.Fonts.Zoom = zoom
+	d.Top.Layout = Dirty
+	for _, o := range d.overlays {
+		o.Layout = Dirty
+	}
+	if d.tooltip != nil {
+		d.tooltip.Layout = Dirty
+	}
+}
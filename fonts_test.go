@@ -0,0 +1,88 @@
+package duit_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/duittest"
+)
+
+func TestFonts(t *testing.T) {
+	// Font files for each size, with the builtin subfont, and the size as height.
+	dir, err := ioutil.TempDir("", "duit")
+	if err != nil {
+		t.Fatalf("tempdir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"sans", "mono"} {
+		for size := 4; size <= 40; size++ {
+			buf := fmt.Sprintf("%d %d\n0x0000 0x00ff *default*\n", size, size-2)
+			if err := ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%s.%d", name, size)), []byte(buf), 0666); err != nil {
+				t.Fatalf("write font: %s", err)
+			}
+		}
+	}
+
+	dt := duittest.New(t, &duit.Label{Text: "fonts"}, &duit.DUIOpts{Dimensions: "200x100"})
+	defer dt.Close()
+	dui := dt.DUI
+	dui.Fonts = duit.Fonts{
+		Names: map[duit.FontStyle]string{
+			{}:                      filepath.Join(dir, "sans.%d"),
+			{Family: duit.FontMono}: filepath.Join(dir, "mono.%d"),
+		},
+		Size: 10,
+	}
+
+	regular := dui.FontStyle(duit.FontStyle{})
+	if regular == dui.Display.DefaultFont || regular.Height != 10 {
+		t.Fatalf("regular font %s, height %d, expected sans.10", regular.Name, regular.Height)
+	}
+	if f := dui.FontStyle(duit.FontStyle{}); f != regular {
+		t.Fatalf("resolved font not cached")
+	}
+	for size, height := range map[int]int{1: 12, 2: 14, -1: 8} {
+		if f := dui.FontStyle(duit.FontStyle{Size: size}); f.Height != height {
+			t.Fatalf("font for size %d has height %d, expected %d", size, f.Height, height)
+		}
+	}
+
+	// Styles without a name fall back to the regular weight, non-italic, and the sans family.
+	if f := dui.FontStyle(duit.FontStyle{Weight: duit.FontBold, Italic: true}); f != regular {
+		t.Fatalf("bold italic font %s, expected fallback to regular", f.Name)
+	}
+	if f := dui.FontStyle(duit.FontStyle{Family: duit.FontSerif}); f != regular {
+		t.Fatalf("serif font %s, expected fallback to sans", f.Name)
+	}
+	mono := dui.FontStyle(duit.FontStyle{Family: duit.FontMono, Weight: duit.FontBold})
+	if mono == regular || filepath.Base(mono.Name) != "mono.10" {
+		t.Fatalf("bold mono font %s, expected mono.10", mono.Name)
+	}
+
+	// Zoom applies to new lookups and to fonts resolved before.
+	dui.SetFontZoom(1)
+	if f := dui.FontStyle(duit.FontStyle{}); f.Height != 12 {
+		t.Fatalf("zoomed font height %d, expected 12", f.Height)
+	}
+	if f := dui.Font(mono); filepath.Base(f.Name) != "mono.12" {
+		t.Fatalf("zoomed mono font %s, expected mono.12", f.Name)
+	}
+	dui.SetFontZoom(0)
+	if f := dui.Font(mono); f != mono {
+		t.Fatalf("font after resetting zoom %s, expected mono.10", f.Name)
+	}
+
+	// Without names, the builtin default font cannot be zoomed.
+	dui.Fonts = duit.Fonts{}
+	dui.SetFontZoom(2)
+	if f := dui.FontStyle(duit.FontStyle{Weight: duit.FontBold}); f != dui.Display.DefaultFont {
+		t.Fatalf("font without names %s, expected default font", f.Name)
+	}
+	if f := dui.Font(nil); f != dui.Display.DefaultFont {
+		t.Fatalf("zoomed default font %s, expected default font", f.Name)
+	}
+}
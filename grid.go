@@ -171,6 +171,10 @@ func (ui *Grid) FirstFocus(dui *DUI, self *Kid) *image.Point {
 	return KidsFirstFocus(dui, self, ui.Kids)
 }
 
+func (ui *Grid) LastFocus(dui *DUI, self *Kid) *image.Point {
+	return KidsLastFocus(dui, self, ui.Kids)
+}
+
 func (ui *Grid) Focus(dui *DUI, self *Kid, o UI) *image.Point {
 	return KidsFocus(dui, self, ui.Kids, o)
 }
@@ -179,6 +183,10 @@ func (ui *Grid) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 	return KidsMark(self, ui.Kids, o, forLayout)
 }
 
+func (ui *Grid) Children() []*Kid {
+	return ui.Kids
+}
+
 func (ui *Grid) Print(self *Kid, indent int) {
 	PrintUI(fmt.Sprintf("Grid columns=%d padding=%v", ui.Columns, ui.Padding), self, indent)
 	KidsPrint(ui.Kids, indent+1)
@@ -32,6 +32,7 @@ const (
 // Gridlist is a table-like list of selectable values.
 // Currently each cell in each row is drawn as a single-line string.
 // Column widths can be adjusted by dragging the separator in the header.
+// Hovering over a truncated cell shows its full text in a tooltip.
 //
 // Keys:
 // 	arrow up, move selection up
@@ -60,6 +61,7 @@ type Gridlist struct {
 	size             image.Point
 	draggingColStart int         // x offset of column being dragged, so 1 means the first column is being dragged.
 	cellImage        *draw.Image // scratch image to draw cells on if they are too big
+	tooltip          *Label      // full text of truncated cell under the mouse
 }
 
 var _ UI = &Gridlist{}
@@ -434,6 +436,7 @@ func (ui *Gridlist) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, o
 	}
 	rowHeight := ui.rowHeight(dui)
 	index := m.Y / (rowHeight + separatorHeight)
+	ui.cellTooltip(dui, index, m)
 	if ui.draggingColStart > 0 || (index == 0 && ui.Header != nil) {
 		// xxx todo: on double click, max column before fit (but at most twice as large)
 		// xxx todo: should probably show the grid separator with hover style
@@ -514,6 +517,45 @@ func (ui *Gridlist) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, o
 	return
 }
 
+// cellTooltip requests a tooltip with the full text of the cell at m in row index (including header), if the text is truncated.
+func (ui *Gridlist) cellTooltip(dui *DUI, index int, m draw.Mouse) {
+	if ui.draggingColStart > 0 || m.Buttons != 0 {
+		return
+	}
+	var row *Gridrow
+	if ui.Header != nil {
+		if index == 0 {
+			row = ui.Header
+		}
+		index--
+	}
+	if row == nil {
+		if index < 0 || index >= len(ui.Rows) {
+			return
+		}
+		row = ui.Rows[index]
+	}
+	widths := ui.columnWidths(dui, ui.size.X)
+	offsets := ui.makeWidthOffsets(dui, widths)
+	col := -1
+	for i, x := range offsets {
+		if m.X >= x {
+			col = i
+		}
+	}
+	if col < 0 || col >= len(row.Values) {
+		return
+	}
+	s := row.Values[col]
+	if ui.font(dui).StringWidth(s) <= widths[col] {
+		return
+	}
+	if ui.tooltip == nil || ui.tooltip.Text != s {
+		ui.tooltip = &Label{Text: s, Font: ui.Font}
+	}
+	dui.SetTooltip(ui.tooltip)
+}
+
 func (ui *Gridlist) selectedIndices() (l []int) {
 	for i, row := range ui.Rows {
 		if row.Selected {
@@ -651,6 +693,10 @@ func (ui *Gridlist) FirstFocus(dui *DUI, self *Kid) (warp *image.Point) {
 	return &p
 }
 
+func (ui *Gridlist) LastFocus(dui *DUI, self *Kid) (warp *image.Point) {
+	return ui.FirstFocus(dui, self)
+}
+
 func (ui *Gridlist) Focus(dui *DUI, self *Kid, o UI) (warp *image.Point) {
 	if o != ui {
 		return nil
@@ -665,3 +711,51 @@ func (ui *Gridlist) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 func (ui *Gridlist) Print(self *Kid, indent int) {
 	PrintUI("Gridlist", self, indent)
 }
+
+// Access returns a table node with a row node for the header and each row, holding cell nodes, see Accessible.
+func (ui *Gridlist) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	n := &AccessNode{Role: RoleTable}
+	row := ui.exampleRow()
+	if row == nil || len(row.Values) == 0 {
+		return n
+	}
+	rowHeight := ui.rowHeight(dui)
+	pad := dui.ScaleSpace(ui.Padding)
+	widths := ui.columnWidths(dui, ui.size.X)
+	x := ui.makeWidthOffsets(dui, widths)
+
+	rows := ui.Rows
+	if ui.Header != nil {
+		rows = append([]*Gridrow{ui.Header}, rows...)
+	}
+	for i, row := range rows {
+		lineR := image.Rect(0, i*(rowHeight+separatorHeight), ui.size.X, i*(rowHeight+separatorHeight)+rowHeight).Add(orig)
+		rn := &AccessNode{Role: RoleRow, Rect: lineR, State: AccessState{Selected: row.Selected}}
+		role := RoleCell
+		if ui.Header != nil && i == 0 {
+			role = RoleColumnTitle
+		}
+		for j, s := range row.Values {
+			if j >= len(widths) {
+				break
+			}
+			cellR := lineR
+			cellR.Min.X = lineR.Min.X + x[j] + separatorWidth
+			cellR.Max.X = cellR.Min.X + widths[j] + pad.Dx()
+			rn.Children = append(rn.Children, &AccessNode{Role: role, Name: s, Rect: cellR})
+		}
+		n.Children = append(n.Children, rn)
+	}
+	return n
+}
+
+// KeepState copies which rows of old are selected, for LoadUI.
+func (ui *Gridlist) KeepState(old UI) {
+	o, ok := old.(*Gridlist)
+	if !ok || len(o.Rows) != len(ui.Rows) {
+		return
+	}
+	for i, row := range ui.Rows {
+		row.Selected = o.Rows[i].Selected
+	}
+}
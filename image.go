@@ -2,33 +2,333 @@ package duit
 
 import (
 	"image"
+	"math"
+	"time"
 
 	"9fans.net/go/draw"
 )
 
-// Image shows an image. Currently always in its original size.
+// ImageScale is how an Image is sized.
+type ImageScale int
+
+const (
+	ScaleNone    ImageScale = iota // Original size in pixels, ignoring Size. The default.
+	ScaleFixed                     // Size exactly, stretching if needed. If a coordinate of Size is zero, it follows the aspect ratio. If Size is zero, the original size in lowDPI pixels, so the image has the same physical size on hiDPI screens.
+	ScaleFit                       // As large as fits in the space, keeping the aspect ratio.
+	ScaleFill                      // Covers the space, keeping the aspect ratio. Parts that do not fit are cut off, keeping the image centered.
+	ScaleStretch                   // Covers the space exactly, without keeping the aspect ratio.
+)
+
+// Image shows an image.
+// By default, it is shown in its original size. Scale and Size change that.
+// For ScaleFit, ScaleFill and ScaleStretch, the space is Size, with each coordinate that is zero replaced by the available space for that coordinate.
+// Images are resampled in Draw. The pixels of the original image and the resampled images are kept, so resizing only resamples, and the resampled image is only uploaded again when its size changes.
+// The resampled images are freed when the image or its size changes, and after the Image was removed from the UI tree, which is checked every 10 seconds, the way timers check their UI.
+// If Animation is set, its frames are shown in turn instead. The animation pauses while the image is scrolled out of view.
 type Image struct {
-	Image *draw.Image `json:"-"`
+	Image     *draw.Image `json:"-"`
+	Animation *Animation  `json:"-"` // If set, shown instead of Image.
+	Scale     ImageScale  // How the image is sized.
+	Size      image.Point // Size in lowDPI pixels, scaled with DUI.Scale. See ImageScale.
+
+	anim     *Animation      // Animation that frame and loops are for.
+	frame    int             // Index of frame of anim currently shown.
+	loops    int             // Number of times all frames were shown.
+	timer    *Timer          // For showing the next frame.
+	viewport *Scroll         // Scroll the image was last drawn in, if any.
+	drawR    image.Rectangle // Location of the last draw, on the image of viewport or the screen.
+
+	size       image.Point                 // Size of the resampled image, set by Layout. Larger than self.R for ScaleFill.
+	scaleFor   interface{}                 // Image or Animation that srcPixels and scaled are for.
+	srcPixels  map[*draw.Image]*image.RGBA // Original pixels, read back once for resampling.
+	scaled     map[*draw.Image]*draw.Image // Resampled images, all of scaledSize.
+	scaledSize image.Point                 // Size of images in scaled.
+	sweep      *Timer                      // Checks whether ui is still in the UI tree while resampled images are kept.
 }
 
+// resampleCheckInterval is how often an Image with resampled images checks whether it is still in the UI tree.
+const resampleCheckInterval = 10 * time.Second
+
 var _ UI = &Image{}
 
+// current returns the image to show, restarting the animation if it was changed.
+func (ui *Image) current() *draw.Image {
+	if ui.Animation == nil || len(ui.Animation.Frames) == 0 {
+		return ui.Image
+	}
+	if ui.anim != ui.Animation {
+		ui.anim = ui.Animation
+		ui.frame = 0
+		ui.loops = 0
+		if ui.timer != nil {
+			ui.timer.Stop()
+			ui.timer = nil
+		}
+	}
+	return ui.anim.Frames[ui.frame]
+}
+
 func (ui *Image) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 	dui.debugLayout(self)
-	if ui.Image == nil {
+	img := ui.current()
+	if img == nil {
 		self.R = image.ZR
-	} else {
-		self.R = rect(ui.Image.R.Size())
+		return
 	}
-	return
+	orig := img.R.Size()
+	ui.size = orig
+	if ui.Scale == ScaleNone || orig.X == 0 || orig.Y == 0 {
+		self.R = rect(orig)
+		return
+	}
+
+	size := image.Pt(dui.Scale(ui.Size.X), dui.Scale(ui.Size.Y))
+	if ui.Scale == ScaleFixed {
+		switch {
+		case size.X == 0 && size.Y == 0:
+			size = image.Pt(dui.Scale(orig.X), dui.Scale(orig.Y))
+		case size.X == 0:
+			size.X = size.Y * orig.X / orig.Y
+		case size.Y == 0:
+			size.Y = size.X * orig.Y / orig.X
+		}
+		ui.size = size
+		self.R = rect(ui.size)
+		return
+	}
+
+	if size.X == 0 {
+		size.X = sizeAvail.X
+	}
+	if size.Y == 0 {
+		size.Y = sizeAvail.Y
+	}
+	fx := float64(size.X) / float64(orig.X)
+	fy := float64(size.Y) / float64(orig.Y)
+	switch ui.Scale {
+	case ScaleFit:
+		f := math.Min(fx, fy)
+		size = image.Pt(int(f*float64(orig.X)), int(f*float64(orig.Y)))
+		ui.size = size
+	case ScaleFill:
+		f := math.Max(fx, fy)
+		ui.size = image.Pt(int(math.Ceil(f*float64(orig.X))), int(math.Ceil(f*float64(orig.Y))))
+	case ScaleStretch:
+		ui.size = size
+	}
+	self.R = rect(size)
 }
 
 func (ui *Image) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	dui.debugDraw(self)
-	if ui.Image == nil {
+	cur := ui.current()
+	if cur == nil {
 		return
 	}
-	img.Draw(ui.Image.R.Add(orig), ui.Image, nil, image.ZP)
+	ui.viewport = dui.viewport
+	ui.drawR = rect(self.R.Size()).Add(orig)
+	if ui.size.Eq(cur.R.Size()) {
+		ui.freeScaled()
+		img.Draw(ui.drawR, cur, nil, cur.R.Min)
+	} else if scaled := ui.resampled(dui, cur); scaled != nil {
+		// For ScaleFill, the scaled image is larger than self.R, show its center.
+		sp := scaled.R.Min.Add(scaled.R.Size().Sub(self.R.Size()).Div(2))
+		img.Draw(ui.drawR, scaled, nil, sp)
+	}
+	if ui.anim != nil && ui.timer == nil && len(ui.anim.Frames) > 1 && !ui.finished() {
+		ui.schedule(dui)
+	}
+}
+
+// finished returns whether the animation has been shown as often as its LoopCount says.
+func (ui *Image) finished() bool {
+	n := ui.anim.LoopCount
+	return n < 0 && ui.loops > 0 || n > 0 && ui.loops > n
+}
+
+// schedule starts a timer for showing the next frame of the animation.
+func (ui *Image) schedule(dui *DUI) {
+	anim := ui.anim
+	ui.timer = dui.After(nil, anim.Delays[ui.frame], func() {
+		ui.timer = nil
+		if anim != ui.Animation || anim != ui.anim {
+			// Animation was changed, the next draw restarts.
+			return
+		}
+		if !dui.inView(ui.viewport, ui.drawR) {
+			dui.pause(ui, func() {
+				if ui.timer == nil && anim == ui.anim {
+					ui.schedule(dui)
+				}
+			})
+			return
+		}
+		next := ui.frame + 1
+		if next == len(anim.Frames) {
+			ui.loops++
+			if ui.finished() {
+				return
+			}
+			next = 0
+		}
+		if !dui.mark(ui, false) {
+			// No longer in the UI tree.
+			return
+		}
+		ui.frame = next
+		ui.schedule(dui)
+	})
+}
+
+// resampled returns cur resampled to ui.size, reusing the resampled image from an earlier call if possible.
+func (ui *Image) resampled(dui *DUI, cur *draw.Image) *draw.Image {
+	var src interface{} = ui.Image
+	if ui.anim != nil {
+		src = ui.anim
+	}
+	if src != ui.scaleFor {
+		ui.freeScaled()
+		ui.scaleFor = src
+		ui.srcPixels = map[*draw.Image]*image.RGBA{}
+	}
+	if !ui.size.Eq(ui.scaledSize) {
+		ui.freeScaled()
+		ui.scaledSize = ui.size
+	}
+	if ui.scaled == nil {
+		ui.scaled = map[*draw.Image]*draw.Image{}
+	}
+	if scaled, ok := ui.scaled[cur]; ok {
+		return scaled
+	}
+	if ui.size.X <= 0 || ui.size.Y <= 0 {
+		return nil
+	}
+
+	pix, ok := ui.srcPixels[cur]
+	if !ok {
+		var err error
+		pix, err = readPixels(dui.Display, cur)
+		if dui.error(err, "reading image for resampling") {
+			return nil
+		}
+		ui.srcPixels[cur] = pix
+	}
+	scaled, err := loadImage(dui.Display, resample(pix, ui.size))
+	if dui.error(err, "loading resampled image") {
+		return nil
+	}
+	ui.scaled[cur] = scaled
+	ui.watch(dui)
+	return scaled
+}
+
+// watch starts a timer that frees the resampled images and source pixels once ui is no longer in the UI tree or an overlay.
+// Like timers with a UI, the check marks ui as needing a draw while it is still in the tree.
+func (ui *Image) watch(dui *DUI) {
+	if ui.sweep != nil {
+		return
+	}
+	ui.sweep = dui.Every(nil, resampleCheckInterval, func() {
+		if ui.scaled != nil && dui.mark(ui, false) {
+			return
+		}
+		ui.sweep.Stop()
+		ui.sweep = nil
+		ui.freeScaled()
+		ui.scaleFor = nil
+		ui.srcPixels = nil
+	})
+}
+
+// freeScaled frees the resampled images.
+func (ui *Image) freeScaled() {
+	for _, img := range ui.scaled {
+		img.Free()
+	}
+	ui.scaled = nil
+}
+
+// readPixels reads the pixels of img back from devdraw, by first drawing it on an image with the same pixel layout as image.RGBA.
+func readPixels(display *draw.Display, img *draw.Image) (*image.RGBA, error) {
+	tmp, err := display.AllocImage(img.R, draw.ABGR32, false, draw.Transparent)
+	if err != nil {
+		return nil, err
+	}
+	defer tmp.Free()
+	tmp.Draw(tmp.R, img, nil, img.R.Min)
+	pix := image.NewRGBA(rect(img.R.Size()))
+	_, err = tmp.Unload(tmp.R, pix.Pix)
+	if err != nil {
+		return nil, err
+	}
+	return pix, nil
+}
+
+// resample scales src to size by averaging the source pixels that each destination pixel covers.
+// This is a box filter: smooth for shrinking, and crisp pixels when enlarging, e.g. icons on hiDPI screens.
+// Both passes work on premultiplied colors, as image.RGBA stores them.
+func resample(src *image.RGBA, size image.Point) *image.RGBA {
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	xc := boxWeights(sw, size.X)
+	yc := boxWeights(sh, size.Y)
+
+	// Horizontal pass, to size.X by sh.
+	tmp := make([]float64, size.X*sh*4)
+	for y := 0; y < sh; y++ {
+		row := src.Pix[y*src.Stride:]
+		for x, ws := range xc {
+			o := (y*size.X + x) * 4
+			for _, w := range ws {
+				p := row[w.i*4:]
+				for c := 0; c < 4; c++ {
+					tmp[o+c] += w.w * float64(p[c])
+				}
+			}
+		}
+	}
+
+	// Vertical pass, to size.
+	dst := image.NewRGBA(rect(size))
+	for y, ws := range yc {
+		for x := 0; x < size.X; x++ {
+			var v [4]float64
+			for _, w := range ws {
+				o := (w.i*size.X + x) * 4
+				for c := 0; c < 4; c++ {
+					v[c] += w.w * tmp[o+c]
+				}
+			}
+			o := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[o+c] = uint8(math.Min(255, v[c]+0.5))
+			}
+		}
+	}
+	return dst
+}
+
+type boxWeight struct {
+	i int     // Index of source pixel.
+	w float64 // Fraction of the destination pixel covered by the source pixel.
+}
+
+// boxWeights returns for each of the n destination pixels the source pixels that it covers when scaling from srcn pixels.
+func boxWeights(srcn, n int) [][]boxWeight {
+	l := make([][]boxWeight, n)
+	f := float64(srcn) / float64(n)
+	for i := range l {
+		start := float64(i) * f
+		end := start + f
+		for j := int(start); j < srcn && float64(j) < end; j++ {
+			w := math.Min(end, float64(j+1)) - math.Max(start, float64(j))
+			if w > 0 {
+				l[i] = append(l[i], boxWeight{j, w / f})
+			}
+		}
+	}
+	return l
 }
 
 func (ui *Image) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
@@ -43,6 +343,10 @@ func (ui *Image) FirstFocus(dui *DUI, self *Kid) *image.Point {
 	return nil
 }
 
+func (ui *Image) LastFocus(dui *DUI, self *Kid) *image.Point {
+	return nil
+}
+
 func (ui *Image) Focus(dui *DUI, self *Kid, o UI) *image.Point {
 	if ui != o {
 		return nil
@@ -57,3 +361,8 @@ func (ui *Image) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 func (ui *Image) Print(self *Kid, indent int) {
 	PrintUI("Image", self, indent)
 }
+
+// Access returns an image node, see Accessible.
+func (ui *Image) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	return &AccessNode{Role: RoleImage}
+}
@@ -0,0 +1,80 @@
+package duit_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/duittest"
+)
+
+var (
+	red  = color.RGBA{255, 0, 0, 255}
+	blue = color.RGBA{0, 0, 255, 255}
+)
+
+func TestImageScale(t *testing.T) {
+	// Source image of 4x2 pixels, the left half red, the right half blue.
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			c := red
+			if x >= 2 {
+				c = blue
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatalf("encoding png: %s", err)
+	}
+
+	tests := []struct {
+		scale    duit.ImageScale
+		size     image.Point // Image.Size.
+		expSize  image.Point // Of the Image on the screen.
+		red      image.Point // Expected red pixel, relative to the image.
+		blue     image.Point
+		scaledTo image.Point // Size the image is scaled to, larger than expSize for ScaleFill.
+	}{
+		{duit.ScaleNone, image.Pt(20, 20), image.Pt(4, 2), image.Pt(1, 1), image.Pt(2, 1), image.Pt(4, 2)},
+		{duit.ScaleFixed, image.Pt(8, 0), image.Pt(8, 4), image.Pt(3, 3), image.Pt(4, 0), image.Pt(8, 4)},
+		{duit.ScaleFit, image.Pt(20, 20), image.Pt(20, 10), image.Pt(9, 9), image.Pt(10, 0), image.Pt(20, 10)},
+		{duit.ScaleFill, image.Pt(10, 10), image.Pt(10, 10), image.Pt(4, 9), image.Pt(5, 0), image.Pt(20, 10)},
+		{duit.ScaleStretch, image.Pt(10, 30), image.Pt(10, 30), image.Pt(4, 29), image.Pt(5, 0), image.Pt(10, 30)},
+	}
+	for _, test := range tests {
+		ui := &duit.Image{Scale: test.scale, Size: test.size}
+		dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(ui)}, &duit.DUIOpts{Dimensions: "100x100"})
+		img, err := duit.ReadImage(dt.DUI.Display, bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("reading image: %s", err)
+		}
+		ui.Image = img
+		dt.DUI.MarkLayout(ui)
+		dt.DUI.Render()
+
+		r := dt.Rect(ui)
+		if r.Size() != test.expSize {
+			t.Fatalf("scale %d: size %v, expected %v", test.scale, r.Size(), test.expSize)
+		}
+		screen := dt.Screenshot()
+		check := func(p image.Point, exp color.RGBA) {
+			t.Helper()
+			if c := screen.RGBAAt(r.Min.X+p.X, r.Min.Y+p.Y); c != exp {
+				t.Fatalf("scale %d: pixel %v is %v, expected %v", test.scale, p, c, exp)
+			}
+		}
+		check(test.red, red)
+		check(test.blue, blue)
+		// The edges of the halves are crisp, the boundary is in the middle of the scaled image.
+		mid := test.scaledTo.X/2 - (test.scaledTo.X-test.expSize.X)/2
+		check(image.Pt(mid-1, 0), red)
+		check(image.Pt(mid, test.expSize.Y-1), blue)
+		dt.Close()
+	}
+}
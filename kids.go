@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"image"
+	"sort"
 
 	"9fans.net/go/draw"
 )
@@ -15,6 +16,14 @@ type Kid struct {
 	Draw   State           // Whether UI or its children need a draw.
 	Layout State           // Whether UI or its children need a layout.
 	ID     string          // For (re)storing settings with ReadSettings and WriteSettings. If empty, no settings for the UI will be (re)stored.
+
+	// Shown in an overlay when the mouse hovers over the UI for DUI.TooltipDelay, e.g. &Label{Text: "Save file"}.
+	// Only for kids of UIs that deliver mouse events with KidsMouse.
+	Tooltip UI `json:"-"`
+
+	// Position in the tab order among sibling kids. Kids with TabIndex > 0 come first, in increasing order, followed by kids with TabIndex 0 in the order of their container.
+	// Kids with a negative TabIndex are skipped by tab traversal.
+	TabIndex int
 }
 
 // MarshalJSON writes k with an additional field Type containing the name of the UI type.
@@ -51,6 +60,21 @@ func NewKids(uis ...UI) []*Kid {
 	return kids
 }
 
+// Parent is implemented by UIs with kids, for walking the UI tree, e.g. by KidsByID, DUI.AccessKid and package duittest.
+// UIs that hold kids should implement it, UIs without kids do not.
+type Parent interface {
+	// Children returns the kids of the UI that are part of the UI tree, e.g. for Tabs only the kid with the selected UI.
+	Children() []*Kid
+}
+
+// kidsOf returns the kids of ui if it is a Parent.
+func kidsOf(ui UI) []*Kid {
+	if p, ok := ui.(Parent); ok {
+		return p.Children()
+	}
+	return nil
+}
+
 // KidsLayout is called by layout UIs before they do their own layouts.
 // KidsLayout returns whether there is any work left to do, determined by looking at self.Layout.
 // Children will be layed out if necessary. KidsLayout updates layout and draw state of self and kids.
@@ -124,6 +148,7 @@ func KidsDraw(dui *DUI, self *Kid, kids []*Kid, uiSize image.Point, bg, img *dra
 			k.Draw = Dirty
 		}
 		k.UI.Draw(dui, k, img, orig.Add(k.R.Min), mm, force)
+		dui.drawFocus(k.UI, img, k.R.Add(orig))
 		k.Draw = Clean
 	}
 	self.Draw = Clean
@@ -162,6 +187,9 @@ func KidsMouse(dui *DUI, self *Kid, kids []*Kid, m draw.Mouse, origM draw.Mouse,
 		}
 		origM.Point = origM.Point.Sub(k.R.Min)
 		m.Point = m.Point.Sub(k.R.Min)
+		if k.Tooltip != nil {
+			dui.SetTooltip(k.Tooltip)
+		}
 		r = k.UI.Mouse(dui, k, m, origM, orig.Add(k.R.Min))
 		if r.Hit == nil {
 			r.Hit = k.UI
@@ -172,22 +200,40 @@ func KidsMouse(dui *DUI, self *Kid, kids []*Kid, m draw.Mouse, origM draw.Mouse,
 	return Result{}
 }
 
-// KidsKey delivers key event key to the UI at m.
+// KidsKey delivers key event key to the UI at m. DUI sets m to the UI with keyboard focus, if any.
+// If the UI does not consume a tab or DUI.BackTab, focus moves to the next or previous kid in tab order, see Kid.TabIndex.
 // Orig is passed so UIs can calculate locations to warp the mouse to.
 func KidsKey(dui *DUI, self *Kid, kids []*Kid, key rune, m draw.Mouse, orig image.Point) (r Result) {
-	for i, k := range kids {
+	for _, k := range kids {
 		if !m.Point.In(k.R) {
 			continue
 		}
 		m.Point = m.Point.Sub(k.R.Min)
 		r = k.UI.Key(dui, k, key, m, orig.Add(k.R.Min))
-		if !r.Consumed && key == '\t' {
-			for next := i + 1; next < len(kids); next++ {
-				k := kids[next]
-				first := k.UI.FirstFocus(dui, k)
-				if first != nil {
-					p := first.Add(orig).Add(k.R.Min)
-					r.Warp = &p
+		if !r.Consumed && (key == '\t' || key == dui.BackTab) {
+			forward := key == '\t'
+			order := tabOrder(kids)
+			i, step := len(order), -1
+			if forward {
+				i, step = -1, 1
+			}
+			for j, kk := range order {
+				if kk == k {
+					i = j
+					break
+				}
+			}
+			for next := i + step; next >= 0 && next < len(order); next += step {
+				k := order[next]
+				var p *image.Point
+				if forward {
+					p = k.UI.FirstFocus(dui, k)
+				} else {
+					p = k.UI.LastFocus(dui, k)
+				}
+				if p != nil {
+					pp := p.Add(orig).Add(k.R.Min)
+					r.Warp = &pp
 					r.Consumed = true
 					r.Hit = k.UI
 					break
@@ -203,12 +249,41 @@ func KidsKey(dui *DUI, self *Kid, kids []*Kid, key rune, m draw.Mouse, orig imag
 	return Result{}
 }
 
-// KidsFirstFocus delivers the FirstFocus request to the first leaf UI, and returns the location where the mouse should warp to.
+// tabOrder returns kids in the order of tab traversal, see Kid.TabIndex.
+func tabOrder(kids []*Kid) []*Kid {
+	custom := false
+	for _, k := range kids {
+		if k.TabIndex != 0 {
+			custom = true
+			break
+		}
+	}
+	if !custom {
+		return kids
+	}
+	var l []*Kid
+	for _, k := range kids {
+		if k.TabIndex > 0 {
+			l = append(l, k)
+		}
+	}
+	sort.SliceStable(l, func(i, j int) bool {
+		return l[i].TabIndex < l[j].TabIndex
+	})
+	for _, k := range kids {
+		if k.TabIndex == 0 {
+			l = append(l, k)
+		}
+	}
+	return l
+}
+
+// KidsFirstFocus delivers the FirstFocus request to the first leaf UI in tab order, and returns the location where the mouse should warp to.
 func KidsFirstFocus(dui *DUI, self *Kid, kids []*Kid) *image.Point {
 	if len(kids) == 0 {
 		return nil
 	}
-	for _, k := range kids {
+	for _, k := range tabOrder(kids) {
 		first := k.UI.FirstFocus(dui, k)
 		if first != nil {
 			p := first.Add(k.R.Min)
@@ -218,6 +293,20 @@ func KidsFirstFocus(dui *DUI, self *Kid, kids []*Kid) *image.Point {
 	return nil
 }
 
+// KidsLastFocus is like KidsFirstFocus, but delivers the LastFocus request to the last leaf UI in tab order.
+func KidsLastFocus(dui *DUI, self *Kid, kids []*Kid) *image.Point {
+	kids = tabOrder(kids)
+	for i := len(kids) - 1; i >= 0; i-- {
+		k := kids[i]
+		last := k.UI.LastFocus(dui, k)
+		if last != nil {
+			p := last.Add(k.R.Min)
+			return &p
+		}
+	}
+	return nil
+}
+
 // KidsFocus delivers the Focus request to the first leaf UI, and returns the location where the mouse should warp to.
 func KidsFocus(dui *DUI, self *Kid, kids []*Kid, ui UI) *image.Point {
 	if len(kids) == 0 {
@@ -99,6 +99,10 @@ func (ui *Label) FirstFocus(dui *DUI, self *Kid) *image.Point {
 	return nil
 }
 
+func (ui *Label) LastFocus(dui *DUI, self *Kid) *image.Point {
+	return nil
+}
+
 func (ui *Label) Focus(dui *DUI, self *Kid, o UI) *image.Point {
 	if ui != o {
 		return nil
@@ -113,3 +117,8 @@ func (ui *Label) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 func (ui *Label) Print(self *Kid, indent int) {
 	PrintUI("Label", self, indent)
 }
+
+// Access returns a text node, see Accessible.
+func (ui *Label) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	return &AccessNode{Role: RoleText, Name: ui.Text}
+}
@@ -91,6 +91,7 @@ func (ui *List) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig
 				}
 			}
 		}
+		dui.valueChanged(ui)
 		if ui.Changed != nil {
 			e := ui.Changed(index)
 			propagateEvent(self, &r, e)
@@ -178,6 +179,9 @@ func (ui *List) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point)
 		if nindex >= 0 {
 			ui.Values[nindex].Selected = true
 			self.Draw = Dirty
+		}
+		dui.valueChanged(ui)
+		if nindex >= 0 {
 			if ui.Changed != nil {
 				e := ui.Changed(nindex)
 				propagateEvent(self, &r, e)
@@ -206,6 +210,10 @@ func (ui *List) FirstFocus(dui *DUI, self *Kid) *image.Point {
 	return &p
 }
 
+func (ui *List) LastFocus(dui *DUI, self *Kid) *image.Point {
+	return ui.FirstFocus(dui, self)
+}
+
 func (ui *List) Focus(dui *DUI, self *Kid, o UI) *image.Point {
 	if o != ui {
 		return nil
@@ -220,3 +228,35 @@ func (ui *List) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 func (ui *List) Print(self *Kid, indent int) {
 	PrintUI("List", self, indent)
 }
+
+// Access returns a list node with a listitem node for each value, see Accessible.
+func (ui *List) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	n := &AccessNode{Role: RoleList}
+	rowHeight := ui.rowHeight(dui)
+	for i, v := range ui.Values {
+		n.Children = append(n.Children, &AccessNode{
+			Role:  RoleListItem,
+			Name:  v.Text,
+			Rect:  image.Rect(0, i*rowHeight, ui.size.X, (i+1)*rowHeight).Add(orig),
+			State: AccessState{Selected: v.Selected},
+		})
+	}
+	return n
+}
+
+// KeepState copies which values of old are selected, for LoadUI.
+func (ui *List) KeepState(old UI) {
+	if o, ok := old.(*List); ok {
+		keepSelected(ui.Values, o.Values)
+	}
+}
+
+// keepSelected copies whether values are selected from old, if the number of values is the same.
+func keepSelected(values, old []*ListValue) {
+	if len(values) != len(old) {
+		return
+	}
+	for i, v := range values {
+		v.Selected = old[i].Selected
+	}
+}
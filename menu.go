@@ -0,0 +1,382 @@
+package duit
+
+import (
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+// MenuItem is an entry in a Menu.
+type MenuItem struct {
+	Text      string      // Shown in the menu.
+	Value     interface{} `json:"-"` // Auxiliary data, e.g. to recognize the item in Menu.Chosen.
+	Disabled  bool        // If set, the item is drawn with DUI.Disabled colors and cannot be chosen.
+	Separator bool        // If set, a horizontal line is drawn instead of an item. Other fields are ignored.
+	Submenu   []*MenuItem // If not nil, hovering over this item, or selecting it with the keyboard, opens a submenu with these items.
+}
+
+// Menu is a popup menu, shown in an overlay, e.g. as context menu when clicking with Button3.
+// Call Open from a Mouse function to show the menu at the mouse pointer.
+// An item can be chosen by clicking it, or by pressing a button to open the menu, dragging to the item and releasing the button.
+// A click outside the menu, or escape, closes the menu without choosing an item.
+// While the menu is open, it has keyboard focus.
+//
+// Keys:
+//
+//	arrow up, select previous item
+//	arrow down, select next item
+//	home, select first item
+//	end, select last item
+//	arrow right, open submenu
+//	arrow left, close submenu
+//	enter or space, choose item or open submenu
+type Menu struct {
+	Items     []*MenuItem          // Items shown, top to bottom.
+	Font      *draw.Font           `json:"-"` // Used for drawing items, also of submenus.
+	Chosen    func(item *MenuItem) `json:"-"` // Called after the menu was closed because item was chosen, possibly from a submenu. Use DUI.MarkLayout or DUI.MarkDraw to update UIs.
+	Dismissed func()               `json:"-"` // Called after the menu was closed without choosing an item.
+
+	overlay *Overlay
+	parent  *Menu             // Menu that opened this submenu.
+	sub     *Menu             // Open submenu, if any.
+	sel     int               // Index of selected item, -1 for none.
+	rows    []image.Rectangle // Location of each item within the menu, set by Layout.
+	at      image.Point       // Mouse location when the menu was opened.
+	armed   bool              // Whether a button release chooses an item. Set after the mouse moved or a button was pressed after opening the menu.
+	buttons int               // Mouse buttons during the previous mouse event.
+}
+
+var _ UI = &Menu{}
+
+func (ui *Menu) font(dui *DUI) *draw.Font {
+	return dui.Font(ui.Font)
+}
+
+func (ui *Menu) padding(dui *DUI) image.Point {
+	fontHeight := ui.font(dui).Height
+	return image.Pt(fontHeight/2, fontHeight/6)
+}
+
+// Open shows the menu at the mouse pointer, without selected item.
+// Typically called from a Mouse function, e.g. on a click with Button3.
+func (ui *Menu) Open(dui *DUI) {
+	ui.open(dui, dui.mouse.Point, -1)
+}
+
+// OpenAt shows the menu with its top-left corner at p, in screen coordinates, with the first item selected.
+// Useful for opening a menu from a Key function.
+func (ui *Menu) OpenAt(dui *DUI, p image.Point) {
+	ui.open(dui, p, ui.next(-1, 1))
+}
+
+func (ui *Menu) open(dui *DUI, p image.Point, sel int) {
+	ui.sub = nil
+	ui.sel = sel
+	ui.at = dui.mouse.Point
+	ui.armed = false
+	ui.buttons = dui.mouse.Buttons
+	ui.overlay = &Overlay{
+		Kid: Kid{UI: ui},
+		At:  p,
+		Dismissed: func() {
+			ui.sub = nil
+			if ui.Dismissed != nil {
+				ui.Dismissed()
+			}
+		},
+	}
+	dui.AddOverlay(ui.overlay)
+	dui.setFocus(ui, nil, false)
+}
+
+// Close removes the menu, and its submenus. Dismissed is not called.
+func (ui *Menu) Close(dui *DUI) {
+	if ui.overlay != nil {
+		dui.RemoveOverlay(ui.overlay)
+	}
+	ui.sub = nil
+}
+
+// openSub opens the submenu of the item at index i to the right of the item. If focus is set, the submenu gets keyboard focus and its first item is selected.
+func (ui *Menu) openSub(dui *DUI, i int, focus bool) {
+	sub := &Menu{
+		Items:  ui.Items[i].Submenu,
+		Font:   ui.Font,
+		parent: ui,
+		sel:    -1,
+		armed:  ui.root().armed,
+	}
+	sub.overlay = &Overlay{
+		Kid: Kid{UI: sub},
+		At:  ui.overlay.R.Min.Add(image.Pt(ui.overlay.R.Dx()-BorderSize, ui.rows[i].Min.Y-ui.rows[0].Min.Y)),
+		Dismissed: func() {
+			ui.sub = nil
+		},
+	}
+	ui.sub = sub
+	dui.AddOverlay(sub.overlay)
+	if focus {
+		sub.sel = sub.next(-1, 1)
+		dui.setFocus(sub, nil, false)
+	}
+}
+
+// closeSub removes the open submenu, if any.
+func (ui *Menu) closeSub(dui *DUI) {
+	if ui.sub != nil {
+		dui.RemoveOverlay(ui.sub.overlay)
+		ui.sub = nil
+	}
+}
+
+func (ui *Menu) root() *Menu {
+	for ui.parent != nil {
+		ui = ui.parent
+	}
+	return ui
+}
+
+// choose closes the menu and its parents, and calls Chosen of the root menu.
+func (ui *Menu) choose(dui *DUI, item *MenuItem) {
+	root := ui.root()
+	root.Close(dui)
+	if root.Chosen != nil {
+		root.Chosen(item)
+	}
+}
+
+// selectable returns whether the item at index i can be selected.
+func (ui *Menu) selectable(i int) bool {
+	return i >= 0 && i < len(ui.Items) && !ui.Items[i].Separator && !ui.Items[i].Disabled
+}
+
+// next returns the index of the first selectable item after i in direction delta, wrapping around. If there is none, -1 is returned.
+func (ui *Menu) next(i, delta int) int {
+	n := len(ui.Items)
+	for j := 0; j < n; j++ {
+		i = (i + delta + n) % n
+		if ui.selectable(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// itemAt returns the index of the selectable item at p, or -1.
+func (ui *Menu) itemAt(p image.Point) int {
+	for i, r := range ui.rows {
+		if p.In(r) && ui.selectable(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (ui *Menu) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+
+	font := ui.font(dui)
+	pad := ui.padding(dui)
+	width := 0
+	arrow := 0
+	for _, it := range ui.Items {
+		width = maximum(width, font.StringWidth(it.Text))
+		if it.Submenu != nil {
+			arrow = pad.X + font.Height/4
+		}
+	}
+	width += 2*pad.X + arrow
+
+	ui.rows = make([]image.Rectangle, len(ui.Items))
+	y := BorderSize + pad.Y
+	for i, it := range ui.Items {
+		height := font.Height + 2*pad.Y
+		if it.Separator {
+			height = font.Height / 2
+		}
+		ui.rows[i] = image.Rect(BorderSize, y, BorderSize+width, y+height)
+		y += height
+	}
+	self.R = rect(image.Pt(width+2*BorderSize, y+pad.Y+BorderSize))
+}
+
+func (ui *Menu) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	dui.debugDraw(self)
+
+	font := ui.font(dui)
+	pad := ui.padding(dui)
+	r := rect(self.R.Size()).Add(orig)
+	img.Draw(r, dui.Background, nil, image.ZP)
+	drawRoundedBorder(img, r, dui.Regular.Normal.Border)
+
+	for i, it := range ui.Items {
+		rowR := ui.rows[i].Add(orig)
+		if it.Separator {
+			y := rowR.Min.Y + rowR.Dy()/2
+			img.Draw(image.Rect(rowR.Min.X+pad.X, y, rowR.Max.X-pad.X, y+1), dui.Regular.Normal.Border, nil, image.ZP)
+			continue
+		}
+		colors := dui.Regular.Normal
+		if it.Disabled {
+			colors = dui.Disabled
+		} else if i == ui.sel {
+			colors = dui.Inverse
+			img.Draw(rowR, colors.Background, nil, image.ZP)
+		}
+		img.String(rowR.Min.Add(pad), colors.Text, image.ZP, font, it.Text)
+		if it.Submenu != nil {
+			// Triangle pointing right, drawn as columns of decreasing height.
+			size := font.Height / 4
+			p := image.Pt(rowR.Max.X-pad.X-size, rowR.Min.Y+rowR.Dy()/2)
+			for i := 0; i < size; i++ {
+				h := size - i
+				img.Draw(image.Rect(p.X+i, p.Y-h, p.X+i+1, p.Y+h), colors.Text, nil, image.ZP)
+			}
+		}
+	}
+}
+
+// Mouse handles mouse events for the menu and its submenus. While dragging, events are delivered to the menu where the button was pressed, so the location is looked up in all open menus.
+func (ui *Menu) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	r.Hit = ui
+	r.Consumed = true
+	if ui.overlay == nil {
+		return
+	}
+	root := ui.root()
+	p := m.Point.Add(orig)
+	if p != root.at || m.Buttons != 0 {
+		root.armed = true
+	}
+	released := root.buttons != 0 && m.Buttons == 0
+	root.buttons = m.Buttons
+
+	var x *Menu
+	for mm := root; mm != nil; mm = mm.sub {
+		if p.In(mm.overlay.R) {
+			x = mm
+		}
+	}
+	if x == nil {
+		if released && root.armed {
+			root.Close(dui)
+			if root.Dismissed != nil {
+				root.Dismissed()
+			}
+		}
+		return
+	}
+	r.Hit = x
+
+	i := x.itemAt(p.Sub(x.overlay.R.Min))
+	if i < 0 {
+		return
+	}
+	it := x.Items[i]
+	if i != x.sel || x.sub == nil && it.Submenu != nil {
+		x.sel = i
+		x.overlay.Draw = Dirty
+		x.closeSub(dui)
+		if it.Submenu != nil {
+			x.openSub(dui, i, false)
+		}
+	}
+	if released && root.armed && it.Submenu == nil {
+		x.choose(dui, it)
+	}
+	return
+}
+
+func (ui *Menu) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	r.Hit = ui
+	nsel := ui.sel
+	switch k {
+	case draw.KeyUp:
+		nsel = ui.next(ui.sel, -1)
+	case draw.KeyDown:
+		nsel = ui.next(ui.sel, 1)
+	case draw.KeyHome:
+		nsel = ui.next(-1, 1)
+	case draw.KeyEnd:
+		nsel = ui.next(len(ui.Items), -1)
+	case draw.KeyRight, '\n', ' ':
+		if ui.sel < 0 {
+			return
+		}
+		it := ui.Items[ui.sel]
+		if it.Submenu != nil {
+			ui.closeSub(dui)
+			ui.openSub(dui, ui.sel, true)
+		} else if k != draw.KeyRight {
+			ui.choose(dui, it)
+		}
+		r.Consumed = true
+		return
+	case draw.KeyLeft:
+		if ui.parent != nil {
+			ui.parent.closeSub(dui)
+		}
+		r.Consumed = true
+		return
+	default:
+		return
+	}
+	r.Consumed = true
+	if nsel != ui.sel {
+		ui.sel = nsel
+		ui.closeSub(dui)
+		self.Draw = Dirty
+	}
+	return
+}
+
+func (ui *Menu) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	return nil
+}
+
+func (ui *Menu) LastFocus(dui *DUI, self *Kid) *image.Point {
+	return nil
+}
+
+func (ui *Menu) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	if o != ui {
+		return nil
+	}
+	i := maximum(0, ui.sel)
+	if i >= len(ui.rows) {
+		p := self.R.Size().Div(2)
+		return &p
+	}
+	r := ui.rows[i]
+	p := r.Min.Add(r.Size().Div(2))
+	return &p
+}
+
+func (ui *Menu) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return self.Mark(o, forLayout)
+}
+
+func (ui *Menu) Print(self *Kid, indent int) {
+	PrintUI("Menu", self, indent)
+}
+
+// Access returns a menu node with a node for each item, see Accessible. Open submenus are separate overlays.
+func (ui *Menu) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	n := &AccessNode{Role: RoleMenu}
+	for i, it := range ui.Items {
+		c := &AccessNode{Role: RoleMenuItem, Name: it.Text}
+		if it.Separator {
+			c = &AccessNode{Role: RoleSeparator}
+		}
+		if i < len(ui.rows) {
+			c.Rect = ui.rows[i].Add(orig)
+		}
+		c.State = AccessState{
+			Disabled: it.Disabled,
+			Selected: i == ui.sel,
+			Expanded: i == ui.sel && ui.sub != nil,
+		}
+		n.Children = append(n.Children, c)
+	}
+	return n
+}
@@ -0,0 +1,86 @@
+package duit_test
+
+import (
+	"image"
+	"testing"
+
+	"9fans.net/go/draw"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/duittest"
+)
+
+func TestMenuKeys(t *testing.T) {
+	dt := duittest.New(t, &duit.Label{Text: "below"}, &duit.DUIOpts{Dimensions: "300x200"})
+	defer dt.Close()
+
+	var chosen *duit.MenuItem
+	dismissed := 0
+	sub2 := &duit.MenuItem{Text: "sub2"}
+	menu := &duit.Menu{
+		Items: []*duit.MenuItem{
+			{Text: "open"},
+			{Separator: true},
+			{Text: "disabled", Disabled: true},
+			{Text: "more", Submenu: []*duit.MenuItem{{Text: "sub1"}, sub2}},
+			{Text: "quit"},
+		},
+		Chosen: func(item *duit.MenuItem) {
+			chosen = item
+		},
+		Dismissed: func() {
+			dismissed++
+		},
+	}
+	open := func() {
+		chosen = nil
+		menu.OpenAt(dt.DUI, image.Pt(10, 10))
+		dt.DUI.Render()
+	}
+	overlays := func(n int) {
+		t.Helper()
+		if l := dt.DUI.Overlays(); len(l) != n {
+			t.Fatalf("%d overlays, expected %d", len(l), n)
+		}
+	}
+
+	// Arrow down skips the separator and disabled item, arrow right opens the submenu with focus.
+	open()
+	dt.Key(draw.KeyDown)
+	dt.Key(draw.KeyRight)
+	overlays(2)
+	dt.Key(draw.KeyDown)
+	dt.Key('\n')
+	overlays(0)
+	if chosen != sub2 {
+		t.Fatalf("chose %v, expected sub2", chosen)
+	}
+
+	// Arrow up wraps around, home and end select the first and last item.
+	open()
+	dt.Key(draw.KeyUp)
+	dt.Key('\n')
+	if chosen != menu.Items[4] {
+		t.Fatalf("chose %v after wrapping up, expected quit", chosen)
+	}
+	open()
+	dt.Key(draw.KeyEnd)
+	dt.Key(draw.KeyHome)
+	dt.Key(' ')
+	if chosen != menu.Items[0] {
+		t.Fatalf("chose %v after home, expected open", chosen)
+	}
+
+	// Arrow left closes the submenu, escape dismisses the menu.
+	open()
+	dt.Key(draw.KeyDown)
+	dt.Key(draw.KeyRight)
+	overlays(2)
+	dt.Key(draw.KeyLeft)
+	overlays(1)
+	dt.Key(draw.KeyEscape)
+	overlays(0)
+	if chosen != nil || dismissed != 1 {
+		t.Fatalf("chose %v, dismissed %d times, expected nothing chosen and 1 dismiss", chosen, dismissed)
+	}
+}
@@ -70,6 +70,11 @@ func (ui *Middle) FirstFocus(dui *DUI, self *Kid) (warp *image.Point) {
 	return KidsFirstFocus(dui, self, ui.kids)
 }
 
+func (ui *Middle) LastFocus(dui *DUI, self *Kid) (warp *image.Point) {
+	ui.ensure()
+	return KidsLastFocus(dui, self, ui.kids)
+}
+
 func (ui *Middle) Focus(dui *DUI, self *Kid, o UI) (warp *image.Point) {
 	ui.ensure()
 	return KidsFocus(dui, self, ui.kids, o)
@@ -80,6 +85,14 @@ func (ui *Middle) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 	return KidsMark(self, ui.kids, o, forLayout)
 }
 
+func (ui *Middle) Children() []*Kid {
+	if ui.Kid == nil {
+		return nil
+	}
+	ui.ensure()
+	return ui.kids
+}
+
 func (ui *Middle) Print(self *Kid, indent int) {
 	ui.ensure()
 	PrintUI("Middle", self, indent)
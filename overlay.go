@@ -0,0 +1,245 @@
+package duit
+
+import (
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+// Overlay is a UI drawn on top of DUI.Top, for example a menu, tooltip or list of options.
+// Overlays are shown with DUI.AddOverlay and stack: the overlay added last is on top.
+// Mouse events go to the topmost overlay under the mouse, before DUI.Top.
+// Keys go to the overlay with the UI that has keyboard focus, or to the overlay under the mouse.
+// A mouse click outside an overlay, or an escape key that was not consumed, dismisses it.
+type Overlay struct {
+	Kid                   // Holds the UI. After layout, R is the location on the screen.
+	At        image.Point // Requested location of the top-left corner, in screen coordinates. The overlay is moved to fit in the window if needed.
+	Dismissed func()      // Called after the overlay was dismissed by a click outside it or by escape. Not called for RemoveOverlay.
+
+	focus UI   // Keyboard focus before the overlay was added, restored when it is removed.
+	modal bool // For dialogs, drawn over the UI below with a translucent backdrop, see DUI.ShowDialog.
+}
+
+// AddOverlay shows o on top of the UI and of overlays already shown.
+// The overlay is layed out and drawn by the next Render.
+func (d *DUI) AddOverlay(o *Overlay) {
+	d.RemoveOverlay(o)
+	o.Layout = Dirty
+	o.Draw = Dirty
+	o.focus = d.focus
+	d.overlays = append(d.overlays, o)
+}
+
+// Overlays returns the overlays currently shown, the topmost last.
+func (d *DUI) Overlays() []*Overlay {
+	return d.overlays
+}
+
+// RemoveOverlay removes o and all overlays added after it. The UI they covered is redrawn.
+// If a removed overlay had keyboard focus, focus returns to where it was before o was added.
+func (d *DUI) RemoveOverlay(o *Overlay) {
+	for i, x := range d.overlays {
+		if x == o {
+			d.removeOverlays(i, false)
+			return
+		}
+	}
+}
+
+// removeOverlays removes the overlays starting at index i, calling their Dismissed functions if dismissed is set.
+func (d *DUI) removeOverlays(i int, dismissed bool) {
+	l := d.overlays[i:]
+	d.overlays = d.overlays[:i]
+	d.uncovered = true
+	d.lastMouseUI = nil
+	if d.mouse.Buttons != 0 {
+		// Don't deliver the rest of the click to the UI that was below the overlay.
+		d.ignoreMouse = true
+	}
+	if d.focus != nil {
+		if _, p := d.findFocus(d.focus); p == nil {
+			d.setFocus(l[0].focus, nil, d.focusVisible)
+		}
+	}
+	if !dismissed {
+		return
+	}
+	for j := len(l) - 1; j >= 0; j-- {
+		if l[j].Dismissed != nil {
+			l[j].Dismissed()
+		}
+	}
+}
+
+// layoutOverlays lays out the overlays that need it, and places them within the window.
+// If an overlay moved away from part of the screen, the UI below is redrawn.
+func (d *DUI) layoutOverlays() {
+	screen := d.Display.ScreenImage.R
+	for _, o := range d.drawnOverlays() {
+		if o.Layout == Clean {
+			continue
+		}
+		prevR := o.R
+		o.UI.Layout(d, &o.Kid, screen.Size(), o.Layout == Dirty)
+		o.Layout = Clean
+
+		size := o.R.Size()
+		p := o.At
+		if p.X+size.X > screen.Max.X {
+			p.X = screen.Max.X - size.X
+		}
+		if p.Y+size.Y > screen.Max.Y {
+			p.Y = screen.Max.Y - size.Y
+		}
+		p.X = maximum(screen.Min.X, p.X)
+		p.Y = maximum(screen.Min.Y, p.Y)
+		o.R = rect(size).Add(p)
+		if o.R != prevR {
+			o.Draw = Dirty
+			if !prevR.Empty() && !prevR.In(o.R) {
+				d.uncovered = true
+			}
+		}
+	}
+}
+
+// drawOverlays draws the overlays that need it. If force is set, e.g. because the UI below was drawn, all overlays are drawn.
+// After drawing an overlay, all overlays above it are drawn too.
+func (d *DUI) drawOverlays(force bool) {
+	img := d.Display.ScreenImage
+	for _, o := range d.drawnOverlays() {
+		if !force && o.Draw == Clean {
+			continue
+		}
+		if !o.modal {
+			img.Draw(o.R, d.Background, nil, image.ZP)
+		} else if force {
+			// Dims the UI below. Not drawn when only the dialog changed, the UI below was not drawn again, see modalDirty.
+			img.Draw(o.R, d.backdrop, nil, image.ZP)
+		}
+		force = true
+		o.Draw = Dirty
+		m := d.mouse
+		m.Point = m.Point.Sub(o.R.Min)
+		o.UI.Draw(d, &o.Kid, img, o.R.Min, m, true)
+		d.drawFocus(o.UI, img, o.R)
+		o.Draw = Clean
+	}
+}
+
+// drawnOverlays returns the overlays in drawing order, with a tooltip last.
+// Tooltips do not receive input, so they are not part of DUI.overlays.
+func (d *DUI) drawnOverlays() []*Overlay {
+	if d.tooltip == nil {
+		return d.overlays
+	}
+	return append(d.overlays[:len(d.overlays):len(d.overlays)], d.tooltip)
+}
+
+// overlayDirty returns whether any overlay needs a layout (forLayout) or draw.
+func (d *DUI) overlayDirty(forLayout bool) bool {
+	for _, o := range d.drawnOverlays() {
+		if forLayout && o.Layout != Clean || !forLayout && o.Draw != Clean {
+			return true
+		}
+	}
+	return false
+}
+
+// modalDirty returns whether the UI and overlays must be drawn entirely because of a dialog.
+// The translucent backdrop of a dialog is drawn over everything below it, so the UI below cannot be drawn partially, and drawing the backdrop again over the previous backdrop would darken it further.
+func (d *DUI) modalDirty() bool {
+	dirty := d.Top.Draw != Clean
+	for _, o := range d.overlays {
+		if o.modal && (dirty || o.Draw == Dirty) {
+			return true
+		}
+		dirty = dirty || o.Draw != Clean
+	}
+	return false
+}
+
+// layer returns the topmost overlay containing p, or DUI.Top.
+func (d *DUI) layer(p image.Point) *Kid {
+	for i := len(d.overlays) - 1; i >= 0; i-- {
+		o := d.overlays[i]
+		if p.In(o.R) {
+			return &o.Kid
+		}
+	}
+	return &d.Top
+}
+
+// deliverMouse delivers m to the layer at origM, with points translated to its origin.
+// The tooltip requested during delivery is kept in tooltipNext.
+func (d *DUI) deliverMouse(m, origM draw.Mouse) Result {
+	d.tooltipNext = nil
+	k := d.layer(origM.Point)
+	if k == &d.Top {
+		return d.Top.UI.Mouse(d, &d.Top, m, origM, image.ZP)
+	}
+	m.Point = m.Point.Sub(k.R.Min)
+	origM.Point = origM.Point.Sub(k.R.Min)
+	r := k.UI.Mouse(d, k, m, origM, k.R.Min)
+	if r.Hit == nil {
+		r.Hit = k.UI
+	}
+	return r
+}
+
+// layerOrig returns the origin of layer, DUI.Top or an overlay, in screen coordinates.
+func (d *DUI) layerOrig(layer *Kid) image.Point {
+	if layer == &d.Top {
+		return image.ZP
+	}
+	return layer.R.Min
+}
+
+// dismissBelow handles a mouse button press at p by dismissing the overlays above the one at p.
+// It returns whether the press was outside all overlays while some were dismissed, in which case the click should not be delivered.
+func (d *DUI) dismissBelow(p image.Point) (swallow bool) {
+	for i := len(d.overlays) - 1; i >= 0; i-- {
+		if p.In(d.overlays[i].R) {
+			if i+1 < len(d.overlays) {
+				d.removeOverlays(i+1, true)
+			}
+			return false
+		}
+	}
+	if len(d.overlays) == 0 {
+		return false
+	}
+	d.removeOverlays(0, true)
+	return true
+}
+
+// findFocus returns the layer containing ui, and the focus-point for ui in screen coordinates.
+// Overlays are searched before DUI.Top.
+func (d *DUI) findFocus(ui UI) (*Kid, *image.Point) {
+	for i := len(d.overlays) - 1; i >= 0; i-- {
+		o := d.overlays[i]
+		p := o.UI.Focus(d, &o.Kid, ui)
+		if p != nil {
+			pp := p.Add(o.R.Min)
+			return &o.Kid, &pp
+		}
+	}
+	p := d.Top.UI.Focus(d, &d.Top, ui)
+	if p == nil {
+		return nil, nil
+	}
+	return &d.Top, p
+}
+
+// mark marks ui in DUI.Top or one of the overlays as needing a layout or draw (forLayout false).
+func (d *DUI) mark(ui UI, forLayout bool) bool {
+	if d.Top.UI.Mark(&d.Top, ui, forLayout) {
+		return true
+	}
+	for _, o := range d.overlays {
+		if o.UI.Mark(&o.Kid, ui, forLayout) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package duit_test
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"9fans.net/go/draw"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/duittest"
+)
+
+func TestShortcutsOverlay(t *testing.T) {
+	clicks := 0
+	button := &duit.Button{Text: "below", Click: func() (e duit.Event) {
+		clicks++
+		return
+	}}
+	dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(button)}, &duit.DUIOpts{Dimensions: "1000x600"})
+	defer dt.Close()
+	before := dt.Screenshot()
+
+	// Escape dismisses the overlay, and the screen below is restored.
+	dt.Key(draw.KeyFn + 10)
+	if n := len(dt.DUI.Overlays()); n != 1 {
+		t.Fatalf("%d overlays after F10, expected 1", n)
+	}
+	if _, n := duittest.Diff(before, dt.Screenshot()); n == 0 {
+		t.Fatalf("shortcuts not drawn")
+	}
+	dt.Key(draw.KeyEscape)
+	if n := len(dt.DUI.Overlays()); n != 0 {
+		t.Fatalf("%d overlays after escape, expected 0", n)
+	}
+	if _, n := duittest.Diff(before, dt.Screenshot()); n != 0 {
+		t.Fatalf("%d pixels differ after escape", n)
+	}
+
+	// A click outside the overlay dismisses it, without clicking the button below.
+	dt.Key(draw.KeyFn + 10)
+	if o := dt.DUI.Overlays(); len(o) != 1 || o[0].R.Overlaps(dt.Rect(button)) {
+		t.Fatalf("shortcuts not shown, or over the button")
+	}
+	dt.Click(dt.Center(button))
+	if n := len(dt.DUI.Overlays()); n != 0 || clicks != 0 {
+		t.Fatalf("%d overlays and %d clicks after clicking outside, expected 0 and 0", n, clicks)
+	}
+	dt.Click(dt.Center(button))
+	if clicks != 1 {
+		t.Fatalf("%d clicks after dismissing, expected 1", clicks)
+	}
+}
+
+func TestCommandPaletteOverlay(t *testing.T) {
+	field := &duit.Field{}
+	dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(field)}, &duit.DUIOpts{Dimensions: "600x300"})
+	defer dt.Close()
+	dt.Click(dt.Center(field))
+
+	// The palette receives keys while it is shown, and escape dismisses it, returning focus to the field.
+	dt.Key(draw.KeyCmd + 'p')
+	if n := len(dt.DUI.Overlays()); n != 1 {
+		t.Fatalf("%d overlays after cmd-p, expected 1", n)
+	}
+	dt.Type("zz")
+	if field.Text != "" {
+		t.Fatalf("field text %q while palette shown, expected empty", field.Text)
+	}
+	dt.Key(draw.KeyEscape)
+	if n := len(dt.DUI.Overlays()); n != 0 {
+		t.Fatalf("%d overlays after escape, expected 0", n)
+	}
+	dt.Type("a")
+	if field.Text != "a" {
+		t.Fatalf("field text %q after palette, expected %q", field.Text, "a")
+	}
+
+	// A click outside the palette dismisses it.
+	dt.Key(draw.KeyCmd + 'p')
+	dt.Click(image.Pt(5, 295))
+	if n := len(dt.DUI.Overlays()); n != 0 {
+		t.Fatalf("%d overlays after clicking outside, expected 0", n)
+	}
+}
+
+func TestTooltipDelay(t *testing.T) {
+	label := &duit.Label{Text: "hover"}
+	kids := duit.NewKids(label)
+	kids[0].Tooltip = &duit.Label{Text: "tip"}
+	dt := duittest.New(t, &duit.Box{Kids: kids}, &duit.DUIOpts{Dimensions: "200x100"})
+	defer dt.Close()
+	const delay = 100 * time.Millisecond
+	dt.DUI.TooltipDelay = delay
+	before := dt.Screenshot()
+
+	// The tooltip is shown only after hovering for the delay.
+	start := time.Now()
+	dt.Move(dt.Center(label))
+	if _, n := duittest.Diff(before, dt.Screenshot()); n != 0 {
+		t.Fatalf("tooltip shown immediately")
+	}
+	deadline := time.After(5 * time.Second)
+	for {
+		if _, n := duittest.Diff(before, dt.Screenshot()); n != 0 {
+			break
+		}
+		select {
+		case e := <-dt.DUI.Inputs:
+			dt.DUI.Input(e)
+		case <-deadline:
+			t.Fatalf("tooltip not shown")
+		}
+	}
+	if d := time.Since(start); d < delay {
+		t.Fatalf("tooltip shown after %s, expected at least %s", d, delay)
+	}
+
+	// Tooltips are not overlays receiving input. A key hides the tooltip.
+	if n := len(dt.DUI.Overlays()); n != 0 {
+		t.Fatalf("%d overlays with tooltip, expected 0", n)
+	}
+	dt.Key('x')
+	if _, n := duittest.Diff(before, dt.Screenshot()); n != 0 {
+		t.Fatalf("tooltip not hidden by key, %d pixels differ", n)
+	}
+}
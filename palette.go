@@ -0,0 +1,273 @@
+package duit
+
+import (
+	"image"
+	"sort"
+	"strings"
+	"unicode"
+
+	"9fans.net/go/draw"
+)
+
+// Palette is a field for searching values, with a list of the matching values below it, e.g. for a command palette shown with DUI.ShowCommandPalette.
+// Values match if all characters typed in the field occur in their text in order, ignoring case. Consecutive matches and matches at the start of words rank higher, then shorter texts.
+// The best match is selected. The list scrolls if more values match than fit in Rows.
+//
+// Keys:
+//
+//	arrow up, select previous value
+//	arrow down, select next value
+//	page up, select value a page up
+//	page down, select value a page down
+//	enter, choose selected value
+type Palette struct {
+	Values      []*ListValue                 // Values to search. The Selected field is not used. After changing, mark the palette for layout.
+	Placeholder string                       // Shown in the field while it is empty.
+	Width       int                          // Width in lowdpi pixels. If 0, all available width is used.
+	Rows        int                          // Maximum number of values shown at a time. If 0, 10 values are shown.
+	Font        *draw.Font                   `json:"-"` // For drawing the text and values.
+	Chosen      func(v *ListValue) (e Event) `json:"-"` // Called when a value is chosen with enter or a click. Value of v holds its auxiliary data.
+
+	field    *Field // Created on first layout.
+	list     *List  // Matching values. Value of each ListValue is the index in Values.
+	fieldKid Kid
+	listKid  Kid // Scroll with list.
+	kids     []*Kid
+	filtered string // Text of the field the list was filtered for.
+}
+
+var _ UI = &Palette{}
+
+func (ui *Palette) ensure() {
+	if ui.field != nil {
+		return
+	}
+	ui.field = &Field{}
+	ui.list = &List{
+		Click: func(index int, m draw.Mouse) (e Event) {
+			if m.Buttons == Button1 {
+				e = ui.choose(ui.list.Values[index].Value.(int))
+			}
+			e.Consumed = true
+			return
+		},
+	}
+	ui.fieldKid = Kid{UI: ui.field}
+	ui.listKid = Kid{UI: &Scroll{Height: -1, Kid: Kid{UI: ui.list}}}
+	ui.kids = []*Kid{&ui.fieldKid, &ui.listKid}
+	ui.filter()
+}
+
+// fuzzyScore returns whether all characters of pattern occur in text in order, ignoring case, and a score for ranking the match, higher is better.
+func fuzzyScore(pattern, text string) (score int, ok bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+	prev := -2
+	j := 0
+	for i := 0; i < len(t) && j < len(p); i++ {
+		if t[i] != p[j] {
+			continue
+		}
+		score++
+		if i == prev+1 {
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(t[i-1]) && !unicode.IsDigit(t[i-1]) {
+			score += 2
+		}
+		prev = i
+		j++
+	}
+	return score, j == len(p)
+}
+
+// filter sets the values of the list to the values matching the text of the field, best match first.
+// If the text did not change and the selected value still matches, it stays selected. Otherwise the best match is selected.
+func (ui *Palette) filter() {
+	sel := -1
+	if i := ui.list.firstSelected(); i >= 0 && ui.field.Text == ui.filtered {
+		sel = ui.list.Values[i].Value.(int)
+	}
+	type match struct {
+		index int
+		score int
+	}
+	var l []match
+	for i, v := range ui.Values {
+		if score, ok := fuzzyScore(ui.field.Text, v.Text); ok {
+			l = append(l, match{i, score})
+		}
+	}
+	sort.SliceStable(l, func(i, j int) bool {
+		if l[i].score != l[j].score {
+			return l[i].score > l[j].score
+		}
+		return len(ui.Values[l[i].index].Text) < len(ui.Values[l[j].index].Text)
+	})
+	values := make([]*ListValue, len(l))
+	for i, m := range l {
+		values[i] = &ListValue{Text: ui.Values[m.index].Text, Value: m.index, Selected: m.index == sel}
+	}
+	ui.list.Values = values
+	if ui.list.firstSelected() < 0 && len(values) > 0 {
+		values[0].Selected = true
+	}
+	ui.filtered = ui.field.Text
+}
+
+// Text returns the text typed in the field.
+func (ui *Palette) Text() string {
+	ui.ensure()
+	return ui.field.Text
+}
+
+// Selected returns the value selected in the list, or nil if no value matches.
+func (ui *Palette) Selected() *ListValue {
+	ui.ensure()
+	if i := ui.list.firstSelected(); i >= 0 {
+		return ui.Values[ui.list.Values[i].Value.(int)]
+	}
+	return nil
+}
+
+// choose calls Chosen for the value at index in Values.
+func (ui *Palette) choose(index int) (e Event) {
+	if ui.Chosen != nil {
+		e = ui.Chosen(ui.Values[index])
+	}
+	return
+}
+
+// selectList selects the value at index in the list.
+func (ui *Palette) selectList(dui *DUI, index int) {
+	for i, v := range ui.list.Values {
+		v.Selected = i == index
+	}
+	// Scrolls the value into view.
+	ui.listKid.UI.Focus(dui, &ui.listKid, ui.list)
+}
+
+func (ui *Palette) rows() int {
+	if ui.Rows <= 0 {
+		return 10
+	}
+	return ui.Rows
+}
+
+func (ui *Palette) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	ui.ensure()
+	dui.debugLayout(self)
+
+	if KidsLayout(dui, self, ui.kids, force) {
+		return
+	}
+
+	if ui.Width > 0 {
+		sizeAvail.X = minimum(sizeAvail.X, dui.Scale(ui.Width))
+	}
+	ui.field.Placeholder = ui.Placeholder
+	ui.field.Font = ui.Font
+	ui.list.Font = ui.Font
+	ui.filter()
+	ui.field.Layout(dui, &ui.fieldKid, sizeAvail, true)
+	space := dui.Scale(2)
+	height := minimum(len(ui.list.Values), ui.rows()) * ui.list.rowHeight(dui)
+	height = minimum(height, maximum(0, sizeAvail.Y-ui.fieldKid.R.Dy()-space))
+	ui.listKid.UI.Layout(dui, &ui.listKid, image.Pt(sizeAvail.X, height), true)
+	ui.listKid.R = ui.listKid.R.Add(image.Pt(0, ui.fieldKid.R.Dy()+space))
+	self.R = image.Rect(0, 0, sizeAvail.X, ui.listKid.R.Max.Y)
+	ui.selectList(dui, ui.list.firstSelected())
+}
+
+func (ui *Palette) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	ui.ensure()
+	dui.debugDraw(self)
+	KidsDraw(dui, self, ui.kids, self.R.Size(), nil, img, orig, m, force)
+}
+
+func (ui *Palette) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	ui.ensure()
+	return KidsMouse(dui, self, ui.kids, m, origM, orig)
+}
+
+func (ui *Palette) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	ui.ensure()
+	switch k {
+	case draw.KeyUp, draw.KeyDown, draw.KeyPageUp, draw.KeyPageDown:
+		r.Consumed = true
+		n := len(ui.list.Values)
+		if n == 0 {
+			return
+		}
+		i := ui.list.firstSelected()
+		switch k {
+		case draw.KeyUp:
+			i--
+		case draw.KeyDown:
+			i++
+		case draw.KeyPageUp:
+			i -= ui.rows()
+		case draw.KeyPageDown:
+			i += ui.rows()
+		}
+		ui.selectList(dui, maximum(0, minimum(i, n-1)))
+		self.Draw = Dirty
+		return
+	case '\n':
+		r.Consumed = true
+		if i := ui.list.firstSelected(); i >= 0 {
+			e := ui.choose(ui.list.Values[i].Value.(int))
+			propagateEvent(self, &r, e)
+		}
+		return
+	}
+	r = KidsKey(dui, self, ui.kids, k, m, orig)
+	if ui.field.Text != ui.filtered {
+		ui.filter()
+		self.Layout = Dirty
+	}
+	return
+}
+
+func (ui *Palette) FirstFocus(dui *DUI, self *Kid) (warp *image.Point) {
+	ui.ensure()
+	return KidsFirstFocus(dui, self, ui.kids)
+}
+
+func (ui *Palette) LastFocus(dui *DUI, self *Kid) (warp *image.Point) {
+	ui.ensure()
+	return ui.FirstFocus(dui, self)
+}
+
+// Focus returns the focus point of the field if o is the palette itself.
+func (ui *Palette) Focus(dui *DUI, self *Kid, o UI) (warp *image.Point) {
+	ui.ensure()
+	if o == ui {
+		o = ui.field
+	}
+	return KidsFocus(dui, self, ui.kids, o)
+}
+
+func (ui *Palette) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	ui.ensure()
+	return KidsMark(self, ui.kids, o, forLayout)
+}
+
+func (ui *Palette) Children() []*Kid {
+	ui.ensure()
+	return ui.kids
+}
+
+func (ui *Palette) Print(self *Kid, indent int) {
+	ui.ensure()
+	PrintUI("Palette", self, indent)
+	KidsPrint(ui.kids, indent+1)
+}
+
+// Access returns an expanded combobox node with the text of the field as value, and the field and list as children, see Accessible.
+func (ui *Palette) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	ui.ensure()
+	n := &AccessNode{Role: RoleCombobox, Name: ui.Placeholder, Value: ui.field.Text, State: AccessState{Expanded: true}}
+	n.Children = dui.AccessKids(ui.kids, orig)
+	return n
+}
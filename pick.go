@@ -42,6 +42,10 @@ func (ui *Pick) FirstFocus(dui *DUI, self *Kid) (warp *image.Point) {
 	return ui.ui.FirstFocus(dui, self)
 }
 
+func (ui *Pick) LastFocus(dui *DUI, self *Kid) (warp *image.Point) {
+	return ui.ui.LastFocus(dui, self)
+}
+
 func (ui *Pick) Focus(dui *DUI, self *Kid, o UI) (warp *image.Point) {
 	return ui.ui.Focus(dui, self, o)
 }
@@ -50,9 +54,21 @@ func (ui *Pick) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 	return ui.ui.Mark(self, o, forLayout)
 }
 
+// Children returns the kids of the picked UI, which is held by the kid of Pick itself.
+func (ui *Pick) Children() []*Kid {
+	return kidsOf(ui.ui)
+}
+
 func (ui *Pick) Print(self *Kid, indent int) {
 	PrintUI("Pick", self, indent)
 	if ui.ui != nil {
 		ui.ui.Print(self, indent+1)
 	}
 }
+
+// Access returns the node for the picked UI, see Accessible.
+func (ui *Pick) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	k := *self
+	k.UI = ui.ui
+	return dui.AccessKid(&k, orig)
+}
@@ -56,6 +56,10 @@ func (ui *Place) FirstFocus(dui *DUI, self *Kid) (warp *image.Point) {
 	return KidsFirstFocus(dui, self, ui.Kids)
 }
 
+func (ui *Place) LastFocus(dui *DUI, self *Kid) (warp *image.Point) {
+	return KidsLastFocus(dui, self, ui.Kids)
+}
+
 func (ui *Place) Focus(dui *DUI, self *Kid, o UI) (warp *image.Point) {
 	return KidsFocus(dui, self, ui.Kids, o)
 }
@@ -64,6 +68,10 @@ func (ui *Place) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 	return KidsMark(self, ui.Kids, o, forLayout)
 }
 
+func (ui *Place) Children() []*Kid {
+	return ui.Kids
+}
+
 func (ui *Place) Print(self *Kid, indent int) {
 	PrintUI("Place", self, indent)
 	KidsPrint(ui.Kids, indent+1)
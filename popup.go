@@ -0,0 +1,68 @@
+package duit
+
+import (
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+// popup draws a UI with a background and border, for showing in an overlay that receives input, like the command palette.
+// Tooltips look the same, but receive no input, see tooltip.
+type popup struct {
+	Kid Kid
+}
+
+func (ui *popup) space(dui *DUI) Space {
+	return dui.ScaleSpace(SpaceXY(4, 2))
+}
+
+func (ui *popup) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	dui.debugLayout(self)
+	space := ui.space(dui)
+	border := pt(2 * BorderSize)
+	ui.Kid.UI.Layout(dui, &ui.Kid, sizeAvail.Sub(space.Size()).Sub(border), true)
+	ui.Kid.R = ui.Kid.R.Add(space.Topleft()).Add(pt(BorderSize))
+	self.R = rect(ui.Kid.R.Size().Add(space.Size()).Add(border))
+}
+
+func (ui *popup) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	dui.debugDraw(self)
+	r := rect(self.R.Size()).Add(orig)
+	img.Draw(r, dui.Placeholder.Background, nil, image.ZP)
+	drawRoundedBorder(img, r, dui.Regular.Normal.Border)
+	m.Point = m.Point.Sub(ui.Kid.R.Min)
+	ui.Kid.UI.Draw(dui, &ui.Kid, img, orig.Add(ui.Kid.R.Min), m, true)
+}
+
+func (ui *popup) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	return KidsMouse(dui, self, []*Kid{&ui.Kid}, m, origM, orig)
+}
+
+func (ui *popup) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	return KidsKey(dui, self, []*Kid{&ui.Kid}, k, m, orig)
+}
+
+func (ui *popup) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	return KidsFirstFocus(dui, self, []*Kid{&ui.Kid})
+}
+
+func (ui *popup) LastFocus(dui *DUI, self *Kid) *image.Point {
+	return KidsLastFocus(dui, self, []*Kid{&ui.Kid})
+}
+
+func (ui *popup) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	return KidsFocus(dui, self, []*Kid{&ui.Kid}, o)
+}
+
+func (ui *popup) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	return KidsMark(self, []*Kid{&ui.Kid}, o, forLayout)
+}
+
+func (ui *popup) Children() []*Kid {
+	return []*Kid{&ui.Kid}
+}
+
+func (ui *popup) Print(self *Kid, indent int) {
+	PrintUI("popup", self, indent)
+	ui.Kid.UI.Print(&ui.Kid, indent+1)
+}
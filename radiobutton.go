@@ -10,7 +10,7 @@ import (
 type Radiobutton struct {
 	Selected bool
 	Disabled bool             // If set, cannot be selected.
-	Group    RadiobuttonGroup // Other radiobuttons as part of this group. If a radiobutton is selected, others in the group are unselected.
+	Group    RadiobuttonGroup `json:"-"` // Other radiobuttons as part of this group. If a radiobutton is selected, others in the group are unselected. Set again after reading a UI from JSON.
 	Font     *draw.Font       `json:"-"` // Used only to determine size of radiobutton to draw.
 	Value    interface{}      `json:"-"` // Auxiliary data.
 
@@ -37,10 +37,7 @@ func (g RadiobuttonGroup) Selected() *Radiobutton {
 }
 
 func (ui *Radiobutton) font(dui *DUI) *draw.Font {
-	if ui.Font != nil {
-		return ui.Font
-	}
-	return dui.Display.DefaultFont
+	return dui.Font(ui.Font)
 }
 
 func (ui *Radiobutton) size(dui *DUI) image.Point {
@@ -48,21 +45,21 @@ func (ui *Radiobutton) size(dui *DUI) image.Point {
 }
 
 func (ui *Radiobutton) innerDim(dui *DUI) int {
-	return 7 * dui.Display.DefaultFont.Height / 10
+	return 7 * ui.font(dui).Height / 10
 }
 
 func (ui *Radiobutton) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 	dui.debugLayout(self)
 
 	hit := image.Point{0, 1}
-	size := pt(2*BorderSize + 7*dui.Display.DefaultFont.Height/10).Add(hit)
+	size := pt(2*BorderSize + ui.innerDim(dui)).Add(hit)
 	self.R = rect(size)
 }
 
 func (ui *Radiobutton) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
 	dui.debugDraw(self)
 
-	r := rect(pt(2*BorderSize + 7*dui.Display.DefaultFont.Height/10))
+	r := rect(pt(2*BorderSize + ui.innerDim(dui)))
 	hover := m.In(r)
 	r = r.Add(orig)
 
@@ -87,7 +84,7 @@ func (ui *Radiobutton) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Poi
 	radius := r.Dx() / 2
 	img.Arc(r.Min.Add(pt(radius)), radius, radius, 0, color, image.ZP, 0, 360)
 
-	cr := r.Inset((7 * dui.Display.DefaultFont.Height / 10) / 5)
+	cr := r.Inset(ui.innerDim(dui) / 5)
 	if ui.Selected {
 		radius = cr.Dx() / 2
 		img.FillArc(cr.Min.Add(pt(radius)), radius, radius, 0, color, image.ZP, 0, 360)
@@ -109,13 +106,19 @@ func (ui *Radiobutton) Select(dui *DUI) {
 	}
 }
 
-func (ui *Radiobutton) check(self *Kid, r *Result) {
+func (ui *Radiobutton) check(dui *DUI, self *Kid, r *Result) {
 	ui.Selected = true
 	for _, r := range ui.Group {
 		if r != ui {
 			r.Selected = false
 		}
 	}
+	dui.valueChanged(ui)
+	for _, o := range ui.Group {
+		if o != ui {
+			dui.valueChanged(o)
+		}
+	}
 	if ui.Changed != nil {
 		e := ui.Changed(ui.Value)
 		propagateEvent(self, r, e)
@@ -144,7 +147,7 @@ func (ui *Radiobutton) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse
 		ui.markDraw(dui)
 		if m.Buttons&1 == 0 {
 			r.Consumed = true
-			ui.check(self, &r)
+			ui.check(dui, self, &r)
 		}
 	}
 	ui.m = m
@@ -156,7 +159,7 @@ func (ui *Radiobutton) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image
 		r.Consumed = true
 		self.Draw = Dirty
 		ui.markDraw(dui)
-		ui.check(self, &r)
+		ui.check(dui, self, &r)
 	}
 	return
 }
@@ -166,6 +169,10 @@ func (ui *Radiobutton) FirstFocus(dui *DUI, self *Kid) *image.Point {
 	return &p
 }
 
+func (ui *Radiobutton) LastFocus(dui *DUI, self *Kid) *image.Point {
+	return ui.FirstFocus(dui, self)
+}
+
 func (ui *Radiobutton) Focus(dui *DUI, self *Kid, o UI) *image.Point {
 	if o != ui {
 		return nil
@@ -180,3 +187,15 @@ func (ui *Radiobutton) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 func (ui *Radiobutton) Print(self *Kid, indent int) {
 	PrintUI("Radiobutton", self, indent)
 }
+
+// Access returns a radio node, see Accessible.
+func (ui *Radiobutton) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	return &AccessNode{Role: RoleRadio, State: AccessState{Checked: ui.Selected, Disabled: ui.Disabled}}
+}
+
+// KeepState copies whether old is selected, for LoadUI.
+func (ui *Radiobutton) KeepState(old UI) {
+	if o, ok := old.(*Radiobutton); ok {
+		ui.Selected = o.Selected
+	}
+}
@@ -1,11 +1,16 @@
 package duit
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"image"
 	imagedraw "image/draw"
+	"image/gif"
 	"io"
+	"io/ioutil"
 	"os"
+	"time"
 
 	"9fans.net/go/draw"
 )
@@ -16,6 +21,11 @@ func ReadImage(display *draw.Display, f io.Reader) (*draw.Image, error) {
 	if err != nil {
 		return nil, fmt.Errorf("decoding image: %s", err)
 	}
+	return loadImage(display, img)
+}
+
+// loadImage copies img to a new image on display.
+func loadImage(display *draw.Display, img image.Image) (*draw.Image, error) {
 	var rgba *image.RGBA
 	switch i := img.(type) {
 	case *image.RGBA:
@@ -47,3 +57,119 @@ func ReadImagePath(display *draw.Display, path string) (*draw.Image, error) {
 	defer f.Close()
 	return ReadImage(display, f)
 }
+
+// Animation holds the frames of an animated image, for showing in an Image UI.
+type Animation struct {
+	Frames    []*draw.Image   // Complete frames, all of the same size.
+	Delays    []time.Duration // How long each frame is shown.
+	LoopCount int             // As in package image/gif: 0 loops forever, -1 shows the frames once, n > 0 shows the frames n+1 times.
+}
+
+// ReadAnimation decodes all frames of a GIF or APNG image from f for use on display.
+// Frames are composed as the GIF or APNG prescribes with its disposal (and blend) operations, so each frame can be drawn as is.
+// Other formats, and PNGs without animation, result in an animation with a single frame.
+func ReadAnimation(display *draw.Display, f io.Reader) (*Animation, error) {
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(len(pngSignature))
+	if bytes.Equal(magic, pngSignature) {
+		return readAPNG(display, br)
+	}
+	if !bytes.HasPrefix(magic, []byte("GIF8")) {
+		return readStill(display, br)
+	}
+
+	g, err := gif.DecodeAll(br)
+	if err != nil {
+		return nil, fmt.Errorf("decoding gif: %s", err)
+	}
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	for _, p := range g.Image {
+		bounds.Max.X = maximum(bounds.Max.X, p.Bounds().Max.X)
+		bounds.Max.Y = maximum(bounds.Max.Y, p.Bounds().Max.Y)
+	}
+	a := &Animation{LoopCount: g.LoopCount}
+	canvas := image.NewRGBA(bounds)
+	for i, p := range g.Image {
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		var prev *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			prev = image.NewRGBA(bounds)
+			copy(prev.Pix, canvas.Pix)
+		}
+		imagedraw.Draw(canvas, p.Bounds(), p, p.Bounds().Min, imagedraw.Over)
+		img, err := loadImage(display, canvas)
+		if err != nil {
+			a.free()
+			return nil, err
+		}
+		a.Frames = append(a.Frames, img)
+		// Like browsers, treat very short delays as the common default.
+		delay := 100 * time.Millisecond
+		if i < len(g.Delay) && g.Delay[i] > 1 {
+			delay = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+		a.Delays = append(a.Delays, delay)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			imagedraw.Draw(canvas, p.Bounds(), image.Transparent, image.ZP, imagedraw.Src)
+		case gif.DisposalPrevious:
+			canvas = prev
+		}
+	}
+	return a, nil
+}
+
+// readStill reads a single image as an animation with one frame.
+func readStill(display *draw.Display, f io.Reader) (*Animation, error) {
+	img, err := ReadImage(display, f)
+	if err != nil {
+		return nil, err
+	}
+	return &Animation{Frames: []*draw.Image{img}, Delays: []time.Duration{0}, LoopCount: -1}, nil
+}
+
+// readAPNG reads the frames of an APNG, or a PNG without animation as a single frame.
+func readAPNG(display *draw.Display, f io.Reader) (*Animation, error) {
+	buf, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading png: %s", err)
+	}
+	frames, delays, loopCount, err := decodeAPNG(buf)
+	if err != nil {
+		return nil, fmt.Errorf("decoding apng: %s", err)
+	}
+	if frames == nil {
+		return readStill(display, bytes.NewReader(buf))
+	}
+	a := &Animation{Delays: delays, LoopCount: loopCount}
+	for _, frame := range frames {
+		img, err := loadImage(display, frame)
+		if err != nil {
+			a.free()
+			return nil, err
+		}
+		a.Frames = append(a.Frames, img)
+	}
+	return a, nil
+}
+
+// ReadAnimationPath is a convenience function that opens path and calls ReadAnimation.
+func ReadAnimationPath(display *draw.Display, path string) (*Animation, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %s", path, err)
+	}
+	defer f.Close()
+	return ReadAnimation(display, f)
+}
+
+func (a *Animation) free() {
+	for _, img := range a.Frames {
+		img.Free()
+	}
+	a.Frames = nil
+}
@@ -0,0 +1,284 @@
+package duit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode"
+
+	"9fans.net/go/draw"
+)
+
+// Recording is a recorded stream of inputs of a DUI, for reproducing problems by replaying it, see DUI.StartRecording and DUI.Replay.
+//
+// A recording is stored as lines of JSON: the first line holds the Recording, without inputs, each following line holds a RecordedInput.
+// Because every input is written as soon as it is handled, a recording of a program that crashed is still usable.
+// Printable keys typed in a password Field are recorded as passwordKey, a replay types that instead.
+// Reads of the snarf buffer, e.g. for pasting, are not recorded: a replay pastes the snarf buffer at the time of replaying.
+type Recording struct {
+	Name   string          // Name of the DUI, as passed to NewDUI.
+	Start  time.Time       // Start of the recording.
+	Size   image.Point     // Window size in pixels at the start.
+	DPI    int             // Of the display.
+	Inputs []RecordedInput `json:"-"`
+}
+
+// RecordedInput is an input in a Recording.
+type RecordedInput struct {
+	Time  time.Duration // Since the start of the recording.
+	Type  InputType     // InputMouse, InputKey or InputResize.
+	Mouse draw.Mouse    // For InputMouse.
+	Key   rune          // For InputKey.
+	Size  image.Point   // For InputResize, the new window size in pixels.
+}
+
+// passwordKey is recorded instead of printable keys typed in a password Field.
+const passwordKey = '*'
+
+// recorder writes inputs handled by a DUI.
+type recorder struct {
+	w     io.Writer
+	c     io.Closer // If set, closed when recording stops.
+	start time.Time
+	err   error // First write error.
+}
+
+func (r *recorder) write(v interface{}) {
+	if r.err != nil {
+		return
+	}
+	buf, err := json.Marshal(v)
+	if err == nil {
+		_, err = r.w.Write(append(buf, '\n'))
+	}
+	if err != nil {
+		r.err = err
+		log.Printf("duit: writing recording: %s\n", err)
+	}
+}
+
+// record writes input e. For a resize, e must be recorded after it was handled, when the new window size is known.
+func (d *DUI) record(e Input) {
+	if d.recorder == nil {
+		return
+	}
+	ri := RecordedInput{Time: time.Since(d.recorder.start), Type: e.Type}
+	switch e.Type {
+	case InputMouse:
+		ri.Mouse = e.Mouse
+	case InputKey:
+		ri.Key = e.Key
+		if f, ok := d.keyUI().(*Field); ok && f.Password && unicode.IsPrint(e.Key) {
+			ri.Key = passwordKey
+		}
+	case InputResize:
+		ri.Size = d.Display.ScreenImage.R.Size()
+	default:
+		return
+	}
+	d.recorder.write(ri)
+}
+
+// StartRecording starts writing the mouse, key and resize inputs handled by Input to w, along with the window size, see Recording.
+// Recording stops with StopRecording, or when the DUI is closed.
+// An error is returned if a recording is already in progress.
+func (d *DUI) StartRecording(w io.Writer) error {
+	return d.startRecording(w, nil)
+}
+
+func (d *DUI) startRecording(w io.Writer, c io.Closer) error {
+	if d.recorder != nil {
+		return fmt.Errorf("already recording")
+	}
+	r := &recorder{w: w, c: c, start: time.Now()}
+	r.write(Recording{Name: d.name, Start: r.start, Size: d.Display.ScreenImage.R.Size(), DPI: d.Display.DPI})
+	if r.err != nil {
+		return r.err
+	}
+	d.recorder = r
+	return nil
+}
+
+// StartRecordingFile is like StartRecording, but creates a new file at path, readable only by the user, and records into it. An existing file is not overwritten.
+// If path is empty, the file is created in $DUIT_RECORD if set, otherwise in the temporary directory, named duit-<name>-<time>.rec.
+// The path of the file is returned. The file is closed by StopRecording.
+// NewDUI calls StartRecordingFile with an empty path when $DUIT_RECORD is set.
+func (d *DUI) StartRecordingFile(path string) (string, error) {
+	if d.recorder != nil {
+		return "", fmt.Errorf("already recording")
+	}
+	if path == "" {
+		dir := os.Getenv("DUIT_RECORD")
+		if dir == "" {
+			dir = os.TempDir()
+		}
+		path = filepath.Join(dir, fmt.Sprintf("duit-%s-%s.rec", d.name, time.Now().Format("20060102-150405")))
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+	err = d.startRecording(f, f)
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// Recording returns whether a recording is in progress.
+func (d *DUI) Recording() bool {
+	return d.recorder != nil
+}
+
+// StopRecording stops the recording in progress, if any, returning the first error writing the recording.
+func (d *DUI) StopRecording() error {
+	r := d.recorder
+	if r == nil {
+		return nil
+	}
+	d.recorder = nil
+	if r.c != nil {
+		if err := r.c.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+	}
+	return r.err
+}
+
+// toggleRecording starts recording to a new file, or stops the recording in progress. Bound to F11 by the command "duit.record".
+func (d *DUI) toggleRecording() {
+	if d.recorder != nil {
+		if err := d.StopRecording(); err != nil {
+			log.Printf("duit: recording: %s\n", err)
+		} else {
+			log.Println("duit: recording stopped")
+		}
+		return
+	}
+	path, err := d.StartRecordingFile("")
+	if err != nil {
+		log.Printf("duit: start recording: %s\n", err)
+		return
+	}
+	log.Printf("duit: recording to %s\n", path)
+}
+
+// ReadRecording reads a recording from r, as written by StartRecording.
+func ReadRecording(r io.Reader) (*Recording, error) {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1024*1024)
+	if !s.Scan() {
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("reading recording: empty")
+	}
+	var rec Recording
+	if err := json.Unmarshal(s.Bytes(), &rec); err != nil {
+		return nil, fmt.Errorf("reading recording: %s", err)
+	}
+	for line := 2; s.Scan(); line++ {
+		var ri RecordedInput
+		if err := json.Unmarshal(s.Bytes(), &ri); err != nil {
+			return nil, fmt.Errorf("reading recording: line %d: %s", line, err)
+		}
+		switch ri.Type {
+		case InputMouse, InputKey, InputResize:
+		default:
+			return nil, fmt.Errorf("reading recording: line %d: bad input type %d", line, ri.Type)
+		}
+		rec.Inputs = append(rec.Inputs, ri)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// ReadRecordingPath is a convenience function that opens path and calls ReadRecording.
+func ReadRecordingPath(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %s", path, err)
+	}
+	defer f.Close()
+	return ReadRecording(f)
+}
+
+// replaySize resizes the window to size, for backends that can, like the headless backend of package github.com/mjl-/duit/devdraw.
+// The backend must not signal the resize: the caller handles it right away, in order with the other replayed inputs.
+// It returns whether the window has the requested size.
+func (d *DUI) replaySize(size image.Point) bool {
+	if d.Display.ScreenImage.R.Size() == size {
+		return true
+	}
+	b, ok := d.backend.(interface{ SetSize(size image.Point) error })
+	if !ok {
+		log.Printf("duit: replay: cannot resize window to %v with this backend\n", size)
+		return false
+	}
+	if err := b.SetSize(size); err != nil {
+		log.Printf("duit: replay: resize window to %v: %s\n", size, err)
+		return false
+	}
+	return true
+}
+
+// replayInput feeds ri through Input.
+func (d *DUI) replayInput(ri RecordedInput) {
+	switch ri.Type {
+	case InputMouse:
+		d.Input(Input{Type: InputMouse, Mouse: ri.Mouse})
+	case InputKey:
+		d.Input(Input{Type: InputKey, Key: ri.Key})
+	case InputResize:
+		if d.replaySize(ri.Size) {
+			d.Input(Input{Type: InputResize})
+		}
+	}
+}
+
+// Replay feeds the inputs of rec through Input, one after the other, without delays, and returns after the last input.
+// The window is first resized to the size at the start of the recording. Resizing is only possible with backends that implement SetSize(size image.Point) error, resizing without signaling the resize, like the headless backend of package github.com/mjl-/duit/devdraw.
+// With the headless backend, replaying a recording in a test reproduces the layout and drawing of the recorded session, for regression tests.
+// Timers started with After or Every, e.g. for tooltips, do not fire during Replay, see ReplayTimed.
+// Replay must be called from the main loop.
+func (d *DUI) Replay(rec *Recording) {
+	if d.replaySize(rec.Size) {
+		d.Resize()
+	}
+	for _, ri := range rec.Inputs {
+		d.replayInput(ri)
+	}
+}
+
+// ReplayTimed is like Replay, but feeds the inputs with the delays between them as recorded, through timers, and returns immediately.
+// Done is called after the last input, it can be nil.
+func (d *DUI) ReplayTimed(rec *Recording, done func()) {
+	if d.replaySize(rec.Size) {
+		d.Resize()
+	}
+	start := time.Now()
+	var next func(i int)
+	next = func(i int) {
+		if i >= len(rec.Inputs) {
+			if done != nil {
+				done()
+			}
+			return
+		}
+		d.After(nil, rec.Inputs[i].Time-time.Since(start), func() {
+			d.replayInput(rec.Inputs[i])
+			next(i + 1)
+		})
+	}
+	next(0)
+}
@@ -0,0 +1,141 @@
+package duit_test
+
+import (
+	"bytes"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/duittest"
+)
+
+// recordUI returns a UI for recording and replaying, with a pointer to its number of button clicks.
+func recordUI() (duit.UI, *duit.Field, *int) {
+	clicks := new(int)
+	button := &duit.Button{Text: "count", Click: func() (e duit.Event) {
+		*clicks++
+		return
+	}}
+	field := &duit.Field{}
+	return &duit.Box{Kids: duit.NewKids(button, field)}, field, clicks
+}
+
+func TestReplay(t *testing.T) {
+	ui, field, clicks := recordUI()
+	dt := duittest.New(t, ui, &duit.DUIOpts{Dimensions: "200x100"})
+	defer dt.Close()
+	var buf bytes.Buffer
+	if err := dt.DUI.StartRecording(&buf); err != nil {
+		t.Fatalf("start recording: %s", err)
+	}
+	dt.Click(image.Pt(5, 5))
+	dt.Click(dt.Center(field))
+	dt.Type("ab")
+	dt.Resize(image.Pt(300, 150))
+	dt.Click(image.Pt(5, 5))
+	if err := dt.DUI.StopRecording(); err != nil {
+		t.Fatalf("stop recording: %s", err)
+	}
+	if *clicks != 2 || field.Text != "ab" {
+		t.Fatalf("recorded %d clicks and text %q, expected 2 and %q", *clicks, field.Text, "ab")
+	}
+
+	rec, err := duit.ReadRecording(&buf)
+	if err != nil {
+		t.Fatalf("read recording: %s", err)
+	}
+	if rec.Size != image.Pt(200, 100) {
+		t.Fatalf("recording starts at size %v, expected 200x100", rec.Size)
+	}
+
+	// Replay in a window of another size, which is first resized to the size of the recording.
+	nui, nfield, nclicks := recordUI()
+	ndt := duittest.New(t, nui, &duit.DUIOpts{Dimensions: "250x120"})
+	defer ndt.Close()
+	ndt.DUI.Replay(rec)
+	if *nclicks != 2 || nfield.Text != "ab" {
+		t.Fatalf("replayed %d clicks and text %q, expected 2 and %q", *nclicks, nfield.Text, "ab")
+	}
+	if _, n := duittest.Diff(dt.Screenshot(), ndt.Screenshot()); n != 0 {
+		t.Fatalf("replayed screen differs in %d pixels", n)
+	}
+
+	// Replay handled the resizes, the backend must not have signaled them as well.
+	select {
+	case e := <-ndt.DUI.Inputs:
+		t.Fatalf("input type %d pending after replay", e.Type)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRecordPassword(t *testing.T) {
+	password := &duit.Field{Password: true}
+	dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(password)}, &duit.DUIOpts{Dimensions: "200x100"})
+	defer dt.Close()
+	var buf bytes.Buffer
+	if err := dt.DUI.StartRecording(&buf); err != nil {
+		t.Fatalf("start recording: %s", err)
+	}
+	dt.Click(dt.Center(password))
+	dt.Type("ab\b")
+	if err := dt.DUI.StopRecording(); err != nil {
+		t.Fatalf("stop recording: %s", err)
+	}
+	if password.Text != "a" {
+		t.Fatalf("password %q, expected %q", password.Text, "a")
+	}
+
+	// Typed characters are replaced, other keys like backspace are kept.
+	rec, err := duit.ReadRecording(&buf)
+	if err != nil {
+		t.Fatalf("read recording: %s", err)
+	}
+	var keys []rune
+	for _, ri := range rec.Inputs {
+		if ri.Type == duit.InputKey {
+			keys = append(keys, ri.Key)
+		}
+	}
+	if string(keys) != "**\b" {
+		t.Fatalf("recorded keys %q, expected %q", string(keys), "**\b")
+	}
+}
+
+func TestStartRecordingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "duit")
+	if err != nil {
+		t.Fatalf("tempdir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	dt := duittest.New(t, &duit.Label{Text: "recorded"}, &duit.DUIOpts{Dimensions: "200x100"})
+	defer dt.Close()
+
+	// An existing file is not overwritten.
+	existing := filepath.Join(dir, "existing.rec")
+	if err := ioutil.WriteFile(existing, []byte("keep"), 0600); err != nil {
+		t.Fatalf("write file: %s", err)
+	}
+	if _, err := dt.DUI.StartRecordingFile(existing); err == nil || dt.DUI.Recording() {
+		t.Fatalf("recording to existing file started")
+	}
+	if buf, err := ioutil.ReadFile(existing); err != nil || string(buf) != "keep" {
+		t.Fatalf("existing file changed, %q, %v", buf, err)
+	}
+
+	path, err := dt.DUI.StartRecordingFile(filepath.Join(dir, "new.rec"))
+	if err != nil {
+		t.Fatalf("start recording: %s", err)
+	}
+	defer dt.DUI.StopRecording()
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat recording: %s", err)
+	}
+	if perm := fi.Mode().Perm(); perm&0077 != 0 {
+		t.Fatalf("recording permissions %o, expected only for the user", perm)
+	}
+}
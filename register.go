@@ -0,0 +1,120 @@
+package duit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+)
+
+var uiTypes = map[string]func() UI{}
+
+// RegisterUI registers a UI type for Kid.UnmarshalJSON. NewUI must return a new UI of the type, e.g. func() UI { return &MyUI{} }.
+// The type is registered under the name Kid.MarshalJSON writes in the Type field, such as "*duit.Box".
+// The built-in UIs are registered already. Registering a type again replaces the earlier registration.
+// RegisterUI is typically called from an init function, it is not safe for concurrent use.
+func RegisterUI(newUI func() UI) {
+	uiTypes[fmt.Sprintf("%T", newUI())] = newUI
+}
+
+func init() {
+	RegisterUI(func() UI { return &Box{} })
+	RegisterUI(func() UI { return &Button{} })
+	RegisterUI(func() UI { return &Buttongroup{} })
+	RegisterUI(func() UI { return &Checkbox{} })
+	RegisterUI(func() UI { return &Dropdown{} })
+	RegisterUI(func() UI {
+		ui, err := NewEdit(bytes.NewReader(nil))
+		if err != nil {
+			panic(err) // Cannot happen for a bytes.Reader.
+		}
+		return ui
+	})
+	RegisterUI(func() UI { return &Field{} })
+	RegisterUI(func() UI { return &Form{} })
+	RegisterUI(func() UI { return &Grid{} })
+	RegisterUI(func() UI { return &Gridlist{} })
+	RegisterUI(func() UI { return &Image{} })
+	RegisterUI(func() UI { return &Label{} })
+	RegisterUI(func() UI { return &List{} })
+	RegisterUI(func() UI { return &Menu{} })
+	RegisterUI(func() UI { return &Middle{} })
+	RegisterUI(func() UI { return &Palette{} })
+	RegisterUI(func() UI { return &Pick{} })
+	RegisterUI(func() UI { return &Place{} })
+	RegisterUI(func() UI { return &Radiobutton{} })
+	RegisterUI(func() UI { return &Scroll{} })
+	RegisterUI(func() UI { return &Split{} })
+	RegisterUI(func() UI { return &Tabs{} })
+	RegisterUI(func() UI { return &Validation{} })
+}
+
+// UnmarshalJSON reads k as written by MarshalJSON, creating its UI from the Type field with the constructor registered with RegisterUI.
+// Child kids are read recursively. Fields of UIs that cannot be represented in JSON, such as functions, fonts and images, are left empty.
+// Set functions like Click and Changed after reading, finding the UIs by the IDs of their kids with KidsByID.
+// Pick and Place need their function set before the first layout.
+// The layout and draw state of k are not read, k needs a layout and draw.
+func (k *Kid) UnmarshalJSON(buf []byte) error {
+	type plainKid Kid // Without methods, so reading it does not call UnmarshalJSON recursively.
+	var kid struct {
+		plainKid
+		UI   json.RawMessage
+		Type string
+	}
+	if err := json.Unmarshal(buf, &kid); err != nil {
+		return err
+	}
+	*k = Kid(kid.plainKid)
+	k.UI = nil
+	k.R = image.ZR
+	k.Layout = Dirty
+	k.Draw = Dirty
+	if kid.Type == "" || kid.Type == "<nil>" {
+		return nil
+	}
+	newUI, ok := uiTypes[kid.Type]
+	if !ok {
+		return fmt.Errorf("unknown UI type %q", kid.Type)
+	}
+	ui := newUI()
+	if len(kid.UI) > 0 {
+		if err := json.Unmarshal(kid.UI, ui); err != nil {
+			return fmt.Errorf("reading %s: %s", kid.Type, err)
+		}
+	}
+	k.UI = ui
+	return nil
+}
+
+// KidsByID returns the kids with an ID in the UI tree of k, including k itself, by ID.
+// Kids are found through Parent, and through the UIs of Tabs, so the inactive UIs of Tabs are included.
+// If an ID is used more than once, the first kid found is returned.
+func KidsByID(k *Kid) map[string]*Kid {
+	m := map[string]*Kid{}
+	seen := map[*Kid]bool{}
+	var walkKid func(k *Kid)
+	var walkUI func(ui UI)
+	walkKid = func(k *Kid) {
+		if k == nil || seen[k] {
+			return
+		}
+		seen[k] = true
+		if _, ok := m[k.ID]; k.ID != "" && !ok {
+			m[k.ID] = k
+		}
+		walkUI(k.UI)
+	}
+	walkUI = func(ui UI) {
+		for _, c := range kidsOf(ui) {
+			walkKid(c)
+		}
+		// Before its first layout, Tabs has no kids, and after, only the selected UI.
+		if t, ok := ui.(*Tabs); ok {
+			for _, x := range t.UIs {
+				walkUI(x)
+			}
+		}
+	}
+	walkKid(k)
+	return m
+}
@@ -0,0 +1,79 @@
+package duit_test
+
+import (
+	"encoding/json"
+	"image"
+	"testing"
+
+	"github.com/mjl-/duit"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	tabs := &duit.Tabs{
+		Buttongroup: &duit.Buttongroup{Texts: []string{"one", "two"}, Selected: 1},
+		UIs:         []duit.UI{&duit.Label{Text: "first"}, &duit.Field{Text: "second"}},
+	}
+	top := &duit.Kid{ID: "top", UI: &duit.Box{
+		Padding: duit.SpaceXY(4, 2),
+		Margin:  image.Pt(6, 4),
+		Kids: []*duit.Kid{
+			{ID: "label", UI: &duit.Label{Text: "hello"}},
+			{ID: "check", UI: &duit.Checkbox{Checked: true}, TabIndex: 2},
+			{ID: "tabs", UI: tabs},
+			{UI: &duit.Grid{Columns: 2, Kids: duit.NewKids(&duit.Label{Text: "a"}, &duit.Button{Text: "b"})}},
+		},
+	}}
+
+	buf, err := json.Marshal(top)
+	if err != nil {
+		t.Fatalf("marshal: %s", err)
+	}
+	var k duit.Kid
+	if err := json.Unmarshal(buf, &k); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if k.Layout != duit.Dirty || k.Draw != duit.Dirty {
+		t.Fatalf("read kid not dirty")
+	}
+	nbuf, err := json.Marshal(&k)
+	if err != nil {
+		t.Fatalf("marshal read kid: %s", err)
+	}
+	if string(nbuf) != string(buf) {
+		t.Fatalf("round trip changed json\nbefore %s\nafter  %s", buf, nbuf)
+	}
+
+	ids := duit.KidsByID(&k)
+	if check, ok := ids["check"].UI.(*duit.Checkbox); !ok || !check.Checked || ids["check"].TabIndex != 2 {
+		t.Fatalf("checkbox not read back, got %#v", ids["check"])
+	}
+	ntabs, ok := ids["tabs"].UI.(*duit.Tabs)
+	if !ok || len(ntabs.UIs) != 2 || ntabs.Buttongroup.Selected != 1 {
+		t.Fatalf("tabs not read back, got %#v", ids["tabs"].UI)
+	}
+	if f, ok := ntabs.UIs[1].(*duit.Field); !ok || f.Text != "second" {
+		t.Fatalf("ui of tab not read back, got %#v", ntabs.UIs[1])
+	}
+}
+
+// custom is a UI for registering with RegisterUI.
+type custom struct {
+	duit.Label
+	Extra string
+}
+
+func TestRegisterUI(t *testing.T) {
+	buf := []byte(`{"Type": "*duit_test.custom", "UI": {"Text": "x", "Extra": "y"}}`)
+	var k duit.Kid
+	if err := json.Unmarshal(buf, &k); err == nil {
+		t.Fatalf("unregistered type accepted")
+	}
+
+	duit.RegisterUI(func() duit.UI { return &custom{} })
+	if err := json.Unmarshal(buf, &k); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if c, ok := k.UI.(*custom); !ok || c.Text != "x" || c.Extra != "y" {
+		t.Fatalf("custom ui not read, got %#v", k.UI)
+	}
+}
@@ -0,0 +1,141 @@
+package duit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+)
+
+// RunOpts holds optional hooks for Run.
+type RunOpts struct {
+	Chans []RunChan             // Application channels to receive from in the main loop, e.g. for results of background work.
+	Error func(err error) error // Called with errors received on DUI.Error, e.g. from UIs that could not allocate an image, and with errors from devdraw, which Input treats as fatal. A non-nil return value stops Run. If nil, errors are logged.
+}
+
+// RunChan is a channel Run receives from, with a function to handle the values.
+type RunChan struct {
+	Chan    interface{}                        // Channel to receive from, e.g. a chan string.
+	Receive func(v interface{}, ok bool) error // Called from the main loop with each received value. Ok is false after the channel is closed, it is not received from after that. A non-nil return value stops Run. The UI is rendered after Receive returns.
+}
+
+// Run runs the main loop: it renders the UI, then handles inputs with Input, until the window is closed or ctx is canceled.
+// Run returns nil when the window is closed, by the user or with Close, and when DUI.Error was closed.
+// When ctx is canceled or a hook returns an error, Run closes the DUI and returns ctx.Err() or the error of the hook.
+// Opts can be nil.
+//
+// Run replaces the select loop over DUI.Inputs and DUI.Error that programs would otherwise write themselves:
+//
+//	err := dui.Run(context.Background(), &duit.RunOpts{
+//		Chans: []duit.RunChan{
+//			{
+//				Chan: results,
+//				Receive: func(v interface{}, ok bool) error {
+//					label.Text = v.(string)
+//					dui.MarkLayout(label)
+//					return nil
+//				},
+//			},
+//		},
+//	})
+func (d *DUI) Run(ctx context.Context, opts *RunOpts) error {
+	if opts == nil {
+		opts = &RunOpts{}
+	}
+	const (
+		caseDone = iota
+		caseInput
+		caseError
+		caseChans
+	)
+	chans, err := opts.chanCases()
+	if err != nil {
+		return err
+	}
+	cases := append([]reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(d.Inputs)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(d.Error)},
+	}, chans...)
+
+	stop := func(err error) error {
+		if !d.closed {
+			d.Close()
+		}
+		return err
+	}
+
+	d.Render()
+	for {
+		i, v, ok := reflect.Select(cases)
+		switch i {
+		case caseDone:
+			return stop(ctx.Err())
+
+		case caseInput:
+			e := v.Interface().(Input)
+			if e.Type == InputError {
+				if err := opts.error(e.Error); err != nil {
+					return stop(err)
+				}
+				continue
+			}
+			d.Input(e)
+			if d.closed {
+				return nil
+			}
+
+		case caseError:
+			if !ok {
+				// Window was closed.
+				return nil
+			}
+			if err := opts.error(v.Interface().(error)); err != nil {
+				return stop(err)
+			}
+
+		default:
+			if err := opts.receive(i-caseChans, &cases[i], v, ok); err != nil {
+				return stop(err)
+			}
+			if d.closed {
+				return nil
+			}
+			d.Render()
+		}
+	}
+}
+
+// chanCases returns select cases for receiving from the channels of opts, in order.
+func (opts *RunOpts) chanCases() ([]reflect.SelectCase, error) {
+	var cases []reflect.SelectCase
+	for i, c := range opts.Chans {
+		v := reflect.ValueOf(c.Chan)
+		if v.Kind() != reflect.Chan || v.Type().ChanDir()&reflect.RecvDir == 0 || c.Receive == nil {
+			return nil, fmt.Errorf("run: chan %d: must be a channel to receive from, with Receive function", i)
+		}
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: v})
+	}
+	return cases, nil
+}
+
+// receive calls the Receive function of channel i with value v received through select case c.
+// If the channel was closed, c is disabled.
+func (opts *RunOpts) receive(i int, c *reflect.SelectCase, v reflect.Value, ok bool) error {
+	var x interface{}
+	if ok {
+		x = v.Interface()
+	} else {
+		c.Chan = reflect.Value{}
+	}
+	return opts.Chans[i].Receive(x, ok)
+}
+
+// error passes err to the Error hook, or logs it if there is no hook.
+func (opts *RunOpts) error(err error) error {
+	if opts.Error == nil {
+		log.Printf("duit: %s\n", err)
+		return nil
+	}
+	return opts.Error(err)
+}
@@ -0,0 +1,60 @@
+package duit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/duittest"
+)
+
+// Run closes the DUI when it stops, so the tests below do not close the Tester.
+
+func TestRunCancel(t *testing.T) {
+	dt := duittest.New(t, &duit.Label{Text: "run"}, &duit.DUIOpts{Dimensions: "200x100"})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := dt.DUI.Run(ctx, nil); err != context.DeadlineExceeded {
+		t.Fatalf("run returned %v, expected %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRunError(t *testing.T) {
+	dt := duittest.New(t, &duit.Label{Text: "run"}, &duit.DUIOpts{Dimensions: "200x100"})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Errors from devdraw reach the hook, instead of terminating the program. The hook decides whether Run stops.
+	stopErr := errors.New("stop")
+	var errs []error
+	go func() {
+		dt.DUI.Inputs <- duit.Input{Type: duit.InputError, Error: errors.New("first")}
+		dt.DUI.Error <- errors.New("second")
+	}()
+	err := dt.DUI.Run(ctx, &duit.RunOpts{
+		Error: func(err error) error {
+			errs = append(errs, err)
+			if len(errs) == 2 {
+				return stopErr
+			}
+			return nil
+		},
+	})
+	if err != stopErr || len(errs) != 2 || errs[0].Error() != "first" || errs[1].Error() != "second" {
+		t.Fatalf("run returned %v after errors %v, expected %v after first and second", err, errs, stopErr)
+	}
+}
+
+func TestRunClose(t *testing.T) {
+	dt := duittest.New(t, &duit.Label{Text: "run"}, &duit.DUIOpts{Dimensions: "200x100"})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() {
+		dt.DUI.Call <- dt.DUI.Close
+	}()
+	if err := dt.DUI.Run(ctx, nil); err != nil {
+		t.Fatalf("run returned %v after close, expected nil", err)
+	}
+}
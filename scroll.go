@@ -20,6 +20,8 @@ type Scroll struct {
 	img           *draw.Image // for child to draw on
 	scrollbarSize int
 	lastMouseUI   UI
+	viewport      *Scroll         // Scroll this scroll was drawn in, if any.
+	viewR         image.Rectangle // Location of childR at the last draw, on the image of viewport or the screen.
 }
 
 var _ UI = &Scroll{}
@@ -61,6 +63,8 @@ func (ui *Scroll) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool)
 		ui.r.Max.Y = kY
 		ui.childR.Max.Y = kY
 	}
+	// The child may have shrunk, or the offset was kept from an earlier UI.
+	ui.scroll(0)
 	self.R = rect(ui.r.Size())
 }
 
@@ -77,6 +81,8 @@ func (ui *Scroll) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m
 	}
 
 	ui.scroll(0)
+	ui.viewport = dui.viewport
+	ui.viewR = ui.childR.Add(orig)
 	barHover := m.In(ui.barR)
 
 	bg := dui.ScrollBGNormal
@@ -127,7 +133,10 @@ func (ui *Scroll) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m
 		if force {
 			ui.Kid.Draw = Dirty
 		}
+		dui.viewport = ui
 		ui.Kid.UI.Draw(dui, &ui.Kid, ui.img, image.ZP, m, ui.Kid.Draw == Dirty)
+		dui.viewport = ui.viewport
+		dui.drawFocus(ui.Kid.UI, ui.img, ui.Kid.R)
 		ui.Kid.Draw = Clean
 	}
 	img.Draw(ui.childR.Add(orig), ui.img, nil, image.Pt(0, ui.offset))
@@ -266,18 +275,44 @@ func (ui *Scroll) warpScroll(dui *DUI, self *Kid, warp *image.Point, orig image.
 			dui.MarkDraw(ui)
 		}
 	}
+	ui.warpPoint(warp, orig)
+}
+
+// warpPoint translates warp from the image the child is drawn on to the coordinates of orig, at the current offset.
+func (ui *Scroll) warpPoint(warp *image.Point, orig image.Point) {
 	warp.Y -= ui.offset
 	warp.X += orig.X + ui.scrollbarSize
 	warp.Y += orig.Y
 }
 
+// inView returns whether part of r, on the image the child is drawn on, is currently visible, also in the scrolls this scroll is in.
+func (ui *Scroll) inView(r image.Rectangle) bool {
+	visible := rect(ui.childR.Size()).Add(image.Pt(0, ui.offset))
+	r = r.Intersect(visible)
+	if r.Empty() {
+		return false
+	}
+	if ui.viewport == nil {
+		return true
+	}
+	return ui.viewport.inView(r.Sub(visible.Min).Add(ui.viewR.Min))
+}
+
 func (ui *Scroll) _focus(dui *DUI, p *image.Point) *image.Point {
 	if p == nil {
 		return nil
 	}
 	pp := p.Add(ui.childR.Min)
 	p = &pp
-	ui.warpScroll(dui, nil, p, image.ZP)
+	if !dui.focusNoScroll {
+		ui.warpScroll(dui, nil, p, image.ZP)
+		return p
+	}
+	if p.Y < ui.offset || p.Y > ui.offset+ui.r.Dy() {
+		// Scrolled out of view by the user. Keys go to the scroll until the UI is back in view, not to the UI that is now at its location.
+		return ui.Focus(dui, nil, ui)
+	}
+	ui.warpPoint(p, image.ZP)
 	return p
 }
 
@@ -286,6 +321,11 @@ func (ui *Scroll) FirstFocus(dui *DUI, self *Kid) *image.Point {
 	return ui._focus(dui, p)
 }
 
+func (ui *Scroll) LastFocus(dui *DUI, self *Kid) *image.Point {
+	p := ui.Kid.UI.LastFocus(dui, &ui.Kid)
+	return ui._focus(dui, p)
+}
+
 func (ui *Scroll) Focus(dui *DUI, self *Kid, o UI) *image.Point {
 	if o == ui {
 		p := image.Pt(minimum(ui.scrollbarSize/2, ui.r.Dx()), minimum(ui.scrollbarSize/2, ui.r.Dy()))
@@ -314,8 +354,28 @@ func (ui *Scroll) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 	return
 }
 
+func (ui *Scroll) Children() []*Kid {
+	return []*Kid{&ui.Kid}
+}
+
 func (ui *Scroll) Print(self *Kid, indent int) {
 	what := fmt.Sprintf("Scroll offset=%d childR=%v", ui.offset, ui.childR)
 	PrintUI(what, self, indent)
 	ui.Kid.UI.Print(&ui.Kid, indent+1)
 }
+
+// Access returns a scroll node with its visible part as Rect, and the node of its kid, see Accessible.
+func (ui *Scroll) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	n := &AccessNode{Role: RoleScroll, Rect: ui.r.Add(orig)}
+	if ui.Kid.UI != nil {
+		n.Children = []*AccessNode{dui.AccessKid(&ui.Kid, orig.Add(ui.childR.Min).Sub(image.Pt(0, ui.offset)))}
+	}
+	return n
+}
+
+// KeepState copies the scroll offset of old, for LoadUI.
+func (ui *Scroll) KeepState(old UI) {
+	if o, ok := old.(*Scroll); ok {
+		ui.offset = o.offset
+	}
+}
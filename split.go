@@ -220,6 +220,10 @@ func (ui *Split) FirstFocus(dui *DUI, self *Kid) *image.Point {
 	return KidsFirstFocus(dui, self, ui.Kids)
 }
 
+func (ui *Split) LastFocus(dui *DUI, self *Kid) *image.Point {
+	return KidsLastFocus(dui, self, ui.Kids)
+}
+
 func (ui *Split) Focus(dui *DUI, self *Kid, o UI) *image.Point {
 	return KidsFocus(dui, self, ui.Kids, o)
 }
@@ -228,6 +232,10 @@ func (ui *Split) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
 	return KidsMark(self, ui.Kids, o, forLayout)
 }
 
+func (ui *Split) Children() []*Kid {
+	return ui.Kids
+}
+
 func (ui *Split) Print(self *Kid, indent int) {
 	how := "horizontal"
 	if ui.Vertical {
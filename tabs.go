@@ -1,6 +1,7 @@
 package duit
 
 import (
+	"encoding/json"
 	"fmt"
 	"image"
 )
@@ -39,8 +40,46 @@ func (ui *Tabs) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
 	ui.Box.Layout(dui, self, sizeAvail, force)
 }
 
+// MarshalJSON writes the UIs as kids, so their types are included. The kids of Box are not written, they are created from Buttongroup and UIs.
+func (ui *Tabs) MarshalJSON() ([]byte, error) {
+	box := ui.Box
+	box.Kids = nil
+	return json.Marshal(struct {
+		Buttongroup *Buttongroup
+		UIs         []*Kid
+		Box
+	}{ui.Buttongroup, NewKids(ui.UIs...), box})
+}
+
+// UnmarshalJSON reads tabs as written by MarshalJSON.
+func (ui *Tabs) UnmarshalJSON(buf []byte) error {
+	var tabs struct {
+		Buttongroup *Buttongroup
+		UIs         []*Kid
+		*Box
+	}
+	tabs.Box = &ui.Box
+	if err := json.Unmarshal(buf, &tabs); err != nil {
+		return err
+	}
+	ui.Buttongroup = tabs.Buttongroup
+	ui.UIs = make([]UI, len(tabs.UIs))
+	for i, k := range tabs.UIs {
+		ui.UIs[i] = k.UI
+	}
+	ui.Box.Kids = nil
+	return nil
+}
+
 func (ui *Tabs) Print(self *Kid, indent int) {
 	PrintUI("Tabs", self, indent)
 	PrintUI("Box", self, indent+1)
 	KidsPrint(ui.Box.Kids, indent+2)
 }
+
+// KeepState copies the selected tab of old, for LoadUI.
+func (ui *Tabs) KeepState(old UI) {
+	if o, ok := old.(*Tabs); ok && o.Buttongroup != nil && ui.Buttongroup != nil {
+		ui.Buttongroup.KeepState(o.Buttongroup)
+	}
+}
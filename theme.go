@@ -0,0 +1,313 @@
+package duit
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"9fans.net/go/draw"
+)
+
+// ThemeColor is a color in a Theme. In JSON, it is written as "#rrggbb" or, with alpha, as "#rrggbbaa".
+type ThemeColor draw.Color
+
+// MarshalJSON writes c as "#rrggbb", or "#rrggbbaa" if c is not opaque.
+func (c ThemeColor) MarshalJSON() ([]byte, error) {
+	if c&0xff == 0xff {
+		return json.Marshal(fmt.Sprintf("#%06x", uint32(c)>>8))
+	}
+	return json.Marshal(fmt.Sprintf("#%08x", uint32(c)))
+}
+
+// UnmarshalJSON reads a color written by MarshalJSON.
+func (c *ThemeColor) UnmarshalJSON(buf []byte) error {
+	var s string
+	if err := json.Unmarshal(buf, &s); err != nil {
+		return err
+	}
+	t := strings.TrimPrefix(s, "#")
+	if t == s || len(t) != 6 && len(t) != 8 {
+		return fmt.Errorf("bad color %q, must be #rrggbb or #rrggbbaa", s)
+	}
+	if len(t) == 6 {
+		t += "ff"
+	}
+	v, err := strconv.ParseUint(t, 16, 32)
+	if err != nil {
+		return fmt.Errorf("bad color %q: %s", s, err)
+	}
+	*c = ThemeColor(v)
+	return nil
+}
+
+// ThemeColors are the colors for Colors.
+type ThemeColors struct {
+	Text, Background, Border ThemeColor
+}
+
+// ThemeColorset are the colors for a Colorset.
+type ThemeColorset struct {
+	Normal, Hover ThemeColors
+}
+
+// Theme holds the colors of a DUI, see the fields of DUI with the same names. Apply a theme with DUI.SetTheme.
+// Themes can be read from JSON with ReadTheme. Users can set a theme for an application in $APPDATA/duit/<name>/theme.json, see NewDUI.
+type Theme struct {
+	// Theme that fields missing in the JSON are taken from, for ReadTheme: "light" (default) or "dark".
+	// Makes it easy to change a few colors of a built-in theme.
+	Base string `json:",omitempty"`
+
+	Disabled,
+	Inverse,
+	Selection,
+	SelectionHover,
+	Placeholder,
+	Striped ThemeColors
+
+	Regular,
+	Primary,
+	Secondary,
+	Success,
+	Danger ThemeColorset
+
+	Background ThemeColor
+
+	ScrollBGNormal,
+	ScrollBGHover,
+	ScrollVisibleNormal,
+	ScrollVisibleHover ThemeColor
+
+	Gutter    ThemeColor
+	FocusRing ThemeColor
+
+	CommandMode,
+	VisualMode ThemeColor
+}
+
+var (
+	// LightTheme is the default theme.
+	LightTheme = &Theme{
+		Disabled:       ThemeColors{Text: 0x888888ff, Background: 0xf0f0f0ff, Border: 0xe0e0e0ff},
+		Inverse:        ThemeColors{Text: 0xeeeeeeff, Background: 0x3272dcff, Border: 0x666666ff},
+		Selection:      ThemeColors{Text: 0xeeeeeeff, Background: 0xbbbbbbff, Border: 0x666666ff},
+		SelectionHover: ThemeColors{Text: 0xeeeeeeff, Background: 0x3272dcff, Border: 0x666666ff},
+		Placeholder:    ThemeColors{Text: 0xaaaaaaff, Background: 0xf8f8f8ff, Border: 0xbbbbbbff},
+		Striped:        ThemeColors{Text: 0x333333ff, Background: 0xf2f2f2ff, Border: 0xbbbbbbff},
+
+		Regular: ThemeColorset{
+			Normal: ThemeColors{Text: 0x333333ff, Background: 0xf8f8f8ff, Border: 0xbbbbbbff},
+			Hover:  ThemeColors{Text: 0x222222ff, Background: 0xfafafaff, Border: 0x3272dcff},
+		},
+		Primary: ThemeColorset{
+			Normal: ThemeColors{Text: 0xffffffff, Background: 0x007bffff, Border: 0x007bffff},
+			Hover:  ThemeColors{Text: 0xffffffff, Background: 0x0062ccff, Border: 0x0062ccff},
+		},
+		Secondary: ThemeColorset{
+			Normal: ThemeColors{Text: 0xffffffff, Background: 0x868e96ff, Border: 0x868e96ff},
+			Hover:  ThemeColors{Text: 0xffffffff, Background: 0x727b84ff, Border: 0x6c757dff},
+		},
+		Success: ThemeColorset{
+			Normal: ThemeColors{Text: 0xffffffff, Background: 0x28a745ff, Border: 0x28a745ff},
+			Hover:  ThemeColors{Text: 0xffffffff, Background: 0x218838ff, Border: 0x1e7e34ff},
+		},
+		Danger: ThemeColorset{
+			Normal: ThemeColors{Text: 0xffffffff, Background: 0xdc3545ff, Border: 0xdc3545ff},
+			Hover:  ThemeColors{Text: 0xffffffff, Background: 0xc82333ff, Border: 0xbd2130ff},
+		},
+
+		Background: 0xfcfcfcff,
+
+		ScrollBGNormal:      0xf4f4f4ff,
+		ScrollBGHover:       0xf0f0f0ff,
+		ScrollVisibleNormal: 0xbbbbbbff,
+		ScrollVisibleHover:  0x999999ff,
+
+		Gutter:    0xbbbbbbff,
+		FocusRing: 0x3272dcff,
+
+		CommandMode: 0x3272dcff,
+		VisualMode:  0x5cb85cff,
+	}
+
+	// DarkTheme has light text on dark backgrounds.
+	DarkTheme = &Theme{
+		Disabled:       ThemeColors{Text: 0x777777ff, Background: 0x262626ff, Border: 0x3a3a3aff},
+		Inverse:        ThemeColors{Text: 0xffffffff, Background: 0x3272dcff, Border: 0x888888ff},
+		Selection:      ThemeColors{Text: 0xeeeeeeff, Background: 0x4a4a4aff, Border: 0x888888ff},
+		SelectionHover: ThemeColors{Text: 0xffffffff, Background: 0x3272dcff, Border: 0x888888ff},
+		Placeholder:    ThemeColors{Text: 0x777777ff, Background: 0x2b2b2bff, Border: 0x555555ff},
+		Striped:        ThemeColors{Text: 0xddddddff, Background: 0x252525ff, Border: 0x555555ff},
+
+		Regular: ThemeColorset{
+			Normal: ThemeColors{Text: 0xddddddff, Background: 0x2b2b2bff, Border: 0x555555ff},
+			Hover:  ThemeColors{Text: 0xeeeeeeff, Background: 0x333333ff, Border: 0x4a90e2ff},
+		},
+		Primary: ThemeColorset{
+			Normal: ThemeColors{Text: 0xffffffff, Background: 0x1f6fd1ff, Border: 0x1f6fd1ff},
+			Hover:  ThemeColors{Text: 0xffffffff, Background: 0x1a5fb4ff, Border: 0x1a5fb4ff},
+		},
+		Secondary: ThemeColorset{
+			Normal: ThemeColors{Text: 0xffffffff, Background: 0x5a6268ff, Border: 0x5a6268ff},
+			Hover:  ThemeColors{Text: 0xffffffff, Background: 0x4e555bff, Border: 0x484e53ff},
+		},
+		Success: ThemeColorset{
+			Normal: ThemeColors{Text: 0xffffffff, Background: 0x2e9e4fff, Border: 0x2e9e4fff},
+			Hover:  ThemeColors{Text: 0xffffffff, Background: 0x268a43ff, Border: 0x23803eff},
+		},
+		Danger: ThemeColorset{
+			Normal: ThemeColors{Text: 0xffffffff, Background: 0xd9404fff, Border: 0xd9404fff},
+			Hover:  ThemeColors{Text: 0xffffffff, Background: 0xbf2f3eff, Border: 0xb32b39ff},
+		},
+
+		Background: 0x1e1e1eff,
+
+		ScrollBGNormal:      0x2a2a2aff,
+		ScrollBGHover:       0x303030ff,
+		ScrollVisibleNormal: 0x555555ff,
+		ScrollVisibleHover:  0x777777ff,
+
+		Gutter:    0x555555ff,
+		FocusRing: 0x4a90e2ff,
+
+		CommandMode: 0x4a90e2ff,
+		VisualMode:  0x5cb85cff,
+	}
+)
+
+// ReadTheme reads a theme in JSON from r.
+// Colors missing in the JSON are taken from the theme named by Base.
+func ReadTheme(r io.Reader) (*Theme, error) {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var base struct{ Base string }
+	if err := json.Unmarshal(buf, &base); err != nil {
+		return nil, fmt.Errorf("reading theme: %s", err)
+	}
+	var t Theme
+	switch base.Base {
+	case "", "light":
+		t = *LightTheme
+	case "dark":
+		t = *DarkTheme
+	default:
+		return nil, fmt.Errorf("reading theme: unknown base theme %q", base.Base)
+	}
+	if err := json.Unmarshal(buf, &t); err != nil {
+		return nil, fmt.Errorf("reading theme: %s", err)
+	}
+	return &t, nil
+}
+
+// ReadThemePath is a convenience function that opens path and calls ReadTheme.
+func ReadThemePath(path string) (*Theme, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %s", path, err)
+	}
+	defer f.Close()
+	return ReadTheme(f)
+}
+
+// SetTheme allocates images for the colors of t, sets them in d, and marks the UI and overlays as needing a draw.
+// The images of the previous theme are freed. UIs must not hold on to them, e.g. a Box with Background set to DUI.Gutter must have its Background set again after SetTheme.
+// UIs that follow the theme refer to the colors through DUI at draw time, like the UIs of this package.
+func (d *DUI) SetTheme(t *Theme) error {
+	var err error
+	var allocated []*draw.Image
+	color := func(c ThemeColor) *draw.Image {
+		if err != nil {
+			return nil
+		}
+		var img *draw.Image
+		img, err = d.Display.AllocImage(image.Rect(0, 0, 1, 1), draw.ARGB32, true, draw.Color(c))
+		allocated = append(allocated, img)
+		return img
+	}
+	colors := func(c ThemeColors) Colors {
+		return Colors{
+			Text:       color(c.Text),
+			Background: color(c.Background),
+			Border:     color(c.Border),
+		}
+	}
+	colorset := func(c ThemeColorset) Colorset {
+		return Colorset{
+			Normal: colors(c.Normal),
+			Hover:  colors(c.Hover),
+		}
+	}
+
+	disabled := colors(t.Disabled)
+	inverse := colors(t.Inverse)
+	selection := colors(t.Selection)
+	selectionHover := colors(t.SelectionHover)
+	placeholder := colors(t.Placeholder)
+	striped := colors(t.Striped)
+	regular := colorset(t.Regular)
+	primary := colorset(t.Primary)
+	secondary := colorset(t.Secondary)
+	success := colorset(t.Success)
+	danger := colorset(t.Danger)
+	background := color(t.Background)
+	scrollBGNormal := color(t.ScrollBGNormal)
+	scrollBGHover := color(t.ScrollBGHover)
+	scrollVisibleNormal := color(t.ScrollVisibleNormal)
+	scrollVisibleHover := color(t.ScrollVisibleHover)
+	gutter := color(t.Gutter)
+	focusRing := color(t.FocusRing)
+	commandMode := color(t.CommandMode)
+	visualMode := color(t.VisualMode)
+	if err != nil {
+		for _, img := range allocated {
+			if img != nil {
+				img.Free()
+			}
+		}
+		return fmt.Errorf("allocimage: %s", err)
+	}
+
+	d.Disabled = disabled
+	d.Inverse = inverse
+	d.Selection = selection
+	d.SelectionHover = selectionHover
+	d.Placeholder = placeholder
+	d.Striped = striped
+	d.Regular = regular
+	d.Primary = primary
+	d.Secondary = secondary
+	d.Success = success
+	d.Danger = danger
+	d.BackgroundColor = draw.Color(t.Background)
+	d.Background = background
+	d.ScrollBGNormal = scrollBGNormal
+	d.ScrollBGHover = scrollBGHover
+	d.ScrollVisibleNormal = scrollVisibleNormal
+	d.ScrollVisibleHover = scrollVisibleHover
+	d.Gutter = gutter
+	d.FocusRing = focusRing
+	d.CommandMode = commandMode
+	d.VisualMode = visualMode
+
+	for _, img := range d.themeImages {
+		img.Free()
+	}
+	d.themeImages = allocated
+
+	d.Top.Draw = Dirty
+	// Handling the event that led to SetTheme may change Top.Draw to DirtyKid, the whole screen must be drawn.
+	d.uncovered = true
+	for _, o := range d.overlays {
+		o.Draw = Dirty
+	}
+	if d.tooltip != nil {
+		d.tooltip.Draw = Dirty
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package duit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/duittest"
+)
+
+func TestReadTheme(t *testing.T) {
+	th, err := duit.ReadTheme(strings.NewReader(`{"Base": "dark", "Gutter": "#11223344"}`))
+	if err != nil {
+		t.Fatalf("read theme: %s", err)
+	}
+	if th.Gutter != 0x11223344 || th.Background != duit.DarkTheme.Background {
+		t.Fatalf("theme gutter %x, background %x, expected 11223344 and dark background", th.Gutter, th.Background)
+	}
+	if _, err := duit.ReadTheme(strings.NewReader(`{"Base": "bogus"}`)); err == nil {
+		t.Fatalf("unknown base theme accepted")
+	}
+}
+
+func TestSetTheme(t *testing.T) {
+	button := &duit.Button{Text: "ok"}
+	dt := duittest.New(t, &duit.Box{Kids: duit.NewKids(button)}, &duit.DUIOpts{Dimensions: "100x50"})
+	defer dt.Close()
+
+	light := dt.Screenshot()
+	gutter := dt.DUI.Gutter
+	regular := dt.DUI.Regular.Normal.Background
+	if err := dt.DUI.SetTheme(duit.DarkTheme); err != nil {
+		t.Fatalf("set theme: %s", err)
+	}
+	if gutter.Display != nil || regular.Display != nil {
+		t.Fatalf("images of previous theme not freed")
+	}
+	dt.DUI.Render()
+	if _, n := duittest.Diff(light, dt.Screenshot()); n == 0 {
+		t.Fatalf("ui not drawn with new theme")
+	}
+	// Switching back from a callback, e.g. of a button, draws the whole screen.
+	button.Click = func() (e duit.Event) {
+		if err := dt.DUI.SetTheme(duit.LightTheme); err != nil {
+			t.Errorf("set theme: %s", err)
+		}
+		return
+	}
+	dt.Click(dt.Center(button))
+	if c, exp := dt.Screenshot().RGBAAt(90, 40), light.RGBAAt(90, 40); c != exp {
+		t.Fatalf("background %v after setting light theme again, expected %v", c, exp)
+	}
+}
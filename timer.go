@@ -0,0 +1,134 @@
+package duit
+
+import (
+	"image"
+	"time"
+)
+
+// Timer is a function scheduled to run in the main loop, created with DUI.After or DUI.Every.
+type Timer struct {
+	dui      *DUI
+	ui       UI
+	fn       func()
+	interval time.Duration // Zero for a timer that fires once.
+	t        *time.Timer
+	stopped  bool
+}
+
+// After schedules fn to be called once after duration, in the main loop, like functions sent on DUI.Call.
+// If ui is not nil, it is marked as needing a draw before fn is called.
+// If ui is no longer in the UI tree or an overlay by then, the timer stops without calling fn.
+// After must be called from the main loop, e.g. from a UI function or a function sent on DUI.Call.
+func (d *DUI) After(ui UI, duration time.Duration, fn func()) *Timer {
+	return d.startTimer(ui, duration, 0, fn)
+}
+
+// Every is like After, but calls fn every interval, until the timer is stopped or ui is no longer in the UI tree.
+// The next interval starts after fn returns, so calls do not pile up when the main loop is busy.
+// Every must be called from the main loop.
+func (d *DUI) Every(ui UI, interval time.Duration, fn func()) *Timer {
+	return d.startTimer(ui, interval, interval, fn)
+}
+
+// startTimer registers the timer in d.timers, which is only accessed from the main loop, so needs no lock.
+func (d *DUI) startTimer(ui UI, delay, interval time.Duration, fn func()) *Timer {
+	t := &Timer{dui: d, ui: ui, fn: fn, interval: interval}
+	t.t = time.AfterFunc(delay, func() {
+		d.call(t.fire)
+	})
+	d.timers[t] = struct{}{}
+	return t
+}
+
+// fire is called in the main loop when the timer expired.
+func (t *Timer) fire() {
+	if t.stopped {
+		return
+	}
+	if t.ui != nil && !t.dui.mark(t.ui, false) {
+		t.Stop()
+		return
+	}
+	if t.interval == 0 {
+		t.Stop()
+	}
+	t.fn()
+	if !t.stopped {
+		t.t.Reset(t.interval)
+	}
+}
+
+// Stop stops the timer. Its function is not called anymore, also not when the timer already expired but the function did not run yet.
+// Stop must be called from the main loop.
+func (t *Timer) Stop() {
+	if t.stopped {
+		return
+	}
+	t.stopped = true
+	t.t.Stop()
+	delete(t.dui.timers, t)
+}
+
+// Frame requests an animation frame for ui: at the next frame, ui is marked as needing a draw and fn is called in the main loop with the time of the frame.
+// Frames are DUI.FrameInterval apart. Only the latest request for a UI is kept. To keep animating, call Frame again from fn.
+// If ui is no longer in the UI tree or an overlay at the next frame, fn is not called.
+// Frame must be called from the main loop.
+func (d *DUI) Frame(ui UI, fn func(t time.Time)) {
+	if d.frames == nil {
+		d.frames = map[UI]func(time.Time){}
+	}
+	d.frames[ui] = fn
+	if d.frameTimer == nil {
+		d.frameTimer = d.After(nil, d.FrameInterval, d.frame)
+	}
+}
+
+// frame calls the functions for the pending frame requests.
+func (d *DUI) frame() {
+	d.frameTimer = nil
+	l := d.frames
+	d.frames = nil
+	now := time.Now()
+	for ui, fn := range l {
+		if d.mark(ui, false) {
+			fn(now)
+		}
+	}
+}
+
+// stopTimers stops all timers and frame requests, for closing the DUI.
+func (d *DUI) stopTimers() {
+	for t := range d.timers {
+		t.Stop()
+	}
+	d.frameTimer = nil
+	d.frames = nil
+	d.paused = nil
+}
+
+// inView returns whether part of r is visible. R is on the image a UI was drawn on: the child image of viewport, or the screen if viewport is nil.
+// Animating UIs remember the viewport (DUI.viewport during their draw) and their location to check later whether they are still in view.
+func (d *DUI) inView(viewport *Scroll, r image.Rectangle) bool {
+	if viewport == nil {
+		return r.Overlaps(d.Display.ScreenImage.R)
+	}
+	return viewport.inView(r)
+}
+
+// pause registers fn to be called after the next draw, for ui that stopped animating because it was out of view.
+// Scrolling is followed by a draw, after which the UI can check again whether it is in view.
+func (d *DUI) pause(ui UI, fn func()) {
+	if d.paused == nil {
+		d.paused = map[UI]func(){}
+	}
+	d.paused[ui] = fn
+}
+
+// resume calls the functions registered with pause.
+func (d *DUI) resume() {
+	l := d.paused
+	d.paused = nil
+	for _, fn := range l {
+		fn()
+	}
+}
@@ -0,0 +1,30 @@
+package duit_test
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/duittest"
+)
+
+func TestTimerAfterClose(t *testing.T) {
+	dt := duittest.New(t, &duit.Label{Text: "timers"}, &duit.DUIOpts{Dimensions: "100x50"})
+	n := runtime.NumGoroutine()
+
+	// Timers expire while the main loop is not reading DUI.Call, and the window is closed before it does.
+	for i := 0; i < 20; i++ {
+		dt.DUI.After(nil, time.Millisecond, func() {})
+	}
+	time.Sleep(20 * time.Millisecond)
+	dt.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines after close, expected at most %d", runtime.NumGoroutine(), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
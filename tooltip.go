@@ -0,0 +1,87 @@
+package duit
+
+import (
+	"image"
+
+	"9fans.net/go/draw"
+)
+
+// SetTooltip requests ui as tooltip for the current mouse position.
+// UIs call SetTooltip from their Mouse function, e.g. to show the full text of a truncated cell. Kids with a Tooltip get their tooltip without calling SetTooltip.
+// The tooltip is shown near the mouse after it hovered for TooltipDelay, as long as the same ui is requested. It is hidden when the mouse moves to a UI without this tooltip, on a button press, or a key press.
+func (d *DUI) SetTooltip(ui UI) {
+	d.tooltipNext = ui
+}
+
+// updateTooltip schedules the tooltip requested while delivering the latest mouse event, or hides the current tooltip.
+func (d *DUI) updateTooltip() {
+	ui := d.tooltipNext
+	if d.mouse.Buttons != 0 {
+		d.hideTooltip()
+		return
+	}
+	if ui == d.tooltipUI {
+		return
+	}
+	d.hideTooltip()
+	d.tooltipUI = ui
+	if ui == nil {
+		return
+	}
+	d.tooltipTimer = d.After(nil, d.TooltipDelay, func() {
+		if d.tooltipUI == ui && d.tooltip == nil {
+			d.showTooltip(ui)
+		}
+	})
+}
+
+// showTooltip shows ui in an overlay below the mouse.
+func (d *DUI) showTooltip(ui UI) {
+	d.tooltip = &Overlay{
+		Kid: Kid{UI: &tooltip{popup{Kid: Kid{UI: ui}}}},
+		At:  d.mouse.Point.Add(image.Pt(0, d.Scale(20))),
+	}
+}
+
+// hideTooltip removes a tooltip that is shown or scheduled.
+// The tooltip is not scheduled again until the mouse moves to another UI with a tooltip.
+func (d *DUI) hideTooltip() {
+	if d.tooltipTimer != nil {
+		d.tooltipTimer.Stop()
+		d.tooltipTimer = nil
+	}
+	if d.tooltip != nil {
+		d.tooltip = nil
+		d.uncovered = true
+	}
+}
+
+// tooltip draws a UI like popup, but receives no input: mouse and key events are not delivered to the UI, and it cannot get focus.
+type tooltip struct {
+	popup
+}
+
+func (ui *tooltip) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	return
+}
+
+func (ui *tooltip) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	return
+}
+
+func (ui *tooltip) FirstFocus(dui *DUI, self *Kid) *image.Point {
+	return nil
+}
+
+func (ui *tooltip) LastFocus(dui *DUI, self *Kid) *image.Point {
+	return nil
+}
+
+func (ui *tooltip) Focus(dui *DUI, self *Kid, o UI) *image.Point {
+	return nil
+}
+
+func (ui *tooltip) Print(self *Kid, indent int) {
+	PrintUI("tooltip", self, indent)
+	ui.Kid.UI.Print(&ui.Kid, indent+1)
+}
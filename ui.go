@@ -42,13 +42,18 @@ type UI interface {
 	// See the Key-constants in the draw library for use special keys like the arrow keys,
 	// function keys and combinations with the cmd key.
 	// `m` is the mouse location at the time of the key, relative to this UIs zero point.
+	// If a UI has keyboard focus, m is set to its location, see DUI.Focused.
 	// `orig` is the origin location of this UI. If you want to warp the mouse, add the origin to the UI-relative point.
 	Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result)
 
 	// FirstFocus returns where the focus should go next when "tab" is hit, if anything.
 	FirstFocus(dui *DUI, self *Kid) (warp *image.Point)
 
+	// LastFocus is like FirstFocus, but for moving focus backwards with DUI.BackTab, returning the last location that can get focus, if any.
+	LastFocus(dui *DUI, self *Kid) (warp *image.Point)
+
 	// Focus returns the focus-point for `ui`.
+	// DUI also uses it to find where to deliver keys for the UI with keyboard focus, so it must not return nil while ui is in this subtree.
 	Focus(dui *DUI, self *Kid, o UI) (warp *image.Point)
 
 	// Mark looks for ui (itself or children), marks it as dirty for layout or draw (forLayout),
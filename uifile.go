@@ -0,0 +1,184 @@
+package duit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ReadUI reads a UI description from r, returning the UI tree and the UIs of the kids that have an ID, by ID.
+//
+// A UI description is a JSON object for a kid, e.g.:
+//
+//	{
+//		"Type": "Box",
+//		"Padding": {"Top": 4, "Right": 6, "Bottom": 4, "Left": 6},
+//		"Kids": [
+//			{"Type": "Label", "Text": "Name"},
+//			{"Type": "Field", "ID": "name"},
+//			{"Type": "Button", "ID": "save", "Text": "Save"}
+//		]
+//	}
+//
+// Type is the name of the UI type, as registered with RegisterUI, e.g. "*main.MyUI". For the UIs of this package, the name of the type is enough, e.g. "Box".
+// ID and TabIndex are set on the Kid. All other fields are the fields of the UI, as read with encoding/json.
+// Fields holding kids or UIs, e.g. Kids of Box, Kid of Scroll and UIs of Tabs, hold UI descriptions themselves.
+// Functions such as Click are set by the caller, finding the UIs by ID.
+func ReadUI(r io.Reader) (top *Kid, ids map[string]UI, err error) {
+	top, err = readUI(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return top, uiIDs(top), nil
+}
+
+// ReadUIPath is a convenience function that opens path and calls ReadUI.
+func ReadUIPath(path string) (top *Kid, ids map[string]UI, err error) {
+	top, err = readUIPath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return top, uiIDs(top), nil
+}
+
+func readUI(r io.Reader) (top *Kid, err error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("reading ui description: %s", err)
+	}
+	buf, err := json.Marshal(convertUIDesc(v))
+	if err != nil {
+		return nil, err
+	}
+	top = &Kid{}
+	if err := json.Unmarshal(buf, top); err != nil {
+		return nil, fmt.Errorf("reading ui description: %s", err)
+	}
+	return top, nil
+}
+
+// convertUIDesc returns v from a UI description in the JSON format of Kid.
+func convertUIDesc(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		cm := map[string]interface{}{}
+		for k, e := range x {
+			cm[k] = convertUIDesc(e)
+		}
+		t, ok := x["Type"].(string)
+		if !ok {
+			return cm
+		}
+		if _, ok := uiTypes[t]; !ok {
+			if _, ok := uiTypes["*duit."+t]; ok {
+				t = "*duit." + t
+			}
+		}
+		kid := map[string]interface{}{"Type": t}
+		for _, k := range []string{"ID", "TabIndex"} {
+			if e, ok := cm[k]; ok {
+				kid[k] = e
+				delete(cm, k)
+			}
+		}
+		delete(cm, "Type")
+		kid["UI"] = cm
+		return kid
+	case []interface{}:
+		cl := make([]interface{}, len(x))
+		for i, e := range x {
+			cl[i] = convertUIDesc(e)
+		}
+		return cl
+	}
+	return v
+}
+
+func uiIDs(top *Kid) map[string]UI {
+	ids := map[string]UI{}
+	for id, k := range KidsByID(top) {
+		ids[id] = k.UI
+	}
+	return ids
+}
+
+// LoadUI reads the UI description file at path with ReadUIPath, calls loaded, and makes the UI the Top UI.
+// Loaded is typically used to set functions like Click on the UIs in ids.
+//
+// If watch is set, e.g. during development, the file is checked for changes until the DUI is closed.
+// The file is polled every second by comparing its modification time and size, also while it does not change, so watch is meant for development.
+// A changed file is read again, loaded is called for the new UI tree, and the new UI replaces the Top UI.
+// For kids with an ID that is also in the previous UI tree, and whose UI implements StateKeeper, the new UI gets the state of the old UI, such as the text of a Field, a scroll offset, or the selected tab.
+// The state is kept also if the fields of the UI changed in the file. KeepState only takes state that still fits, e.g. not a selected tab that no longer exists.
+// Errors while reading a changed file are sent to DUI.Error, the old UI stays in place.
+func (d *DUI) LoadUI(path string, watch bool, loaded func(top *Kid, ids map[string]UI)) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	top, err := readUIPath(path)
+	if err != nil {
+		return err
+	}
+	d.setUI(top, loaded)
+	if !watch {
+		return nil
+	}
+
+	d.Every(nil, time.Second, func() {
+		nfi, err := os.Stat(path)
+		if d.error(err, "checking ui description for changes") || nfi.ModTime().Equal(fi.ModTime()) && nfi.Size() == fi.Size() {
+			return
+		}
+		fi = nfi
+		ntop, err := readUIPath(path)
+		if d.error(err, "reloading ui description") {
+			return
+		}
+		old := KidsByID(top)
+		for id, k := range KidsByID(ntop) {
+			if o := old[id]; o != nil {
+				keepState(o.UI, k.UI)
+			}
+		}
+		top = ntop
+		d.setUI(top, loaded)
+	})
+	return nil
+}
+
+func readUIPath(path string) (*Kid, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %s", path, err)
+	}
+	defer f.Close()
+	return readUI(f)
+}
+
+// setUI makes top the Top UI, after calling loaded.
+func (d *DUI) setUI(top *Kid, loaded func(top *Kid, ids map[string]UI)) {
+	if loaded != nil {
+		loaded(top, uiIDs(top))
+	}
+	d.Top.UI = top.UI
+	d.Top.ID = top.ID
+	d.MarkLayout(nil)
+}
+
+// StateKeeper is implemented by UIs with state changed by the user, such as the text of a Field, to keep that state when LoadUI reads a changed UI description file.
+type StateKeeper interface {
+	// KeepState copies the state of old, the UI of the same type with the same ID in the previous UI tree, into the UI.
+	KeepState(old UI)
+}
+
+// keepState calls KeepState on nui if it is a StateKeeper.
+func keepState(old, nui UI) {
+	if sk, ok := nui.(StateKeeper); ok && old != nui {
+		sk.KeepState(old)
+	}
+}
@@ -0,0 +1,105 @@
+package duit_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/duittest"
+)
+
+const testUIFile = `{
+	"Type": "Box",
+	"Kids": [
+		{"Type": "Field", "ID": "name"},
+		{"Type": "Field", "ID": "other", "Placeholder": "before"},
+		{"Type": "Checkbox", "ID": "subscribe"},
+		{"Type": "Button", "ID": "save", "Text": "Save"}
+	]
+}`
+
+func TestReadUI(t *testing.T) {
+	top, ids, err := duit.ReadUI(strings.NewReader(testUIFile))
+	if err != nil {
+		t.Fatalf("read ui: %s", err)
+	}
+	if _, ok := top.UI.(*duit.Box); !ok {
+		t.Fatalf("top is %T, expected *duit.Box", top.UI)
+	}
+	if b, ok := ids["save"].(*duit.Button); !ok || b.Text != "Save" {
+		t.Fatalf("save is %#v, expected button", ids["save"])
+	}
+	if len(ids) != 4 {
+		t.Fatalf("got %d ids, expected 4", len(ids))
+	}
+
+	if _, _, err := duit.ReadUI(strings.NewReader(`{"Type": "Bogus"}`)); err == nil {
+		t.Fatalf("unknown type accepted")
+	}
+}
+
+func TestLoadUIKeepState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "duit")
+	if err != nil {
+		t.Fatalf("tempdir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ui.json")
+	if err := ioutil.WriteFile(path, []byte(testUIFile), 0666); err != nil {
+		t.Fatalf("write: %s", err)
+	}
+
+	dt := duittest.New(t, &duit.Label{}, &duit.DUIOpts{Dimensions: "300x200"})
+	defer dt.Close()
+	var ids map[string]duit.UI
+	loaded := func(top *duit.Kid, nids map[string]duit.UI) {
+		ids = nids
+	}
+	if err := dt.DUI.LoadUI(path, true, loaded); err != nil {
+		t.Fatalf("load ui: %s", err)
+	}
+	dt.DUI.Render()
+
+	name := ids["name"].(*duit.Field)
+	other := ids["other"].(*duit.Field)
+	dt.Click(dt.Center(name))
+	dt.Type("x")
+	dt.Click(dt.Center(other))
+	dt.Type("y")
+	dt.Click(dt.Center(ids["subscribe"]))
+
+	// Change the button and the placeholder of the other field. The other field keeps its state, state is kept by ID.
+	buf := strings.Replace(strings.Replace(testUIFile, "Save", "Store", 1), "before", "after", 1)
+	if err := ioutil.WriteFile(path, []byte(buf), 0666); err != nil {
+		t.Fatalf("write: %s", err)
+	}
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(path, future, future)
+	top := dt.DUI.Top.UI
+	deadline := time.After(5 * time.Second)
+	for dt.DUI.Top.UI == top {
+		select {
+		case e := <-dt.DUI.Inputs:
+			dt.DUI.Input(e)
+		case <-deadline:
+			t.Fatalf("ui not reloaded")
+		}
+	}
+
+	if f := ids["name"].(*duit.Field); f == name || f.Text != "x" {
+		t.Fatalf("name field %q after reload, expected new field with %q", f.Text, "x")
+	}
+	if f := ids["other"].(*duit.Field); f == other || f.Text != "y" || f.Placeholder != "after" {
+		t.Fatalf("changed field with text %q and placeholder %q, expected new field with %q and %q", f.Text, f.Placeholder, "y", "after")
+	}
+	if c := ids["subscribe"].(*duit.Checkbox); !c.Checked {
+		t.Fatalf("checkbox not kept checked")
+	}
+	if b := ids["save"].(*duit.Button); b.Text != "Store" {
+		t.Fatalf("button text %q, expected %q", b.Text, "Store")
+	}
+}
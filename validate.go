@@ -0,0 +1,414 @@
+package duit
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"9fans.net/go/draw"
+)
+
+// Validator checks the text of a field, returning an error with a message for the user if the text is not valid.
+// Any function with this signature can be used as custom validator.
+type Validator func(text string) error
+
+// ValidateRequired returns a validator that fails with msg for empty text, or text with only whitespace.
+func ValidateRequired(msg string) Validator {
+	return func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return errors.New(msg)
+		}
+		return nil
+	}
+}
+
+// ValidateRegexp returns a validator that fails with msg for text not matching re.
+// Empty text is valid, combine with ValidateRequired for required fields.
+func ValidateRegexp(re *regexp.Regexp, msg string) Validator {
+	return func(text string) error {
+		if text != "" && !re.MatchString(text) {
+			return errors.New(msg)
+		}
+		return nil
+	}
+}
+
+// ValidateRange returns a validator that fails for text that is not a number between min and max, inclusive.
+// Empty text is valid, combine with ValidateRequired for required fields.
+func ValidateRange(min, max float64) Validator {
+	return func(text string) error {
+		if text == "" {
+			return nil
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		if v < min || v > max {
+			return fmt.Errorf("must be between %v and %v", min, max)
+		}
+		return nil
+	}
+}
+
+// Validation checks the text of a Field with validators, and shows the first error: the field gets a border in DUI.Danger colors, with the error message below it.
+// Errors are shown after the user changed the text, or after submitting the Form that holds the validation.
+// After changing the text of the field programmatically, mark the validation for layout to validate again.
+type Validation struct {
+	Field      *Field      // Field to validate.
+	Validators []Validator `json:"-"` // Checked in order, the first error is shown.
+
+	shown    bool  // Whether errors are shown.
+	err      error // Result of the last validation.
+	fieldKid Kid
+	kids     []*Kid
+	size     image.Point
+}
+
+var _ UI = &Validation{}
+
+// Validate returns the first error of the validators for the current text of the field, without showing it.
+func (ui *Validation) Validate() error {
+	if ui.Field == nil {
+		return nil
+	}
+	for _, v := range ui.Validators {
+		if err := v(ui.Field.Text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Valid returns whether the current text of the field is valid.
+func (ui *Validation) Valid() bool {
+	return ui.Validate() == nil
+}
+
+// ensure sets up the kid holding Field. Without Field, there are no kids: the validation draws nothing and is valid.
+func (ui *Validation) ensure() {
+	if ui.Field == nil {
+		ui.fieldKid = Kid{}
+		ui.kids = nil
+		return
+	}
+	if len(ui.kids) != 1 || ui.fieldKid.UI != ui.Field {
+		ui.fieldKid = Kid{UI: ui.Field}
+		ui.kids = []*Kid{&ui.fieldKid}
+	}
+}
+
+// show validates again and shows the error from now on, returning whether the error shown changed and the validation needs a layout.
+func (ui *Validation) show() bool {
+	old := ui.message()
+	ui.shown = true
+	ui.err = ui.Validate()
+	if ui.Field != nil {
+		ui.Field.Invalid = ui.err != nil
+	}
+	return ui.message() != old
+}
+
+func (ui *Validation) message() string {
+	if ui.shown && ui.err != nil {
+		return ui.err.Error()
+	}
+	return ""
+}
+
+func (ui *Validation) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	ui.ensure()
+	dui.debugLayout(self)
+
+	if ui.Field == nil {
+		ui.size = image.ZP
+		self.R = image.ZR
+		return
+	}
+	if KidsLayout(dui, self, ui.kids, force) {
+		return
+	}
+
+	ui.err = ui.Validate()
+	ui.Field.Invalid = ui.shown && ui.err != nil
+	ui.fieldKid.UI.Layout(dui, &ui.fieldKid, sizeAvail, true)
+	ui.size = ui.fieldKid.R.Size()
+	if ui.message() != "" {
+		ui.size.Y += dui.Scale(2) + dui.Font(ui.Field.Font).Height
+	}
+	self.R = rect(ui.size)
+}
+
+func (ui *Validation) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	ui.ensure()
+	dui.debugDraw(self)
+
+	if ui.Field == nil {
+		return
+	}
+	force = force || self.Draw == Dirty
+	KidsDraw(dui, self, ui.kids, ui.size, nil, img, orig, m, force)
+	if msg := ui.message(); msg != "" && force {
+		p := orig.Add(image.Pt(0, ui.fieldKid.R.Dy()+dui.Scale(2)))
+		img.String(p, dui.Danger.Normal.Background, image.ZP, dui.Font(ui.Field.Font), msg)
+	}
+}
+
+func (ui *Validation) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	ui.ensure()
+	if ui.Field == nil {
+		return
+	}
+	text := ui.Field.Text
+	r = KidsMouse(dui, self, ui.kids, m, origM, orig)
+	if ui.Field.Text != text && ui.show() {
+		self.Layout = Dirty
+	}
+	return
+}
+
+func (ui *Validation) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	ui.ensure()
+	if ui.Field == nil {
+		return
+	}
+	text := ui.Field.Text
+	r = KidsKey(dui, self, ui.kids, k, m, orig)
+	if ui.Field.Text != text && ui.show() {
+		self.Layout = Dirty
+	}
+	return
+}
+
+func (ui *Validation) FirstFocus(dui *DUI, self *Kid) (warp *image.Point) {
+	ui.ensure()
+	return KidsFirstFocus(dui, self, ui.kids)
+}
+
+func (ui *Validation) LastFocus(dui *DUI, self *Kid) (warp *image.Point) {
+	ui.ensure()
+	return KidsLastFocus(dui, self, ui.kids)
+}
+
+func (ui *Validation) Focus(dui *DUI, self *Kid, o UI) (warp *image.Point) {
+	ui.ensure()
+	return KidsFocus(dui, self, ui.kids, o)
+}
+
+func (ui *Validation) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	ui.ensure()
+	return KidsMark(self, ui.kids, o, forLayout)
+}
+
+func (ui *Validation) Children() []*Kid {
+	ui.ensure()
+	return ui.kids
+}
+
+func (ui *Validation) Print(self *Kid, indent int) {
+	ui.ensure()
+	PrintUI("Validation", self, indent)
+	KidsPrint(ui.kids, indent+1)
+}
+
+// Access returns the node of the field, with the error shown as description, see Accessible.
+func (ui *Validation) Access(dui *DUI, self *Kid, orig image.Point) *AccessNode {
+	ui.ensure()
+	if ui.Field == nil {
+		return &AccessNode{Role: RoleGroup}
+	}
+	n := dui.AccessKid(&ui.fieldKid, orig.Add(ui.fieldKid.R.Min))
+	if msg := ui.message(); msg != "" {
+		n.Description = msg
+	}
+	return n
+}
+
+// Form holds a UI with fields to fill in, with Validations for the fields and a button to submit.
+// The submit button is disabled while any of the fields is invalid.
+// Hitting return in a field submits the form, showing the errors of all fields and focusing the first invalid field, or calling Submitted if all fields are valid.
+type Form struct {
+	Kid       Kid              // UI with the fields, validations and the submit button, e.g. a Box or Grid.
+	Submit    *Button          `json:"-"` // Submit button, somewhere in Kid. Form replaces its Click at the first layout, a Click set before is called before submitting. Set after reading a UI from JSON.
+	Submitted func() (e Event) `json:"-"` // Called when the form is submitted and all fields are valid.
+
+	validations []*Validation    // Validations shown in Kid, in order, found at layout.
+	clickFor    *Button          // Button whose Click was replaced by submitClick.
+	click       func() (e Event) // Click of clickFor before it was replaced, restored when Submit changes.
+	clicked     bool             // Set when the submit button was clicked, while delivering a mouse or key event.
+	kids        []*Kid
+}
+
+var _ UI = &Form{}
+
+func (ui *Form) ensure() {
+	if len(ui.kids) != 1 {
+		ui.kids = []*Kid{&ui.Kid}
+	}
+	if ui.clickFor != ui.Submit {
+		if ui.clickFor != nil {
+			ui.clickFor.Click = ui.click
+		}
+		ui.clickFor = ui.Submit
+		ui.click = nil
+		if ui.Submit != nil {
+			ui.click = ui.Submit.Click
+			ui.Submit.Click = ui.submitClick
+		}
+	}
+}
+
+// submitClick is the Click of the submit button. It calls the Click the button had before, and marks the form for submitting.
+func (ui *Form) submitClick() (e Event) {
+	if ui.click != nil {
+		e = ui.click()
+	}
+	ui.clicked = true
+	return
+}
+
+// findValidations returns the validations in the UI tree of k, not looking into validations.
+func findValidations(k *Kid) (l []*Validation) {
+	if v, ok := k.UI.(*Validation); ok {
+		return []*Validation{v}
+	}
+	for _, c := range kidsOf(k.UI) {
+		l = append(l, findValidations(c)...)
+	}
+	return
+}
+
+// Valid returns whether all fields are valid.
+func (ui *Form) Valid() bool {
+	for _, v := range findValidations(&ui.Kid) {
+		if !v.Valid() {
+			return false
+		}
+	}
+	return true
+}
+
+// invalid returns whether any of the validations found at layout is invalid.
+func (ui *Form) invalid() bool {
+	for _, v := range ui.validations {
+		if !v.Valid() {
+			return true
+		}
+	}
+	return false
+}
+
+// update disables the submit button if a field is invalid, or enables it.
+func (ui *Form) update(dui *DUI) {
+	if ui.Submit != nil && ui.Submit.Disabled != ui.invalid() {
+		ui.Submit.Disabled = !ui.Submit.Disabled
+		dui.MarkDraw(ui.Submit)
+	}
+}
+
+// submit shows the errors of all fields, and focuses the first invalid field through r.Warp or calls Submitted.
+func (ui *Form) submit(dui *DUI, self *Kid, orig image.Point, r *Result) {
+	var first *Validation
+	for _, v := range ui.validations {
+		if v.show() {
+			dui.MarkLayout(v)
+		}
+		if v.err != nil && first == nil {
+			first = v
+		}
+	}
+	if first != nil {
+		if p := ui.Focus(dui, self, first.Field); p != nil {
+			pp := p.Add(orig)
+			r.Warp = &pp
+			r.Hit = first.Field
+		}
+		r.Consumed = true
+		return
+	}
+	if ui.Submitted != nil {
+		e := ui.Submitted()
+		propagateEvent(self, r, e)
+	}
+	r.Consumed = true
+}
+
+func (ui *Form) Layout(dui *DUI, self *Kid, sizeAvail image.Point, force bool) {
+	ui.ensure()
+	dui.debugLayout(self)
+
+	if KidsLayout(dui, self, ui.kids, force) {
+		return
+	}
+
+	ui.validations = findValidations(&ui.Kid)
+	if ui.Submit != nil {
+		ui.Submit.Disabled = ui.invalid()
+	}
+	ui.Kid.UI.Layout(dui, &ui.Kid, sizeAvail, true)
+	self.R = rect(ui.Kid.R.Size())
+}
+
+func (ui *Form) Draw(dui *DUI, self *Kid, img *draw.Image, orig image.Point, m draw.Mouse, force bool) {
+	ui.ensure()
+	dui.debugDraw(self)
+	KidsDraw(dui, self, ui.kids, self.R.Size(), nil, img, orig, m, force)
+}
+
+func (ui *Form) Mouse(dui *DUI, self *Kid, m draw.Mouse, origM draw.Mouse, orig image.Point) (r Result) {
+	ui.ensure()
+	ui.clicked = false
+	r = KidsMouse(dui, self, ui.kids, m, origM, orig)
+	ui.update(dui)
+	if ui.clicked {
+		ui.clicked = false
+		ui.submit(dui, self, orig, &r)
+	}
+	return
+}
+
+func (ui *Form) Key(dui *DUI, self *Kid, k rune, m draw.Mouse, orig image.Point) (r Result) {
+	ui.ensure()
+	ui.clicked = false
+	r = KidsKey(dui, self, ui.kids, k, m, orig)
+	ui.update(dui)
+	if ui.clicked || !r.Consumed && k == '\n' {
+		ui.clicked = false
+		ui.submit(dui, self, orig, &r)
+	}
+	return
+}
+
+func (ui *Form) FirstFocus(dui *DUI, self *Kid) (warp *image.Point) {
+	ui.ensure()
+	return KidsFirstFocus(dui, self, ui.kids)
+}
+
+func (ui *Form) LastFocus(dui *DUI, self *Kid) (warp *image.Point) {
+	ui.ensure()
+	return KidsLastFocus(dui, self, ui.kids)
+}
+
+func (ui *Form) Focus(dui *DUI, self *Kid, o UI) (warp *image.Point) {
+	ui.ensure()
+	return KidsFocus(dui, self, ui.kids, o)
+}
+
+func (ui *Form) Mark(self *Kid, o UI, forLayout bool) (marked bool) {
+	ui.ensure()
+	return KidsMark(self, ui.kids, o, forLayout)
+}
+
+func (ui *Form) Children() []*Kid {
+	ui.ensure()
+	return ui.kids
+}
+
+func (ui *Form) Print(self *Kid, indent int) {
+	ui.ensure()
+	PrintUI("Form", self, indent)
+	KidsPrint(ui.kids, indent+1)
+}
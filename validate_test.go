@@ -0,0 +1,111 @@
+package duit_test
+
+import (
+	"testing"
+
+	"github.com/mjl-/duit"
+	"github.com/mjl-/duit/duittest"
+)
+
+func TestValidators(t *testing.T) {
+	if err := duit.ValidateRequired("")(""); err == nil {
+		t.Fatalf("empty text valid for required")
+	}
+	if err := duit.ValidateRange(1, 10)("11"); err == nil {
+		t.Fatalf("11 valid for range 1-10")
+	}
+	if err := duit.ValidateRange(1, 10)("5"); err != nil {
+		t.Fatalf("5 invalid for range 1-10: %s", err)
+	}
+}
+
+func TestForm(t *testing.T) {
+	first := &duit.Field{}
+	second := &duit.Field{}
+	clicks, submits := 0, 0
+	submit := &duit.Button{Text: "submit", Click: func() (e duit.Event) {
+		clicks++
+		return
+	}}
+	form := &duit.Form{
+		Kid: duit.Kid{UI: &duit.Box{Kids: duit.NewKids(
+			&duit.Validation{Field: first, Validators: []duit.Validator{duit.ValidateRequired("")}},
+			&duit.Validation{Field: second, Validators: []duit.Validator{duit.ValidateRequired("")}},
+			submit,
+		)}},
+		Submit: submit,
+		Submitted: func() (e duit.Event) {
+			submits++
+			return
+		},
+	}
+	dt := duittest.New(t, form, &duit.DUIOpts{Dimensions: "300x200"})
+	defer dt.Close()
+
+	if form.Valid() || !submit.Disabled {
+		t.Fatalf("form with empty required fields valid, or submit enabled")
+	}
+
+	// Return in the second field focuses the first invalid field.
+	dt.Click(dt.Center(second))
+	dt.Type("x\n")
+	if submits != 0 {
+		t.Fatalf("invalid form submitted")
+	}
+	dt.Type("y")
+	if first.Text != "y" || second.Text != "x" {
+		t.Fatalf("fields %q and %q, expected %q and %q", first.Text, second.Text, "y", "x")
+	}
+	if !form.Valid() || submit.Disabled {
+		t.Fatalf("form with filled in fields invalid, or submit disabled")
+	}
+
+	// The Click of the button set before is still called.
+	dt.Click(dt.Center(submit))
+	if clicks != 1 || submits != 1 {
+		t.Fatalf("got %d clicks and %d submits, expected 1 each", clicks, submits)
+	}
+	dt.Type("\n")
+	if submits != 2 {
+		t.Fatalf("got %d submits after return, expected 2", submits)
+	}
+}
+
+func TestFormSubmitChange(t *testing.T) {
+	clicks, submits := 0, 0
+	first := &duit.Button{Text: "first", Click: func() (e duit.Event) {
+		clicks++
+		return
+	}}
+	second := &duit.Button{Text: "second"}
+	form := &duit.Form{
+		Kid: duit.Kid{UI: &duit.Box{Kids: duit.NewKids(
+			&duit.Validation{},
+			first,
+			second,
+		)}},
+		Submit: first,
+		Submitted: func() (e duit.Event) {
+			submits++
+			return
+		},
+	}
+	dt := duittest.New(t, form, &duit.DUIOpts{Dimensions: "300x200"})
+	defer dt.Close()
+
+	// Switching the submit button and back, the original Click is called once, and the other button no longer submits.
+	form.Submit = second
+	dt.DUI.MarkLayout(form)
+	dt.DUI.Render()
+	form.Submit = first
+	dt.DUI.MarkLayout(form)
+	dt.DUI.Render()
+	dt.Click(dt.Center(first))
+	if clicks != 1 || submits != 1 {
+		t.Fatalf("got %d clicks and %d submits, expected 1 each", clicks, submits)
+	}
+	dt.Click(dt.Center(second))
+	if submits != 1 {
+		t.Fatalf("got %d submits after clicking other button, expected 1", submits)
+	}
+}